@@ -0,0 +1,28 @@
+// Command backfill_conversations gives every direct message stored before
+// conversation IDs existed its deterministic conversation ID. Run it once
+// against each database after deploying conversation IDs:
+//
+//	go run ./cmd/backfill_conversations
+package main
+
+import (
+	"fmt"
+	"log"
+
+	mongo_db "github.com/Ahmeds-Library/Chat-App/internal/database/Mongo_DB"
+)
+
+const batchSize = 500
+
+func main() {
+	if err := mongo_db.ConnectMongoDatabase(); err != nil {
+		log.Fatal("❌ Mongo connection failed: ", err)
+	}
+
+	updated, err := mongo_db.BackfillConversationIDs(mongo_db.MongoClient.Database("chat-app"), batchSize)
+	if err != nil {
+		log.Fatalf("❌ Backfill failed after %d messages: %v", updated, err)
+	}
+
+	fmt.Printf("✅ Backfilled conversation IDs on %d messages\n", updated)
+}
@@ -1,21 +1,47 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"log"
 
+	message_handler "github.com/Ahmeds-Library/Chat-App/internal/api/mesage_handler"
 	mongo_db "github.com/Ahmeds-Library/Chat-App/internal/database/Mongo_DB"
 	pg_admin "github.com/Ahmeds-Library/Chat-App/internal/database/Pg_Admin"
+	"github.com/Ahmeds-Library/Chat-App/internal/middleware"
+	"github.com/Ahmeds-Library/Chat-App/internal/otp"
 	"github.com/Ahmeds-Library/Chat-App/internal/routes"
+	"github.com/Ahmeds-Library/Chat-App/internal/storage"
 	"github.com/gin-gonic/gin"
 )
 
 func main() {
 	pg_admin.ConnectPgAdminDatabase()
+	if err := pg_admin.EnsureSchema(); err != nil {
+		log.Fatal("❌ Postgres schema update failed: ", err)
+	}
 	err := mongo_db.ConnectMongoDatabase()
 	if err != nil {
 		log.Fatal("❌ Mongo connection failed: ", err)
 	}
+	if err := mongo_db.EnsureIndexes(); err != nil {
+		log.Fatal("❌ Mongo index creation failed: ", err)
+	}
+	middleware.Keys, err = middleware.NewKeySetFromEnv()
+	if err != nil {
+		log.Fatal("❌ JWT key setup failed: ", err)
+	}
+	middleware.CheckSession = pg_admin.UseSession
+	storage.Blobs, err = storage.NewBlobStoreFromEnv()
+	if err != nil {
+		log.Fatal("❌ Blob store setup failed: ", err)
+	}
+	otp.Sender, err = otp.NewSenderFromEnv()
+	if err != nil {
+		log.Fatal("❌ SMS sender setup failed: ", err)
+	}
+
+	go message_handler.SweepAttachments(context.Background())
 
 	fmt.Println("Server starting...")
 	r := gin.Default()
@@ -0,0 +1,16 @@
+package auth_handler
+
+import (
+	"net/http"
+
+	"github.com/Ahmeds-Library/Chat-App/internal/middleware"
+	"github.com/gin-gonic/gin"
+)
+
+// JWKS publishes the public keys tokens are signed with, so other services
+// can verify tokens without holding a secret. Verifiers should fetch it again
+// when they meet an unknown kid.
+func JWKS(c *gin.Context) {
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(http.StatusOK, middleware.Keys.JWKS())
+}
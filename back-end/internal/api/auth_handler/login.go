@@ -4,7 +4,6 @@ import (
 	"net/http"
 
 	pg_admin "github.com/Ahmeds-Library/Chat-App/internal/database/Pg_Admin"
-	"github.com/Ahmeds-Library/Chat-App/internal/middleware"
 	"github.com/Ahmeds-Library/Chat-App/internal/models"
 	"github.com/gin-gonic/gin"
 	"golang.org/x/crypto/bcrypt"
@@ -18,7 +17,7 @@ func Login(c *gin.Context) {
 		return
 	}
 
-	dbPassword, dbNumber, dbID, err := pg_admin.GetUserCredentials(u.Username)
+	dbPassword, dbNumber, dbID, verified, err := pg_admin.GetUserCredentials(u.Username)
 	if err != nil {
 		if err.Error() == "user not found" {
 			c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found", "details": err.Error()})
@@ -38,13 +37,12 @@ func Login(c *gin.Context) {
 		return
 	}
 
-	refreshtoken, err := middleware.Create_Refresh_Token(dbID, u.Username, u.Number)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Unable to create token", "details": err.Error()})
+	if !verified {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Phone number not verified", "details": "Enter the code sent to your number"})
 		return
 	}
 
-	accesstoken, err := middleware.Create_Access_Token(dbID)
+	refreshtoken, accesstoken, err := startSession(c, dbID, u.Username, u.Number)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Unable to create token", "details": err.Error()})
 		return
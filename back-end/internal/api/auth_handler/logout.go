@@ -0,0 +1,41 @@
+package auth_handler
+
+import (
+	"errors"
+	"net/http"
+
+	pg_admin "github.com/Ahmeds-Library/Chat-App/internal/database/Pg_Admin"
+	"github.com/gin-gonic/gin"
+)
+
+// Logout ends the caller's session. Its refresh token stops working and its
+// websocket connections are closed.
+func Logout(c *gin.Context) {
+	userID, sessionID, ok := accessSession(c)
+	if !ok {
+		return
+	}
+
+	err := pg_admin.RevokeSession(userID, sessionID)
+	if err != nil && !errors.Is(err, pg_admin.ErrSessionNotFound) {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Logged out"})
+}
+
+// LogoutAll ends every session of the caller, on every device.
+func LogoutAll(c *gin.Context) {
+	userID, _, ok := accessSession(c)
+	if !ok {
+		return
+	}
+
+	if err := pg_admin.RevokeUserSessions(userID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Logged out of all sessions"})
+}
@@ -0,0 +1,105 @@
+package auth_handler
+
+import (
+	"errors"
+	"net/http"
+
+	pg_admin "github.com/Ahmeds-Library/Chat-App/internal/database/Pg_Admin"
+	"github.com/Ahmeds-Library/Chat-App/internal/models"
+	"github.com/Ahmeds-Library/Chat-App/internal/otp"
+	"github.com/gin-gonic/gin"
+)
+
+// ChangePassword sets a new password for the caller and logs out their other
+// sessions.
+func ChangePassword(c *gin.Context) {
+	userID, sessionID, ok := accessSession(c)
+	if !ok {
+		return
+	}
+
+	var req models.Change_Password
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input", "details": err.Error()})
+		return
+	}
+
+	matches, err := accounts.CheckPassword(userID, req.Old_Password)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error", "details": err.Error()})
+		return
+	}
+	if !matches {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Incorrect password"})
+		return
+	}
+
+	if err := accounts.SetPassword(userID, req.New_Password, sessionID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Password changed"})
+}
+
+// ForgotPassword texts a reset code for the account to its verified number.
+func ForgotPassword(c *gin.Context) {
+	var req models.Forgot_Password
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input", "details": err.Error()})
+		return
+	}
+
+	userID, ok := verifiedUser(c, req.Username, req.Number)
+	if !ok {
+		return
+	}
+
+	if err := otp.Send(userID, req.Number, otp.PurposeReset); err != nil {
+		respondOTPError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Reset code sent"})
+}
+
+// ResetPassword sets a new password using the code from ForgotPassword and
+// logs the user out everywhere.
+func ResetPassword(c *gin.Context) {
+	var req models.Reset_Password
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input", "details": err.Error()})
+		return
+	}
+
+	userID, ok := verifiedUser(c, req.Username, req.Number)
+	if !ok {
+		return
+	}
+
+	if err := otp.Verify(userID, req.Number, otp.PurposeReset, req.Code); err != nil {
+		respondOTPError(c, err)
+		return
+	}
+	if err := accounts.SetPassword(userID, req.New_Password, ""); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Password reset, log in with your new password"})
+}
+
+// verifiedUser returns the ID of the account with the username if it has
+// confirmed the number, writing the error response itself otherwise.
+func verifiedUser(c *gin.Context, username, number string) (string, bool) {
+	userID, err := accounts.GetVerifiedUser(username, number)
+	if errors.Is(err, pg_admin.ErrNumberNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found", "details": "No verified account has this username and number"})
+		return "", false
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error", "details": err.Error()})
+		return "", false
+	}
+	return userID, true
+}
@@ -0,0 +1,165 @@
+package auth_handler
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/Ahmeds-Library/Chat-App/internal/middleware"
+	"github.com/gin-gonic/gin"
+)
+
+// login starts a session for the user as startSession would and returns its
+// ID and access token.
+func (e *testEnv) login(t *testing.T, userID string) (string, string) {
+	t.Helper()
+	sessionID := middleware.NewSessionID()
+	token, err := middleware.Create_Access_Token(userID, sessionID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := e.accounts.CreateSession(sessionID, userID, "test", "127.0.0.1", "", time.Now().Add(time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	return sessionID, token
+}
+
+func (e *testEnv) password(t *testing.T, username string) string {
+	t.Helper()
+	user, ok := e.accounts.user(username)
+	if !ok {
+		t.Fatalf("no account %q", username)
+	}
+	return user.password
+}
+
+func TestChangePassword(t *testing.T) {
+	env := newTestEnv(t)
+	aliceID := env.accounts.addUser("alice", "old-password", testNumber, true)
+	current, token := env.login(t, aliceID)
+	other, _ := env.login(t, aliceID)
+
+	status, _ := env.postAuth(t, "/change_password", token, gin.H{"old_password": "wrong-password", "new_password": "new-password"})
+	if status != http.StatusUnauthorized {
+		t.Fatalf("wrong old password: %d, want 401", status)
+	}
+	if env.password(t, "alice") != "old-password" {
+		t.Fatal("password changed with the wrong old password")
+	}
+	if active, _ := env.accounts.sessionActive(other); !active {
+		t.Fatal("session ended by a failed change")
+	}
+
+	status, resp := env.postAuth(t, "/change_password", token, gin.H{"old_password": "old-password", "new_password": "new-password"})
+	if status != http.StatusOK {
+		t.Fatalf("change: %d %v", status, resp)
+	}
+	if env.password(t, "alice") != "new-password" {
+		t.Fatal("password not changed")
+	}
+	if active, _ := env.accounts.sessionActive(other); active {
+		t.Fatal("other session still active")
+	}
+	if active, _ := env.accounts.sessionActive(current); !active {
+		t.Fatal("current session ended")
+	}
+}
+
+func TestChangePasswordValidation(t *testing.T) {
+	env := newTestEnv(t)
+	aliceID := env.accounts.addUser("alice", "old-password", testNumber, true)
+	_, token := env.login(t, aliceID)
+
+	if status, _ := env.post(t, "/change_password", gin.H{"old_password": "old-password", "new_password": "new-password"}); status != http.StatusUnauthorized {
+		t.Fatalf("without a token: %d, want 401", status)
+	}
+	if status, _ := env.postAuth(t, "/change_password", token, gin.H{"old_password": "old-password", "new_password": "short"}); status != http.StatusBadRequest {
+		t.Fatalf("short password: %d, want 400", status)
+	}
+	if env.password(t, "alice") != "old-password" {
+		t.Fatal("password changed by a rejected request")
+	}
+}
+
+func TestResetPassword(t *testing.T) {
+	env := newTestEnv(t)
+	aliceID := env.accounts.addUser("alice", "old-password", testNumber, true)
+	first, _ := env.login(t, aliceID)
+	second, _ := env.login(t, aliceID)
+
+	if status, resp := env.post(t, "/forgot_password", gin.H{"username": "alice", "number": testNumber}); status != http.StatusOK {
+		t.Fatalf("forgot: %d %v", status, resp)
+	}
+	code := env.outbox.LastCode(testNumber)
+
+	reset := func(code string) int {
+		status, _ := env.post(t, "/reset_password", gin.H{"username": "alice", "number": testNumber, "code": code, "new_password": "new-password"})
+		return status
+	}
+	if status := reset(wrongCode(code)); status != http.StatusUnauthorized {
+		t.Fatalf("wrong code: %d, want 401", status)
+	}
+	if env.password(t, "alice") != "old-password" {
+		t.Fatal("password reset with the wrong code")
+	}
+
+	if status := reset(code); status != http.StatusOK {
+		t.Fatalf("reset: %d", status)
+	}
+	if env.password(t, "alice") != "new-password" {
+		t.Fatal("password not reset")
+	}
+	for _, sessionID := range []string{first, second} {
+		if active, _ := env.accounts.sessionActive(sessionID); active {
+			t.Fatalf("session %s still active", sessionID)
+		}
+	}
+
+	if status := reset(code); status != http.StatusUnauthorized {
+		t.Fatalf("reused code: %d, want 401", status)
+	}
+}
+
+func TestResetPasswordTargetsNamedAccount(t *testing.T) {
+	env := newTestEnv(t)
+	// Accounts from before numbers were verified can share one.
+	env.accounts.addUser("alice", "alice-password", testNumber, true)
+	env.accounts.addUser("bob", "bob-password", testNumber, true)
+
+	if status, _ := env.post(t, "/forgot_password", gin.H{"username": "bob", "number": testNumber}); status != http.StatusOK {
+		t.Fatalf("forgot: %d", status)
+	}
+	code := env.outbox.LastCode(testNumber)
+
+	status, _ := env.post(t, "/reset_password", gin.H{"username": "alice", "number": testNumber, "code": code, "new_password": "new-password"})
+	if status != http.StatusUnauthorized {
+		t.Fatalf("bob's code on alice: %d, want 401", status)
+	}
+	if env.password(t, "alice") != "alice-password" {
+		t.Fatal("alice's password reset with bob's code")
+	}
+
+	status, _ = env.post(t, "/reset_password", gin.H{"username": "bob", "number": testNumber, "code": code, "new_password": "new-password"})
+	if status != http.StatusOK || env.password(t, "bob") != "new-password" {
+		t.Fatalf("bob's reset: %d", status)
+	}
+}
+
+func TestForgotPasswordNeedsVerifiedAccount(t *testing.T) {
+	env := newTestEnv(t)
+	env.accounts.addUser("alice", "alice-password", testNumber, true)
+	env.accounts.addUser("pending", "pending-password", "03007654321", false)
+
+	for _, req := range []gin.H{
+		{"username": "alice", "number": "03007654321"},
+		{"username": "nobody", "number": testNumber},
+		{"username": "pending", "number": "03007654321"},
+	} {
+		if status, _ := env.post(t, "/forgot_password", req); status != http.StatusNotFound {
+			t.Fatalf("%v: %d, want 404", req, status)
+		}
+	}
+	if env.outbox.Count(testNumber) != 0 || env.outbox.Count("03007654321") != 0 {
+		t.Fatal("code texted for an unknown account")
+	}
+}
@@ -1,19 +1,21 @@
 package auth_handler
 
 import (
+	"errors"
 	"net/http"
 
+	pg_admin "github.com/Ahmeds-Library/Chat-App/internal/database/Pg_Admin"
 	"github.com/Ahmeds-Library/Chat-App/internal/middleware"
 	"github.com/Ahmeds-Library/Chat-App/internal/utils"
 	"github.com/gin-gonic/gin"
 )
 
+// Refresh_Key trades a refresh token for a new access token and a new
+// refresh token. The old refresh token cannot be used again.
 func Refresh_Key(c *gin.Context) {
 	tokenString := c.GetHeader("Authorization")
-
-	if err := middleware.VerifyToken(tokenString); err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token", "details": err.Error()})
-		return
+	if len(tokenString) > 7 && tokenString[:7] == "Bearer " {
+		tokenString = tokenString[7:]
 	}
 
 	claims, err := utils.DecodeToken(tokenString)
@@ -23,28 +25,45 @@ func Refresh_Key(c *gin.Context) {
 	}
 
 	token_type, ok := claims["token_type"].(string)
-	userID, ok2 := claims["id"].(string)
-
 	if !ok || token_type != "refresh" {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token type", "details": "Use a valid refresh token"})
 		return
 	}
-	if userID == "" {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID not found in token"})
+
+	userID, _ := claims["id"].(string)
+	sessionID, _ := claims["sid"].(string)
+	if userID == "" || sessionID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token claims", "details": "Log in again"})
 		return
 	}
+	username, _ := claims["username"].(string)
+	number, _ := claims["number"].(string)
 
-	if !ok2 {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token claims", "details": "User ID not found in token claims"})
+	refreshtoken, expiresAt, err := middleware.Create_Refresh_Token(userID, username, number, sessionID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Unable to create refresh token", "details": err.Error()})
 		return
 	}
 
-	accesstoken, err := middleware.Create_Access_Token(userID)
+	err = pg_admin.RotateRefreshToken(sessionID, middleware.HashToken(tokenString), middleware.HashToken(refreshtoken), expiresAt)
+	if errors.Is(err, pg_admin.ErrRefreshTokenReused) {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Refresh token reused", "details": "This session has been ended for safety, log in again"})
+		return
+	}
+	if errors.Is(err, pg_admin.ErrRefreshTokenInvalid) {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token", "details": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error", "details": err.Error()})
+		return
+	}
 
+	accesstoken, err := middleware.Create_Access_Token(userID, sessionID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Unable to create refresh token", "details": err.Error()})
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Unable to create access token", "details": err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "Token renewed successfully", "Access token": accesstoken})
+	c.JSON(http.StatusOK, gin.H{"message": "Token renewed successfully", "Refresh token": refreshtoken, "Access token": accesstoken})
 }
@@ -0,0 +1,52 @@
+package auth_handler
+
+import (
+	"net/http"
+
+	"github.com/Ahmeds-Library/Chat-App/internal/middleware"
+	"github.com/Ahmeds-Library/Chat-App/internal/utils"
+	"github.com/gin-gonic/gin"
+)
+
+// startSession begins a login session for the user on the requesting device
+// and returns its refresh and access tokens.
+func startSession(c *gin.Context, userID, username, number string) (string, string, error) {
+	sessionID := middleware.NewSessionID()
+
+	refreshToken, expiresAt, err := middleware.Create_Refresh_Token(userID, username, number, sessionID)
+	if err != nil {
+		return "", "", err
+	}
+	accessToken, err := middleware.Create_Access_Token(userID, sessionID)
+	if err != nil {
+		return "", "", err
+	}
+
+	if err := accounts.CreateSession(sessionID, userID, c.Request.UserAgent(), c.ClientIP(), middleware.HashToken(refreshToken), expiresAt); err != nil {
+		return "", "", err
+	}
+	return refreshToken, accessToken, nil
+}
+
+// accessSession returns the user and session of a valid access token,
+// writing the error response itself when there is none.
+func accessSession(c *gin.Context) (string, string, bool) {
+	claims, ok := utils.ValidateToken(c)
+	if !ok {
+		return "", "", false
+	}
+
+	token_type, ok := claims["token_type"].(string)
+	if !ok || token_type != "Access" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token type", "details": "Use a valid access token"})
+		return "", "", false
+	}
+
+	userID, _ := claims["id"].(string)
+	sessionID, _ := claims["sid"].(string)
+	if userID == "" || sessionID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token", "details": "Log in again"})
+		return "", "", false
+	}
+	return userID, sessionID, true
+}
@@ -0,0 +1,50 @@
+package auth_handler
+
+import (
+	"errors"
+	"net/http"
+
+	pg_admin "github.com/Ahmeds-Library/Chat-App/internal/database/Pg_Admin"
+	"github.com/gin-gonic/gin"
+)
+
+// ListSessions returns the devices the caller is logged in on, marking the
+// one making the request.
+func ListSessions(c *gin.Context) {
+	userID, sessionID, ok := accessSession(c)
+	if !ok {
+		return
+	}
+
+	sessions, err := pg_admin.GetUserSessions(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error", "details": err.Error()})
+		return
+	}
+	for i := range sessions {
+		sessions[i].Current = sessions[i].ID == sessionID
+	}
+
+	c.JSON(http.StatusOK, gin.H{"sessions": sessions})
+}
+
+// RevokeSession logs the caller out of one of their devices. Its refresh
+// token stops working and its websocket connections are closed.
+func RevokeSession(c *gin.Context) {
+	userID, _, ok := accessSession(c)
+	if !ok {
+		return
+	}
+
+	err := pg_admin.RevokeSession(userID, c.Param("id"))
+	if errors.Is(err, pg_admin.ErrSessionNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Session not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Session revoked"})
+}
@@ -1,11 +1,13 @@
 package auth_handler
 
 import (
+    "errors"
+    "log"
     "net/http"
 
     pg_admin "github.com/Ahmeds-Library/Chat-App/internal/database/Pg_Admin"
-    "github.com/Ahmeds-Library/Chat-App/internal/middleware"
     "github.com/Ahmeds-Library/Chat-App/internal/models"
+    "github.com/Ahmeds-Library/Chat-App/internal/otp"
     "github.com/gin-gonic/gin"
 )
 
@@ -17,9 +19,13 @@ func Signup(c *gin.Context) {
         return
     }
 
-    err := pg_admin.CreateUser(u.Username, u.Password, u.Number)
+    var err error
+    u.ID, err = accounts.CreateUser(u.Username, u.Password, u.Number)
     if err != nil {
-        if err.Error() == "username already exists" {
+        if errors.Is(err, pg_admin.ErrNumberTaken) {
+            c.JSON(http.StatusConflict, gin.H{"error": "Phone number already in use"})
+            return
+        } else if err.Error() == "username already exists" {
             c.JSON(http.StatusConflict, gin.H{"error": "Username already exists"})
             return
         } else if err.Error() == "pq: new row for relation \"users\" violates check constraint \"number_length_check\"" {
@@ -33,22 +39,18 @@ func Signup(c *gin.Context) {
         return
     }
 
-    // Generate tokens after successful user creation
-    refreshtoken, err := middleware.Create_Refresh_Token(u.ID, u.Username, u.Number)
-    if err != nil {
-        c.JSON(http.StatusInternalServerError, gin.H{"error": "Unable to create refresh token", "details": err.Error()})
-        return
-    }
-
-    accesstoken, err := middleware.Create_Access_Token(u.ID)
-    if err != nil {
-        c.JSON(http.StatusInternalServerError, gin.H{"error": "Unable to create access token", "details": err.Error()})
+    // Tokens are only issued once the number is confirmed with VerifyNumber.
+    if err := otp.Send(u.ID, u.Number, otp.PurposeVerify); err != nil {
+        log.Println("Verification code send error:", err)
+        c.JSON(http.StatusCreated, gin.H{
+            "message": "User registered successfully",
+            "details": "Verification code could not be sent, request a new one",
+        })
         return
     }
 
     c.JSON(http.StatusCreated, gin.H{
-        "message":        "User registered successfully",
-        "Refresh token":  refreshtoken,
-        "Access token":   accesstoken,
+        "message": "User registered successfully",
+        "details": "Enter the verification code sent to your number",
     })
 }	
\ No newline at end of file
@@ -0,0 +1,56 @@
+package auth_handler
+
+import (
+	"time"
+
+	pg_admin "github.com/Ahmeds-Library/Chat-App/internal/database/Pg_Admin"
+)
+
+// accountStore is the part of pg_admin the signup, verification and password
+// handlers use, so tests can run them without a database.
+type accountStore interface {
+	CreateUser(username, password, number string) (string, error)
+	GetPendingSignup(number string) (string, string, error)
+	NumberVerified(number string) (bool, error)
+	MarkPhoneVerified(userID string) error
+	GetVerifiedUser(username, number string) (string, error)
+	CheckPassword(userID, password string) (bool, error)
+	SetPassword(userID, password, keepSessionID string) error
+	CreateSession(sessionID, userID, userAgent, ip, tokenHash string, expiresAt time.Time) error
+}
+
+var accounts accountStore = pgAccounts{}
+
+type pgAccounts struct{}
+
+func (pgAccounts) CreateUser(username, password, number string) (string, error) {
+	return pg_admin.CreateUser(username, password, number)
+}
+
+func (pgAccounts) GetPendingSignup(number string) (string, string, error) {
+	return pg_admin.GetPendingSignup(number)
+}
+
+func (pgAccounts) NumberVerified(number string) (bool, error) {
+	return pg_admin.NumberVerified(number)
+}
+
+func (pgAccounts) MarkPhoneVerified(userID string) error {
+	return pg_admin.MarkPhoneVerified(userID)
+}
+
+func (pgAccounts) GetVerifiedUser(username, number string) (string, error) {
+	return pg_admin.GetVerifiedUser(username, number)
+}
+
+func (pgAccounts) CheckPassword(userID, password string) (bool, error) {
+	return pg_admin.CheckPassword(userID, password)
+}
+
+func (pgAccounts) SetPassword(userID, password, keepSessionID string) error {
+	return pg_admin.SetPassword(userID, password, keepSessionID)
+}
+
+func (pgAccounts) CreateSession(sessionID, userID, userAgent, ip, tokenHash string, expiresAt time.Time) error {
+	return pg_admin.CreateSession(sessionID, userID, userAgent, ip, tokenHash, expiresAt)
+}
@@ -0,0 +1,119 @@
+package auth_handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	pg_admin "github.com/Ahmeds-Library/Chat-App/internal/database/Pg_Admin"
+	"github.com/Ahmeds-Library/Chat-App/internal/models"
+	"github.com/Ahmeds-Library/Chat-App/internal/otp"
+	"github.com/gin-gonic/gin"
+)
+
+// SendVerificationCode texts a new code for the signup waiting to confirm
+// the number.
+func SendVerificationCode(c *gin.Context) {
+	var req models.Send_Code
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input", "details": err.Error()})
+		return
+	}
+
+	userID, _, ok := pendingSignup(c, req.Number)
+	if !ok {
+		return
+	}
+
+	if err := otp.Send(userID, req.Number, otp.PurposeVerify); err != nil {
+		respondOTPError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Verification code sent"})
+}
+
+// VerifyNumber confirms the number of the signup waiting for it, using the
+// code sent for that account, and logs them in.
+func VerifyNumber(c *gin.Context) {
+	var req models.Verify_Code
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input", "details": err.Error()})
+		return
+	}
+
+	userID, username, ok := pendingSignup(c, req.Number)
+	if !ok {
+		return
+	}
+
+	if err := otp.Verify(userID, req.Number, otp.PurposeVerify, req.Code); err != nil {
+		respondOTPError(c, err)
+		return
+	}
+	err := accounts.MarkPhoneVerified(userID)
+	if errors.Is(err, pg_admin.ErrNumberTaken) {
+		c.JSON(http.StatusConflict, gin.H{"error": "Phone number already verified"})
+		return
+	}
+	if errors.Is(err, pg_admin.ErrNumberNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found", "details": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error", "details": err.Error()})
+		return
+	}
+
+	refreshtoken, accesstoken, err := startSession(c, userID, username, req.Number)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Unable to create tokens", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":       "Phone number verified",
+		"Refresh token": refreshtoken,
+		"Access token":  accesstoken,
+	})
+}
+
+// pendingSignup returns the account waiting to confirm the number, writing
+// the error response itself when there is none.
+func pendingSignup(c *gin.Context, number string) (string, string, bool) {
+	userID, username, err := accounts.GetPendingSignup(number)
+	if errors.Is(err, pg_admin.ErrNumberNotFound) {
+		verified, err := accounts.NumberVerified(number)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error", "details": err.Error()})
+			return "", "", false
+		}
+		if verified {
+			c.JSON(http.StatusConflict, gin.H{"error": "Phone number already verified"})
+			return "", "", false
+		}
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found", "details": pg_admin.ErrNumberNotFound.Error()})
+		return "", "", false
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error", "details": err.Error()})
+		return "", "", false
+	}
+	return userID, username, true
+}
+
+func respondOTPError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, otp.ErrCooldown):
+		c.Header("Retry-After", strconv.Itoa(int(otp.ResendCooldown.Seconds())))
+		c.JSON(http.StatusTooManyRequests, gin.H{"error": "Code already sent", "details": "Wait before requesting another code"})
+	case errors.Is(err, otp.ErrInvalid):
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Incorrect code"})
+	case errors.Is(err, otp.ErrExpired):
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Code expired", "details": "Request a new code"})
+	case errors.Is(err, otp.ErrTooManyAttempts):
+		c.JSON(http.StatusTooManyRequests, gin.H{"error": "Too many incorrect codes", "details": "Try again later"})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Unable to process code", "details": err.Error()})
+	}
+}
@@ -0,0 +1,471 @@
+package auth_handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	pg_admin "github.com/Ahmeds-Library/Chat-App/internal/database/Pg_Admin"
+	"github.com/Ahmeds-Library/Chat-App/internal/middleware"
+	"github.com/Ahmeds-Library/Chat-App/internal/otp"
+	"github.com/Ahmeds-Library/Chat-App/internal/otp/otptest"
+	"github.com/gin-gonic/gin"
+)
+
+const testNumber = "03001234567"
+
+type fakeUser struct {
+	id, username, password, number string
+	verified                       bool
+}
+
+// fakeAccounts keeps accounts in memory, following the contract of the
+// pg_admin functions it stands in for.
+type fakeAccounts struct {
+	mu       sync.Mutex
+	users    []*fakeUser
+	nextID   int
+	sessions map[string]string
+	revoked  map[string]bool
+}
+
+func (f *fakeAccounts) CreateUser(username, password, number string) (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	kept := f.users[:0]
+	for _, u := range f.users {
+		switch {
+		case u.number != number:
+		case u.verified:
+			return "", pg_admin.ErrNumberTaken
+		default:
+			continue
+		}
+		kept = append(kept, u)
+	}
+	for _, u := range kept {
+		if u.username == username {
+			return "", errors.New("username already exists")
+		}
+	}
+
+	f.nextID++
+	user := &fakeUser{id: strconv.Itoa(f.nextID), username: username, password: password, number: number}
+	f.users = append(kept, user)
+	return user.id, nil
+}
+
+func (f *fakeAccounts) GetPendingSignup(number string) (string, string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for i := len(f.users) - 1; i >= 0; i-- {
+		if u := f.users[i]; u.number == number && !u.verified {
+			return u.id, u.username, nil
+		}
+	}
+	return "", "", pg_admin.ErrNumberNotFound
+}
+
+func (f *fakeAccounts) NumberVerified(number string) (bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for _, u := range f.users {
+		if u.number == number && u.verified {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (f *fakeAccounts) MarkPhoneVerified(userID string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	user := f.find(userID)
+	if user == nil || user.verified {
+		return pg_admin.ErrNumberNotFound
+	}
+	for _, u := range f.users {
+		if u.number == user.number && u.verified {
+			return pg_admin.ErrNumberTaken
+		}
+	}
+	user.verified = true
+	return nil
+}
+
+func (f *fakeAccounts) CreateSession(sessionID, userID, userAgent, ip, tokenHash string, expiresAt time.Time) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.sessions == nil {
+		f.sessions = make(map[string]string)
+	}
+	f.sessions[sessionID] = userID
+	return nil
+}
+
+func (f *fakeAccounts) GetVerifiedUser(username, number string) (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for _, u := range f.users {
+		if u.username == username && u.number == number && u.verified {
+			return u.id, nil
+		}
+	}
+	return "", pg_admin.ErrNumberNotFound
+}
+
+func (f *fakeAccounts) CheckPassword(userID, password string) (bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	user := f.find(userID)
+	if user == nil {
+		return false, errors.New("user not found")
+	}
+	return user.password == password, nil
+}
+
+func (f *fakeAccounts) SetPassword(userID, password, keepSessionID string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	user := f.find(userID)
+	if user == nil {
+		return errors.New("user not found")
+	}
+	user.password = password
+	if f.revoked == nil {
+		f.revoked = make(map[string]bool)
+	}
+	for sessionID, owner := range f.sessions {
+		if owner == userID && sessionID != keepSessionID {
+			f.revoked[sessionID] = true
+		}
+	}
+	return nil
+}
+
+// sessionActive stands in for middleware.CheckSession.
+func (f *fakeAccounts) sessionActive(sessionID string) (bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	_, ok := f.sessions[sessionID]
+	return ok && !f.revoked[sessionID], nil
+}
+
+// addUser stores an account directly, as older data may have several
+// verified accounts sharing a number.
+func (f *fakeAccounts) addUser(username, password, number string, verified bool) string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.nextID++
+	user := &fakeUser{id: strconv.Itoa(f.nextID), username: username, password: password, number: number, verified: verified}
+	f.users = append(f.users, user)
+	return user.id
+}
+
+func (f *fakeAccounts) find(userID string) *fakeUser {
+	for _, u := range f.users {
+		if u.id == userID {
+			return u
+		}
+	}
+	return nil
+}
+
+// user returns a copy of the account with the username, if there is one.
+func (f *fakeAccounts) user(username string) (fakeUser, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for _, u := range f.users {
+		if u.username == username {
+			return *u, true
+		}
+	}
+	return fakeUser{}, false
+}
+
+func (f *fakeAccounts) sessionUsers() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var users []string
+	for _, userID := range f.sessions {
+		users = append(users, userID)
+	}
+	return users
+}
+
+type testEnv struct {
+	router   *gin.Engine
+	accounts *fakeAccounts
+	codes    *otptest.Store
+	outbox   *otptest.Outbox
+}
+
+func newTestEnv(t *testing.T) *testEnv {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	t.Setenv("JWT_KEYS_DIR", "")
+	t.Setenv("JWT_PRIVATE_KEY", "")
+	t.Setenv("JWT_PRIVATE_KEY_FILE", "")
+	t.Setenv("JWT_SECRET", "")
+	t.Setenv("JWT_ALLOW_EPHEMERAL_KEY", "true")
+	keys, err := middleware.NewKeySetFromEnv()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	env := &testEnv{
+		router:   gin.New(),
+		accounts: &fakeAccounts{},
+		codes:    otptest.NewStore(),
+		outbox:   &otptest.Outbox{},
+	}
+	oldKeys, oldCheck := middleware.Keys, middleware.CheckSession
+	oldAccounts, oldCodes, oldSender := accounts, otp.Codes, otp.Sender
+	middleware.Keys, middleware.CheckSession = keys, env.accounts.sessionActive
+	accounts, otp.Codes, otp.Sender = env.accounts, env.codes, env.outbox
+	t.Cleanup(func() {
+		middleware.Keys, middleware.CheckSession = oldKeys, oldCheck
+		accounts, otp.Codes, otp.Sender = oldAccounts, oldCodes, oldSender
+	})
+
+	env.router.POST("/signup", Signup)
+	env.router.POST("/send_verification_code", SendVerificationCode)
+	env.router.POST("/verify_number", VerifyNumber)
+	env.router.POST("/forgot_password", ForgotPassword)
+	env.router.POST("/reset_password", ResetPassword)
+	env.router.POST("/change_password", middleware.AuthMiddleware(), ChangePassword)
+	return env
+}
+
+func (e *testEnv) post(t *testing.T, path string, body any) (int, map[string]any) {
+	t.Helper()
+	return e.postAuth(t, path, "", body)
+}
+
+// postAuth is post with an access token, if token is not empty.
+func (e *testEnv) postAuth(t *testing.T, path, token string, body any) (int, map[string]any) {
+	t.Helper()
+	data, err := json.Marshal(body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req := httptest.NewRequest(http.MethodPost, path, bytes.NewReader(data))
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	w := httptest.NewRecorder()
+	e.router.ServeHTTP(w, req)
+
+	var resp map[string]any
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("%s: %v in %q", path, err, w.Body.String())
+	}
+	return w.Code, resp
+}
+
+func (e *testEnv) signup(t *testing.T, username string) (int, map[string]any) {
+	t.Helper()
+	return e.post(t, "/signup", gin.H{"username": username, "password": "secret-" + username, "number": testNumber})
+}
+
+func (e *testEnv) verify(t *testing.T, code string) (int, map[string]any) {
+	t.Helper()
+	return e.post(t, "/verify_number", gin.H{"number": testNumber, "code": code})
+}
+
+func wrongCode(code string) string {
+	if code == "000000" {
+		return "111111"
+	}
+	return "000000"
+}
+
+func TestSignupThenVerifyNumber(t *testing.T) {
+	env := newTestEnv(t)
+
+	if status, resp := env.signup(t, "alice"); status != http.StatusCreated {
+		t.Fatalf("signup: %d %v", status, resp)
+	}
+	code := env.outbox.LastCode(testNumber)
+	if code == "" {
+		t.Fatal("no code texted on signup")
+	}
+
+	if status, _ := env.verify(t, wrongCode(code)); status != http.StatusUnauthorized {
+		t.Fatalf("wrong code: %d, want 401", status)
+	}
+	status, resp := env.verify(t, code)
+	if status != http.StatusOK || resp["Access token"] == nil || resp["Refresh token"] == nil {
+		t.Fatalf("verify: %d %v", status, resp)
+	}
+
+	alice, _ := env.accounts.user("alice")
+	if !alice.verified {
+		t.Fatal("number not marked verified")
+	}
+	if users := env.accounts.sessionUsers(); len(users) != 1 || users[0] != alice.id {
+		t.Fatalf("sessions for %v, want one for %s", users, alice.id)
+	}
+
+	if status, _ := env.verify(t, code); status != http.StatusConflict {
+		t.Fatalf("verifying again: %d, want 409", status)
+	}
+	if status, _ := env.post(t, "/send_verification_code", gin.H{"number": testNumber}); status != http.StatusConflict {
+		t.Fatalf("code for a verified number: %d, want 409", status)
+	}
+}
+
+func TestSignupRejectsVerifiedNumber(t *testing.T) {
+	env := newTestEnv(t)
+
+	env.signup(t, "alice")
+	if status, _ := env.verify(t, env.outbox.LastCode(testNumber)); status != http.StatusOK {
+		t.Fatalf("verify: %d", status)
+	}
+
+	if status, _ := env.signup(t, "mallory"); status != http.StatusConflict {
+		t.Fatalf("signup with a verified number: %d, want 409", status)
+	}
+	if _, ok := env.accounts.user("mallory"); ok {
+		t.Fatal("second account created")
+	}
+}
+
+// A signup that was never confirmed does not keep the owner of the number
+// from signing up.
+func TestSignupReplacesPendingNumber(t *testing.T) {
+	env := newTestEnv(t)
+
+	env.signup(t, "mallory")
+	staleCode := env.outbox.LastCode(testNumber)
+
+	if status, resp := env.signup(t, "alice"); status != http.StatusCreated {
+		t.Fatalf("signup: %d %v", status, resp)
+	}
+	if _, ok := env.accounts.user("mallory"); ok {
+		t.Fatal("pending signup kept")
+	}
+	// The number was just texted, so the new code waits for the cooldown.
+	if env.outbox.Count(testNumber) != 1 {
+		t.Fatal("second signup texted a code within the cooldown")
+	}
+	env.codes.Age(testNumber, otp.PurposeVerify, otp.ResendCooldown+time.Second)
+	if status, _ := env.post(t, "/send_verification_code", gin.H{"number": testNumber}); status != http.StatusOK {
+		t.Fatalf("resend: %d", status)
+	}
+	code := env.outbox.LastCode(testNumber)
+
+	// The code sent for the replaced account does not confirm the new one.
+	if code != staleCode {
+		if status, _ := env.verify(t, staleCode); status != http.StatusUnauthorized {
+			t.Fatalf("stale code: %d, want 401", status)
+		}
+	}
+	if status, _ := env.verify(t, code); status != http.StatusOK {
+		t.Fatalf("verify: %d", status)
+	}
+	alice, _ := env.accounts.user("alice")
+	if users := env.accounts.sessionUsers(); len(users) != 1 || users[0] != alice.id {
+		t.Fatalf("sessions for %v, want one for %s", users, alice.id)
+	}
+}
+
+func TestVerifyNumberExpiredCode(t *testing.T) {
+	env := newTestEnv(t)
+
+	env.signup(t, "alice")
+	code := env.outbox.LastCode(testNumber)
+	env.codes.Age(testNumber, otp.PurposeVerify, otp.CodeLifetime+time.Second)
+
+	status, resp := env.verify(t, code)
+	if status != http.StatusUnauthorized || resp["error"] != "Code expired" {
+		t.Fatalf("expired code: %d %v", status, resp)
+	}
+
+	// A new code can be requested once the old one is stale.
+	if status, _ := env.post(t, "/send_verification_code", gin.H{"number": testNumber}); status != http.StatusOK {
+		t.Fatalf("resend: %d", status)
+	}
+	if status, _ := env.verify(t, env.outbox.LastCode(testNumber)); status != http.StatusOK {
+		t.Fatalf("new code: %d", status)
+	}
+}
+
+func TestVerifyNumberAttemptLimit(t *testing.T) {
+	env := newTestEnv(t)
+
+	env.signup(t, "alice")
+	code := env.outbox.LastCode(testNumber)
+
+	for i := 0; i < otp.MaxAttempts; i++ {
+		if status, _ := env.verify(t, wrongCode(code)); status != http.StatusUnauthorized {
+			t.Fatalf("attempt %d: %d, want 401", i+1, status)
+		}
+	}
+	if status, _ := env.verify(t, code); status != http.StatusTooManyRequests {
+		t.Fatalf("correct code after the limit: %d, want 429", status)
+	}
+	if alice, _ := env.accounts.user("alice"); alice.verified {
+		t.Fatal("verified after too many attempts")
+	}
+}
+
+func TestSendVerificationCode(t *testing.T) {
+	env := newTestEnv(t)
+
+	if status, _ := env.post(t, "/send_verification_code", gin.H{"number": testNumber}); status != http.StatusNotFound {
+		t.Fatalf("unknown number: %d, want 404", status)
+	}
+
+	env.signup(t, "alice")
+	if status, _ := env.post(t, "/send_verification_code", gin.H{"number": testNumber}); status != http.StatusTooManyRequests {
+		t.Fatalf("resend within the cooldown: %d, want 429", status)
+	}
+}
+
+// Signing up again for the number starts neither the cooldown nor the
+// attempts over.
+func TestSignupAgainKeepsAttemptLimit(t *testing.T) {
+	env := newTestEnv(t)
+
+	env.signup(t, "mallory")
+	code := env.outbox.LastCode(testNumber)
+	for i := 0; i < otp.MaxAttempts; i++ {
+		if status, _ := env.verify(t, wrongCode(code)); status != http.StatusUnauthorized {
+			t.Fatalf("attempt %d: %d, want 401", i+1, status)
+		}
+	}
+
+	env.codes.Age(testNumber, otp.PurposeVerify, otp.ResendCooldown+time.Second)
+	if status, _ := env.signup(t, "mallory2"); status != http.StatusCreated {
+		t.Fatalf("signup again: %d", status)
+	}
+	if env.outbox.Count(testNumber) != 2 {
+		t.Fatal("no code texted for the new signup")
+	}
+	if status, _ := env.verify(t, env.outbox.LastCode(testNumber)); status != http.StatusTooManyRequests {
+		t.Fatalf("new signup's code: %d, want 429", status)
+	}
+	if mallory, _ := env.accounts.user("mallory2"); mallory.verified {
+		t.Fatal("verified after too many attempts")
+	}
+}
@@ -0,0 +1,93 @@
+package group_handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	pg_admin "github.com/Ahmeds-Library/Chat-App/internal/database/Pg_Admin"
+	"github.com/Ahmeds-Library/Chat-App/internal/models"
+	"github.com/Ahmeds-Library/Chat-App/internal/utils"
+	"github.com/gin-gonic/gin"
+)
+
+// accessUserID returns the caller's user ID from a valid access token,
+// writing the error response itself when there is none.
+func accessUserID(c *gin.Context) (string, bool) {
+	claims, ok := utils.ValidateToken(c)
+	if !ok {
+		return "", false
+	}
+
+	token_type, ok := claims["token_type"].(string)
+	if !ok || token_type != "Access" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token type", "details": "Use a valid access token"})
+		return "", false
+	}
+
+	userID, ok := claims["id"].(string)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token", "details": "User ID not found in token"})
+		return "", false
+	}
+	return userID, true
+}
+
+// groupID returns the validated :id path parameter.
+func groupID(c *gin.Context) (string, bool) {
+	id := c.Param("id")
+	if _, err := strconv.Atoi(id); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request", "details": "invalid group ID"})
+		return "", false
+	}
+	return id, true
+}
+
+// memberID returns the validated :user_id path parameter.
+func memberID(c *gin.Context) (string, bool) {
+	id := c.Param("user_id")
+	if _, err := strconv.Atoi(id); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request", "details": "invalid user ID"})
+		return "", false
+	}
+	return id, true
+}
+
+// memberRole returns the caller's role in the group, writing a 404 or 403
+// response when the group does not exist or the caller is not in it.
+func memberRole(c *gin.Context, conversationID, userID string) (string, bool) {
+	role, err := pg_admin.GetMemberRole(conversationID, userID)
+	if err != nil {
+		respondGroupError(c, err)
+		return "", false
+	}
+	return role, true
+}
+
+func canManage(role string) bool {
+	return role == models.RoleOwner || role == models.RoleAdmin
+}
+
+// resolveNumbers looks up the user ID for each phone number.
+func resolveNumbers(numbers []string) ([]string, error) {
+	ids := make([]string, 0, len(numbers))
+	for _, number := range numbers {
+		user, err := pg_admin.GetUserByPhone(number)
+		if err != nil {
+			return nil, errors.New("user not found: " + number)
+		}
+		ids = append(ids, user.ID)
+	}
+	return ids, nil
+}
+
+func respondGroupError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, pg_admin.ErrConversationNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Group not found"})
+	case errors.Is(err, pg_admin.ErrNotMember):
+		c.JSON(http.StatusForbidden, gin.H{"error": "Not a member of this group"})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error", "details": err.Error()})
+	}
+}
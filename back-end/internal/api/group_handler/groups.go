@@ -0,0 +1,107 @@
+package group_handler
+
+import (
+	"net/http"
+
+	pg_admin "github.com/Ahmeds-Library/Chat-App/internal/database/Pg_Admin"
+	"github.com/Ahmeds-Library/Chat-App/internal/models"
+	"github.com/gin-gonic/gin"
+)
+
+func CreateGroup(c *gin.Context) {
+	userID, ok := accessUserID(c)
+	if !ok {
+		return
+	}
+
+	var req models.Create_Group
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body", "details": err.Error()})
+		return
+	}
+
+	memberIDs, err := resolveNumbers(req.Member_Numbers)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Member not found", "details": err.Error()})
+		return
+	}
+
+	conversation, err := pg_admin.CreateConversation(req.Name, userID, memberIDs)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, conversation)
+}
+
+func ListGroups(c *gin.Context) {
+	userID, ok := accessUserID(c)
+	if !ok {
+		return
+	}
+
+	conversations, err := pg_admin.GetUserConversations(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, conversations)
+}
+
+func GetGroup(c *gin.Context) {
+	userID, ok := accessUserID(c)
+	if !ok {
+		return
+	}
+	conversationID, ok := groupID(c)
+	if !ok {
+		return
+	}
+	if _, ok := memberRole(c, conversationID, userID); !ok {
+		return
+	}
+
+	conversation, err := pg_admin.GetConversation(conversationID)
+	if err != nil {
+		respondGroupError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, conversation)
+}
+
+// RenameGroup is limited to the owner and admins.
+func RenameGroup(c *gin.Context) {
+	userID, ok := accessUserID(c)
+	if !ok {
+		return
+	}
+	conversationID, ok := groupID(c)
+	if !ok {
+		return
+	}
+
+	var req models.Rename_Group
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body", "details": err.Error()})
+		return
+	}
+
+	role, ok := memberRole(c, conversationID, userID)
+	if !ok {
+		return
+	}
+	if !canManage(role) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only the owner or an admin can rename the group"})
+		return
+	}
+
+	if err := pg_admin.RenameConversation(conversationID, req.Name); err != nil {
+		respondGroupError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Group renamed successfully", "name": req.Name})
+}
@@ -0,0 +1,147 @@
+package group_handler
+
+import (
+	"errors"
+	"net/http"
+
+	pg_admin "github.com/Ahmeds-Library/Chat-App/internal/database/Pg_Admin"
+	"github.com/Ahmeds-Library/Chat-App/internal/models"
+	"github.com/gin-gonic/gin"
+)
+
+// AddMembers is limited to the owner and admins. New members join with the
+// member role.
+func AddMembers(c *gin.Context) {
+	userID, ok := accessUserID(c)
+	if !ok {
+		return
+	}
+	conversationID, ok := groupID(c)
+	if !ok {
+		return
+	}
+
+	var req models.Group_Members
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body", "details": err.Error()})
+		return
+	}
+
+	role, ok := memberRole(c, conversationID, userID)
+	if !ok {
+		return
+	}
+	if !canManage(role) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only the owner or an admin can add members"})
+		return
+	}
+
+	memberIDs, err := resolveNumbers(req.Member_Numbers)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Member not found", "details": err.Error()})
+		return
+	}
+
+	if err := pg_admin.AddConversationMembers(conversationID, memberIDs); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Members added successfully"})
+}
+
+// RemoveMember lets any member leave the group. Admins can remove members,
+// and only the owner can remove admins. The owner cannot be removed.
+func RemoveMember(c *gin.Context) {
+	userID, ok := accessUserID(c)
+	if !ok {
+		return
+	}
+	conversationID, ok := groupID(c)
+	if !ok {
+		return
+	}
+	targetID, ok := memberID(c)
+	if !ok {
+		return
+	}
+
+	role, ok := memberRole(c, conversationID, userID)
+	if !ok {
+		return
+	}
+
+	targetRole, err := pg_admin.GetMemberRole(conversationID, targetID)
+	if errors.Is(err, pg_admin.ErrNotMember) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Member not found"})
+		return
+	}
+	if err != nil {
+		respondGroupError(c, err)
+		return
+	}
+
+	if targetRole == models.RoleOwner {
+		c.JSON(http.StatusForbidden, gin.H{"error": "The group owner cannot be removed"})
+		return
+	}
+	if targetID != userID {
+		if !canManage(role) || (targetRole == models.RoleAdmin && role != models.RoleOwner) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Not allowed to remove this member"})
+			return
+		}
+	}
+
+	if err := pg_admin.RemoveConversationMember(conversationID, targetID); err != nil {
+		respondGroupError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Member removed successfully"})
+}
+
+// SetMemberRole lets the owner promote members to admin or demote them.
+func SetMemberRole(c *gin.Context) {
+	userID, ok := accessUserID(c)
+	if !ok {
+		return
+	}
+	conversationID, ok := groupID(c)
+	if !ok {
+		return
+	}
+	targetID, ok := memberID(c)
+	if !ok {
+		return
+	}
+
+	var req models.Member_Role
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body", "details": err.Error()})
+		return
+	}
+
+	role, ok := memberRole(c, conversationID, userID)
+	if !ok {
+		return
+	}
+	if role != models.RoleOwner {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only the owner can change roles"})
+		return
+	}
+	if targetID == userID {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request", "details": "The owner's role cannot be changed"})
+		return
+	}
+
+	if err := pg_admin.SetMemberRole(conversationID, targetID, req.Role); err != nil {
+		if errors.Is(err, pg_admin.ErrNotMember) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Member not found"})
+			return
+		}
+		respondGroupError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Member role updated successfully", "role": req.Role})
+}
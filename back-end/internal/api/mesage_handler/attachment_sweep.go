@@ -0,0 +1,67 @@
+package message_handler
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	mongo_db "github.com/Ahmeds-Library/Chat-App/internal/database/Mongo_DB"
+)
+
+const (
+	// UnclaimedAttachmentTTL is how long an upload may wait to be sent with
+	// a message, or to be finished, before it is swept away.
+	UnclaimedAttachmentTTL = 24 * time.Hour
+
+	attachmentSweepInterval  = time.Hour
+	attachmentSweepBatchSize = 100
+)
+
+// SweepAttachments deletes unclaimed attachments and abandoned uploads older
+// than UnclaimedAttachmentTTL, along with their blobs, once an hour until ctx
+// is done. Every replica can run it: an attachment is only deleted once.
+func SweepAttachments(ctx context.Context) {
+	ticker := time.NewTicker(attachmentSweepInterval)
+	defer ticker.Stop()
+
+	for {
+		if err := sweepAttachments(time.Now().Add(-UnclaimedAttachmentTTL)); err != nil {
+			log.Println("Attachment sweep failed:", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func sweepAttachments(before time.Time) error {
+	db := mongo_db.MongoClient.Database("chat-app")
+
+	for {
+		expired, err := mongo_db.UnclaimedAttachments(db, before, attachmentSweepBatchSize)
+		if err != nil {
+			return err
+		}
+
+		for _, attachment := range expired {
+			// The document goes first, so a message can never end up
+			// pointing at an attachment whose blob is gone.
+			deleted, err := mongo_db.DeleteUnclaimedAttachment(db, attachment.ID)
+			if errors.Is(err, mongo_db.ErrAttachmentNotFound) {
+				continue
+			}
+			if err != nil {
+				return err
+			}
+			deleteAttachmentBlobs(deleted)
+		}
+
+		if len(expired) < attachmentSweepBatchSize {
+			return nil
+		}
+	}
+}
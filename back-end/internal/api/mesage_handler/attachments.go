@@ -0,0 +1,374 @@
+package message_handler
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"log"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+
+	mongo_db "github.com/Ahmeds-Library/Chat-App/internal/database/Mongo_DB"
+	"github.com/Ahmeds-Library/Chat-App/internal/media"
+	"github.com/Ahmeds-Library/Chat-App/internal/models"
+	"github.com/Ahmeds-Library/Chat-App/internal/storage"
+	"github.com/Ahmeds-Library/Chat-App/internal/utils"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+var (
+	errTypeNotAllowed   = errors.New("file type is not allowed")
+	errChecksumMismatch = errors.New("checksum does not match the uploaded file")
+	errTooLarge         = errors.New("file is larger than the attachment limit")
+)
+
+// maxFormFieldSize bounds the form fields sent with an upload, which are
+// short IDs and a checksum.
+const maxFormFieldSize = 1 << 10
+
+// UploadAttachmentHandler stores a file sent as the "file" field of a
+// multipart form, addressed like a message with conversation_id or
+// receiver_number. An optional checksum field is the SHA-256 of the file in
+// hex. The other fields must come before the file, which is streamed to
+// storage as it arrives. The returned attachment ID can then be sent with a
+// message.
+func UploadAttachmentHandler(c *gin.Context) {
+	userID, ok := accessUserID(c)
+	if !ok {
+		return
+	}
+
+	maxSize := utils.MaxAttachmentSize()
+	// Leave room for the other form fields and the multipart framing.
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxSize+1<<20)
+
+	form, err := c.Request.MultipartReader()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input", "details": err.Error()})
+		return
+	}
+
+	fields := make(map[string]string)
+	var part *multipart.Part
+	for part == nil {
+		next, err := form.NextPart()
+		if errors.Is(err, io.EOF) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input", "details": "No file field in the form"})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input", "details": err.Error()})
+			return
+		}
+		if next.FormName() == "file" {
+			part = next
+			break
+		}
+
+		value, err := io.ReadAll(io.LimitReader(next, maxFormFieldSize+1))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input", "details": err.Error()})
+			return
+		}
+		if len(value) > maxFormFieldSize {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input", "details": "Field " + next.FormName() + " is too long"})
+			return
+		}
+		fields[next.FormName()] = string(value)
+	}
+
+	conversationID, _, ok := resolveConversation(c, userID, fields["receiver_number"], fields["conversation_id"])
+	if !ok {
+		return
+	}
+
+	file, err := spool(part, maxSize)
+	if errors.Is(err, errTooLarge) {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "File too large", "details": "Attachments are limited to " + strconv.FormatInt(maxSize, 10) + " bytes"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input", "details": err.Error()})
+		return
+	}
+	defer file.Close()
+
+	attachment := newAttachment(userID, conversationID, part.FileName(), file.size)
+	attachment.Status = models.UploadComplete
+	attachment.Received = file.size
+
+	if err := storeAttachment(c.Request.Context(), attachment, file, part.Header.Get("Content-Type"), fields["checksum"]); err != nil {
+		respondAttachmentError(c, err)
+		return
+	}
+
+	db := mongo_db.MongoClient.Database("chat-app")
+	if err := mongo_db.CreateAttachment(db, attachment); err != nil {
+		deleteAttachmentBlobs(attachment)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save attachment", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, attachment)
+}
+
+// DownloadAttachmentHandler streams an attachment to a participant of the
+// conversation it was uploaded to, or with ?variant=thumbnail its thumbnail.
+// Attachments of messages deleted for everyone, or for the caller, are gone.
+func DownloadAttachmentHandler(c *gin.Context) {
+	userID, ok := accessUserID(c)
+	if !ok {
+		return
+	}
+
+	db := mongo_db.MongoClient.Database("chat-app")
+
+	variant := c.Query("variant")
+	if variant != "" && variant != "thumbnail" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request", "details": "Unknown variant " + variant})
+		return
+	}
+
+	attachment, ok := loadAttachment(c, db, c.Param("id"))
+	if !ok {
+		return
+	}
+	// Until it is sent with a message, an attachment is only the uploader's.
+	if attachment.Status != models.UploadComplete || (attachment.MessageID == nil && attachment.UploaderID != userID) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Attachment not found"})
+		return
+	}
+
+	if _, _, ok := resolveConversation(c, userID, "", attachment.ConversationID); !ok {
+		return
+	}
+
+	if attachment.MessageID != nil {
+		msg, err := mongo_db.GetMessage(db, attachment.MessageID.Hex())
+		if err != nil && !errors.Is(err, mongo_db.ErrMessageNotFound) {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load message", "details": err.Error()})
+			return
+		}
+		if err != nil || msg.Deleted || slices.Contains(msg.DeletedFor, userID) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Attachment not found"})
+			return
+		}
+	}
+
+	key, size, mimeType := attachment.StorageKey, attachment.Size, attachment.MimeType
+	etag := `"` + attachment.Checksum + `"`
+	disposition := "attachment"
+	if attachment.Kind != models.AttachmentFile {
+		disposition = "inline"
+	}
+	if variant == "thumbnail" {
+		if attachment.Thumbnail == nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Thumbnail not found"})
+			return
+		}
+		key, size, mimeType = attachment.Thumbnail.StorageKey, attachment.Thumbnail.Size, attachment.Thumbnail.MimeType
+		etag = `"` + attachment.Checksum + `-thumbnail"`
+		disposition = "inline"
+	}
+
+	if c.GetHeader("If-None-Match") == etag {
+		c.Status(http.StatusNotModified)
+		return
+	}
+
+	reader, err := storage.Blobs.Get(c.Request.Context(), key)
+	if errors.Is(err, storage.ErrBlobNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Attachment not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to read attachment", "details": err.Error()})
+		return
+	}
+	defer reader.Close()
+
+	c.DataFromReader(http.StatusOK, size, mimeType, reader, map[string]string{
+		"Content-Disposition":    mime.FormatMediaType(disposition, map[string]string{"filename": attachment.FileName}),
+		"ETag":                   etag,
+		"Cache-Control":          "private, max-age=86400",
+		"X-Content-Type-Options": "nosniff",
+	})
+}
+
+func newAttachment(uploaderID, conversationID, fileName string, size int64) *models.Attachment {
+	id := primitive.NewObjectID()
+	return &models.Attachment{
+		ID:             id,
+		UploaderID:     uploaderID,
+		ConversationID: conversationID,
+		FileName:       fileName,
+		Size:           size,
+		StorageKey:     "attachments/" + id.Hex(),
+	}
+}
+
+// loadAttachment writes a 404 or 500 response and returns false when the
+// attachment cannot be loaded.
+func loadAttachment(c *gin.Context, db *mongo.Database, attachmentID string) (*models.Attachment, bool) {
+	attachment, err := mongo_db.GetAttachment(db, attachmentID)
+	if errors.Is(err, mongo_db.ErrAttachmentNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Attachment not found"})
+		return nil, false
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load attachment", "details": err.Error()})
+		return nil, false
+	}
+	return attachment, true
+}
+
+// spooledFile is an upload written out to a temporary file, so it can be
+// inspected and stored without being held in memory. Close removes it.
+type spooledFile struct {
+	*os.File
+	size     int64
+	checksum string
+}
+
+// spool copies r to a temporary file, hashing it on the way. It returns
+// errTooLarge if r holds more than limit bytes.
+func spool(r io.Reader, limit int64) (*spooledFile, error) {
+	file, err := os.CreateTemp("", "attachment-*")
+	if err != nil {
+		return nil, err
+	}
+
+	hash := sha256.New()
+	size, err := io.Copy(io.MultiWriter(file, hash), io.LimitReader(r, limit+1))
+	if err == nil && size > limit {
+		err = errTooLarge
+	}
+	if err != nil {
+		file.Close()
+		os.Remove(file.Name())
+		return nil, err
+	}
+
+	return &spooledFile{File: file, size: size, checksum: hex.EncodeToString(hash.Sum(nil))}, nil
+}
+
+func (f *spooledFile) Close() error {
+	err := f.File.Close()
+	os.Remove(f.Name())
+	return err
+}
+
+// storeAttachment writes a spooled upload to the blob store under the
+// attachment's storage key, filling in its type, kind, size, checksum and
+// media metadata on the way. Images are stored with their location data
+// removed, next to a thumbnail. Nothing is kept if the type is not allowed,
+// the checksum, which is of the file as uploaded, does not match, or the
+// image is too broken to remove its location from.
+func storeAttachment(ctx context.Context, attachment *models.Attachment, file *spooledFile, declaredType, checksum string) error {
+	head := make([]byte, 512)
+	n, err := file.ReadAt(head, 0)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return err
+	}
+
+	attachment.MimeType = utils.DetectMimeType(declaredType, head[:n])
+	if !utils.AttachmentTypeAllowed(attachment.MimeType) {
+		return errTypeNotAllowed
+	}
+	attachment.Kind = utils.AttachmentKind(attachment.MimeType)
+
+	if checksum != "" && !strings.EqualFold(checksum, file.checksum) {
+		return errChecksumMismatch
+	}
+	attachment.Size = file.size
+	attachment.Received = file.size
+	attachment.Checksum = file.checksum
+
+	// Other files are stored as they are, straight from the spool.
+	if attachment.Kind == models.AttachmentFile {
+		return storage.Blobs.Put(ctx, attachment.StorageKey, io.NewSectionReader(file, 0, file.size), file.size, attachment.MimeType)
+	}
+
+	data, unmap, err := mapFile(file.File, file.size)
+	if err != nil {
+		return err
+	}
+	defer unmap()
+
+	// Process may remove metadata, so the size and checksum are taken again
+	// from what is stored.
+	result, err := media.Process(attachment.MimeType, data)
+	if err != nil {
+		return err
+	}
+	attachment.Size = int64(len(result.Data))
+	attachment.Received = attachment.Size
+	attachment.Checksum = sha256Hex(result.Data)
+	attachment.Width = result.Width
+	attachment.Height = result.Height
+	attachment.Duration = result.Duration
+	attachment.Blurhash = result.Blurhash
+
+	if err := storage.Blobs.Put(ctx, attachment.StorageKey, bytes.NewReader(result.Data), attachment.Size, attachment.MimeType); err != nil {
+		return err
+	}
+
+	if result.Thumbnail != nil {
+		thumbnail := &models.Attachment_Variant{
+			StorageKey: "thumbnails/" + attachment.ID.Hex(),
+			MimeType:   media.ThumbnailMimeType,
+			Size:       int64(len(result.Thumbnail)),
+			Width:      result.ThumbnailWidth,
+			Height:     result.ThumbnailHeight,
+		}
+		// An image without a thumbnail is still usable, so this is not fatal.
+		if err := storage.Blobs.Put(ctx, thumbnail.StorageKey, bytes.NewReader(result.Thumbnail), thumbnail.Size, thumbnail.MimeType); err != nil {
+			log.Println("Failed to store thumbnail:", err)
+		} else {
+			attachment.Thumbnail = thumbnail
+		}
+	}
+	return nil
+}
+
+// deleteAttachmentBlobs removes everything stored for an attachment: its
+// blob, its thumbnail and the chunks of an unfinished upload.
+func deleteAttachmentBlobs(attachment *models.Attachment) {
+	keys := append([]string{attachment.StorageKey}, attachment.ChunkKeys...)
+	if attachment.Thumbnail != nil {
+		keys = append(keys, attachment.Thumbnail.StorageKey)
+	}
+	for _, key := range keys {
+		if err := storage.Blobs.Delete(context.Background(), key); err != nil {
+			log.Println("Failed to delete attachment blob:", err)
+		}
+	}
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func respondAttachmentError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, errTypeNotAllowed):
+		c.JSON(http.StatusUnsupportedMediaType, gin.H{"error": "File type not allowed"})
+	case errors.Is(err, errChecksumMismatch):
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "Checksum mismatch", "details": err.Error()})
+	case errors.Is(err, media.ErrMalformedImage):
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "Malformed image", "details": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to store attachment", "details": err.Error()})
+	}
+}
@@ -0,0 +1,179 @@
+package message_handler
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/Ahmeds-Library/Chat-App/internal/media"
+	"github.com/Ahmeds-Library/Chat-App/internal/models"
+	"github.com/Ahmeds-Library/Chat-App/internal/storage"
+	"github.com/Ahmeds-Library/Chat-App/internal/storage/s3test"
+)
+
+// useS3 points the handlers at a fresh S3 stand-in for the test.
+func useS3(t *testing.T) *s3test.Server {
+	t.Helper()
+
+	server := s3test.NewServer()
+	t.Cleanup(server.Close)
+
+	store, err := storage.NewS3Store(context.Background(), storage.S3Config{
+		Endpoint: server.Endpoint(),
+		Bucket:   "attachments",
+		Region:   "us-east-1",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	previous := storage.Blobs
+	storage.Blobs = store
+	t.Cleanup(func() { storage.Blobs = previous })
+	return server
+}
+
+func spoolBytes(t *testing.T, data []byte) *spooledFile {
+	t.Helper()
+
+	file, err := spool(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { file.Close() })
+	return file
+}
+
+func TestSpoolRejectsOversizedFiles(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("TMPDIR", dir)
+
+	if _, err := spool(strings.NewReader("12345"), 4); !errors.Is(err, errTooLarge) {
+		t.Fatalf("spool: %v, want errTooLarge", err)
+	}
+
+	if entries, _ := os.ReadDir(dir); len(entries) != 0 {
+		t.Fatalf("temporary file left behind: %v", entries)
+	}
+}
+
+func TestSpoolChecksumsFile(t *testing.T) {
+	data := []byte("checksum me")
+	file := spoolBytes(t, data)
+
+	if file.size != int64(len(data)) {
+		t.Fatalf("size %d, want %d", file.size, len(data))
+	}
+	if file.checksum != sha256Hex(data) {
+		t.Fatalf("checksum %s, want %s", file.checksum, sha256Hex(data))
+	}
+}
+
+// A file past the client's part size goes to S3 as a multipart upload,
+// read from the spool without being loaded into memory.
+func TestStoreAttachmentStreamsLargeFile(t *testing.T) {
+	server := useS3(t)
+
+	data := bytes.Repeat([]byte("a line of a large text file\n"), (20<<20)/28)
+	attachment := newAttachment("user-1", "dm:user-1:user-2", "notes.txt", int64(len(data)))
+	file := spoolBytes(t, data)
+
+	if err := storeAttachment(context.Background(), attachment, file, "text/plain", sha256Hex(data)); err != nil {
+		t.Fatal(err)
+	}
+
+	if attachment.MimeType != "text/plain" || attachment.Kind != models.AttachmentFile {
+		t.Fatalf("type %s, kind %s", attachment.MimeType, attachment.Kind)
+	}
+	if attachment.Size != int64(len(data)) || attachment.Checksum != sha256Hex(data) {
+		t.Fatalf("size %d, checksum %s", attachment.Size, attachment.Checksum)
+	}
+	if stored, _ := server.Object("attachments", attachment.StorageKey); !bytes.Equal(stored, data) {
+		t.Fatalf("stored %d bytes, want %d", len(stored), len(data))
+	}
+}
+
+func TestStoreAttachmentStripsImageMetadata(t *testing.T) {
+	server := useS3(t)
+
+	img := image.NewRGBA(image.Rect(0, 0, 640, 480))
+	for y := 0; y < 480; y++ {
+		for x := 0; x < 640; x++ {
+			img.Set(x, y, color.RGBA{uint8(x), uint8(y), 128, 255})
+		}
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+	// Slip an EXIF chunk in after the header chunk.
+	encoded := buf.Bytes()
+	headerEnd := 8 + 12 + 13
+	data := append(append(append([]byte{}, encoded[:headerEnd]...), pngChunk("eXIf", []byte("MM\x00*GPS data"))...), encoded[headerEnd:]...)
+
+	attachment := newAttachment("user-1", "dm:user-1:user-2", "photo.png", int64(len(data)))
+	if err := storeAttachment(context.Background(), attachment, spoolBytes(t, data), "image/png", sha256Hex(data)); err != nil {
+		t.Fatal(err)
+	}
+
+	stored, _ := server.Object("attachments", attachment.StorageKey)
+	if !bytes.Equal(stored, encoded) {
+		t.Fatalf("stored image still has its EXIF chunk")
+	}
+	if attachment.Checksum != sha256Hex(encoded) || attachment.Size != int64(len(encoded)) {
+		t.Fatalf("checksum and size describe the upload, not the stored file")
+	}
+	if attachment.Width != 640 || attachment.Height != 480 {
+		t.Fatalf("dimensions %dx%d, want 640x480", attachment.Width, attachment.Height)
+	}
+	if attachment.Thumbnail == nil {
+		t.Fatal("no thumbnail")
+	}
+	if _, ok := server.Object("attachments", attachment.Thumbnail.StorageKey); !ok {
+		t.Fatal("thumbnail not stored")
+	}
+}
+
+func TestStoreAttachmentRejectsUploads(t *testing.T) {
+	server := useS3(t)
+	text := []byte("just some text")
+
+	tests := []struct {
+		name     string
+		data     []byte
+		checksum string
+		want     error
+	}{
+		{"type not allowed", []byte("MZ\x90\x00\x03\x00\x00\x00\x04\x00\x00\x00\xff\xff"), "", errTypeNotAllowed},
+		{"checksum mismatch", text, sha256Hex([]byte("something else")), errChecksumMismatch},
+		{"malformed image", []byte("\xFF\xD8\xFF\xE1\x10\x00Exif\x00\x00MM\x00\x2A"), "", media.ErrMalformedImage},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			attachment := newAttachment("user-1", "dm:user-1:user-2", "file", int64(len(test.data)))
+			err := storeAttachment(context.Background(), attachment, spoolBytes(t, test.data), "application/octet-stream", test.checksum)
+			if !errors.Is(err, test.want) {
+				t.Fatalf("storeAttachment: %v, want %v", err, test.want)
+			}
+		})
+	}
+
+	if keys := server.Keys("attachments"); len(keys) != 0 {
+		t.Fatalf("rejected uploads were stored: %v", keys)
+	}
+}
+
+func pngChunk(chunkType string, data []byte) []byte {
+	chunk := binary.BigEndian.AppendUint32(nil, uint32(len(data)))
+	chunk = append(chunk, chunkType...)
+	chunk = append(chunk, data...)
+	return binary.BigEndian.AppendUint32(chunk, crc32.ChecksumIEEE(chunk[4:]))
+}
@@ -3,12 +3,14 @@ package message_handler
 import (
 	"database/sql"
 	"net/http"
+	"strconv"
 
 	mongo_db "github.com/Ahmeds-Library/Chat-App/internal/database/Mongo_DB"
 	pg_admin "github.com/Ahmeds-Library/Chat-App/internal/database/Pg_Admin"
 	"github.com/Ahmeds-Library/Chat-App/internal/models"
 	"github.com/Ahmeds-Library/Chat-App/internal/utils"
 	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
 )
 
@@ -26,32 +28,83 @@ func GetChatListHandler(mongoClient *mongo.Client, pgConn *sql.DB) gin.HandlerFu
 			return
 		}
 
+		limit := int64(utils.DefaultPageSize)
+		if value := c.Query("limit"); value != "" {
+			parsed, err := strconv.ParseInt(value, 10, 64)
+			if err != nil || parsed <= 0 {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request", "details": "limit must be a positive number"})
+				return
+			}
+			limit = min(parsed, utils.MaxPageSize)
+		}
+
+		var before primitive.ObjectID
+		if value := c.Query("before"); value != "" {
+			var err error
+			before, err = primitive.ObjectIDFromHex(value)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request", "details": "invalid before cursor"})
+				return
+			}
+		}
+
+		groups, err := pg_admin.GetUserConversations(userID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Postgres error: " + err.Error()})
+			return
+		}
+
+		groupIDs := make([]string, len(groups))
+		groupNames := make(map[string]string, len(groups))
+		for i, group := range groups {
+			groupIDs[i] = group.ID
+			groupNames[group.ID] = group.Name
+		}
+
 		database := mongoClient.Database("chat-app")
-		chatPartners, err := mongo_db.GetChatPartners(database, userID)
+		chatPartners, next, err := mongo_db.GetChatPartners(database, userID, groupIDs, before, limit)
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Mongo error: " + err.Error()})
 			return
 		}
 
-		var fullList []models.Chatlist_Item
+		var partnerIDs []string
+		for _, chat := range chatPartners {
+			if partnerID, ok := utils.DirectConversationPartner(chat.ConversationID, userID); ok {
+				partnerIDs = append(partnerIDs, partnerID)
+			}
+		}
+
+		users, err := pg_admin.GetDataFromIDs(partnerIDs)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Postgres error: " + err.Error()})
+			return
+		}
 
+		page := models.Chatlist_Page{Chats: []models.Chatlist_Item{}, Next_Cursor: next}
 		for _, chat := range chatPartners {
+			item := models.Chatlist_Item{
+				ConversationID:     chat.ConversationID,
+				LastMessageID:      chat.LastMessageID.Hex(),
+				LastMessage:        chat.LastMessage,
+				LastMessageAt:      chat.LastMessageAt.Format("2006-01-02 15:04:05"),
+				LastMessageDeleted: chat.LastMessageDeleted,
+				UnreadCount:        chat.UnreadCount,
+			}
 
-			userData, err := pg_admin.GetDataFromID(chat.PartnerID)
-			if err != nil {
-				c.JSON(http.StatusInternalServerError, gin.H{"error": "Postgres error: " + err.Error()})
-				return
+			if partnerID, ok := utils.DirectConversationPartner(chat.ConversationID, userID); ok {
+				userData := users[partnerID]
+				item.PartnerID = partnerID
+				item.PartnerName = userData.Username
+				item.PartnerNumber = userData.Number
+			} else {
+				item.IsGroup = true
+				item.GroupName = groupNames[chat.ConversationID]
 			}
 
-			fullList = append(fullList, models.Chatlist_Item{
-				PartnerID:     chat.PartnerID,
-				PartnerName:   userData.Username,
-				PartnerNumber: userData.Number,
-				LastMessage:   chat.LastMessage,
-				LastMessageAt: chat.LastMessageAt.Format("2006-01-02 15:04:05"),
-			})
+			page.Chats = append(page.Chats, item)
 		}
 
-		c.JSON(http.StatusOK, fullList)
+		c.JSON(http.StatusOK, page)
 	}
-}
\ No newline at end of file
+}
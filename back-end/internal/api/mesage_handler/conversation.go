@@ -0,0 +1,132 @@
+package message_handler
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	pg_admin "github.com/Ahmeds-Library/Chat-App/internal/database/Pg_Admin"
+	"github.com/Ahmeds-Library/Chat-App/internal/models"
+	"github.com/Ahmeds-Library/Chat-App/internal/utils"
+	"github.com/gin-gonic/gin"
+)
+
+// resolveConversation works out which conversation a request addresses:
+// conversationID when it is set, otherwise the direct conversation with
+// partnerNumber. It returns the conversation ID and, for a direct
+// conversation, the partner's user ID. A direct conversation ID is only
+// accepted in the canonical form DirectConversationID gives. When the user may not use the conversation it writes the
+// error response and returns false.
+func resolveConversation(c *gin.Context, userID, partnerNumber, conversationID string) (string, string, bool) {
+	if conversationID != "" {
+		if utils.IsDirectConversation(conversationID) {
+			partnerID, ok := utils.DirectConversationPartner(conversationID, userID)
+			if !ok {
+				c.JSON(http.StatusForbidden, gin.H{"error": "Not a participant in this conversation"})
+				return "", "", false
+			}
+
+			partner, err := pg_admin.GetDataFromID(partnerID)
+			if err != nil {
+				if err.Error() == "user not found" || strings.HasPrefix(err.Error(), "invalid userID format") {
+					c.JSON(http.StatusNotFound, gin.H{"error": "Receiver not found", "details": err.Error()})
+					return "", "", false
+				}
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error", "details": err.Error()})
+				return "", "", false
+			}
+			// The lookup parses the ID, so "01" finds user 1: only accept the
+			// ID the partner's real ID produces, and never a chat with oneself.
+			canonicalID := utils.DirectConversationID(userID, partner.ID)
+			if canonicalID != conversationID || partner.ID == userID {
+				c.JSON(http.StatusForbidden, gin.H{"error": "Not a participant in this conversation"})
+				return "", "", false
+			}
+			return canonicalID, partner.ID, true
+		}
+
+		if !requireGroupMember(c, conversationID, userID) {
+			return "", "", false
+		}
+		return conversationID, "", true
+	}
+
+	partner, err := pg_admin.GetUserByPhone(partnerNumber)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Receiver not found", "details": err.Error()})
+		return "", "", false
+	}
+	if userID == partner.ID {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request", "details": "Sender and receiver cannot be the same"})
+		return "", "", false
+	}
+	return utils.DirectConversationID(userID, partner.ID), partner.ID, true
+}
+
+// requireNotBlocked writes a 403 response and returns false when either user
+// has blocked the other.
+func requireNotBlocked(c *gin.Context, userID, partnerID string) bool {
+	blocked, err := pg_admin.IsBlocked(userID, partnerID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error", "details": err.Error()})
+		return false
+	}
+	if blocked {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Blocked", "details": "You cannot message this user"})
+		return false
+	}
+	return true
+}
+
+// requireGroupMember writes a 404 or 403 response and returns false unless
+// userID belongs to the group.
+func requireGroupMember(c *gin.Context, conversationID, userID string) bool {
+	_, err := pg_admin.GetMemberRole(conversationID, userID)
+	switch {
+	case err == nil:
+		return true
+	case errors.Is(err, pg_admin.ErrConversationNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Group not found"})
+	case errors.Is(err, pg_admin.ErrNotMember):
+		c.JSON(http.StatusForbidden, gin.H{"error": "Not a member of this group"})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error", "details": err.Error()})
+	}
+	return false
+}
+
+// requireMessageParticipant writes an error response and returns false
+// unless userID can see msg: either side of a direct message, or a member of
+// the group it was sent to.
+func requireMessageParticipant(c *gin.Context, msg *models.Save_Message, userID string) bool {
+	if msg.ReceiverID != "" {
+		if msg.SenderID == userID || msg.ReceiverID == userID {
+			return true
+		}
+		c.JSON(http.StatusForbidden, gin.H{"error": "Not a participant in this conversation"})
+		return false
+	}
+	return requireGroupMember(c, msg.ConversationID, userID)
+}
+
+// accessUserID returns the caller's user ID from a valid access token,
+// writing the error response itself when there is none.
+func accessUserID(c *gin.Context) (string, bool) {
+	claims, ok := utils.ValidateToken(c)
+	if !ok {
+		return "", false
+	}
+
+	token_type, ok := claims["token_type"].(string)
+	if !ok || token_type != "Access" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token type", "details": "Use a valid access token"})
+		return "", false
+	}
+
+	userID, ok := claims["id"].(string)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token", "details": "User ID not found in token"})
+		return "", false
+	}
+	return userID, true
+}
@@ -0,0 +1,70 @@
+package message_handler
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	mongo_db "github.com/Ahmeds-Library/Chat-App/internal/database/Mongo_DB"
+	"github.com/Ahmeds-Library/Chat-App/internal/models"
+	"github.com/Ahmeds-Library/Chat-App/internal/utils"
+	"github.com/gin-gonic/gin"
+)
+
+// DeleteMessageHandler deletes a message for the caller only, or, when the
+// caller sent it and the delete window has not passed, for everyone.
+func DeleteMessageHandler(c *gin.Context) {
+	userID, ok := accessUserID(c)
+	if !ok {
+		return
+	}
+
+	var req models.Delete_Message
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input", "details": err.Error()})
+		return
+	}
+
+	db := mongo_db.MongoClient.Database("chat-app")
+
+	msg, err := mongo_db.GetMessage(db, req.ID)
+	if errors.Is(err, mongo_db.ErrMessageNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Message not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load message", "details": err.Error()})
+		return
+	}
+
+	if !requireMessageParticipant(c, msg, userID) {
+		return
+	}
+
+	if req.Scope == models.DeleteForMe {
+		if err := mongo_db.DeleteMessageForMe(db, msg.ID, userID); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete message", "details": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"message": "Message deleted for you", "id": req.ID})
+		return
+	}
+
+	if msg.SenderID != userID {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only the sender can delete a message for everyone"})
+		return
+	}
+
+	now := time.Now()
+	if window := utils.DeleteWindow(); window > 0 && now.Sub(msg.CreatedAt) > window {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Delete window has passed", "details": "Messages can be deleted for everyone up to " + window.String() + " after sending"})
+		return
+	}
+
+	if err := mongo_db.DeleteMessageForEveryone(db, msg.ID, now); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete message", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Message deleted for everyone", "id": req.ID})
+}
@@ -1,10 +1,10 @@
 package message_handler
 
 import (
+	"errors"
 	"net/http"
 
 	mongo_db "github.com/Ahmeds-Library/Chat-App/internal/database/Mongo_DB"
-	pg_admin "github.com/Ahmeds-Library/Chat-App/internal/database/Pg_Admin"
 	"github.com/Ahmeds-Library/Chat-App/internal/models"
 	"github.com/Ahmeds-Library/Chat-App/internal/utils"
 	"github.com/gin-gonic/gin"
@@ -34,24 +34,22 @@ func Get_Message(c *gin.Context) {
 		return
 	}
 
-	receiverID, err := pg_admin.GetUserByPhone(req.Receiver_Number)
-	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Receiver not found", "details": err.Error()})
-		return
-	}
-
-	if senderID == receiverID.ID {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request", "details": "Sender and receiver cannot be the same"})
+	conversationID, _, ok := resolveConversation(c, senderID, req.Receiver_Number, req.Conversation_ID)
+	if !ok {
 		return
 	}
 
 	db := mongo_db.MongoClient
 
-	messages, err := utils.Message_Fetcher(db, senderID, receiverID.ID)
+	page, err := utils.Message_Fetcher(db, senderID, conversationID, *req)
+	if errors.Is(err, utils.ErrInvalidPage) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request", "details": err.Error()})
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get messages", "details": err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, messages)
+	c.JSON(http.StatusOK, page)
 }
@@ -0,0 +1,21 @@
+//go:build linux || darwin || freebsd || netbsd || openbsd
+
+package message_handler
+
+import (
+	"os"
+	"syscall"
+)
+
+// mapFile maps a spooled upload into memory read-only, so it can be parsed
+// as a byte slice without copying it onto the heap. Call unmap when done.
+func mapFile(file *os.File, size int64) (data []byte, unmap func(), err error) {
+	if size == 0 {
+		return nil, func() {}, nil
+	}
+	data, err = syscall.Mmap(int(file.Fd()), 0, int(size), syscall.PROT_READ, syscall.MAP_SHARED)
+	if err != nil {
+		return nil, nil, err
+	}
+	return data, func() { syscall.Munmap(data) }, nil
+}
@@ -0,0 +1,17 @@
+//go:build !(linux || darwin || freebsd || netbsd || openbsd)
+
+package message_handler
+
+import (
+	"io"
+	"os"
+)
+
+// mapFile reads a spooled upload into memory where mmap is not available.
+func mapFile(file *os.File, size int64) (data []byte, unmap func(), err error) {
+	data = make([]byte, size)
+	if _, err := io.ReadFull(io.NewSectionReader(file, 0, size), data); err != nil {
+		return nil, nil, err
+	}
+	return data, func() {}, nil
+}
@@ -0,0 +1,61 @@
+package message_handler
+
+import (
+	"net/http"
+
+	mongo_db "github.com/Ahmeds-Library/Chat-App/internal/database/Mongo_DB"
+	"github.com/Ahmeds-Library/Chat-App/internal/models"
+	"github.com/Ahmeds-Library/Chat-App/internal/utils"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func MarkReadHandler(c *gin.Context) {
+	claims, ok := utils.ValidateToken(c)
+	if !ok {
+		return
+	}
+
+	token_type, ok := claims["token_type"].(string)
+	if !ok || token_type != "Access" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token type", "details": "Use a valid access token"})
+		return
+	}
+
+	readerID, ok := claims["id"].(string)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token", "details": "User ID not found in token"})
+		return
+	}
+
+	var req models.Mark_Read
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body", "details": err.Error()})
+		return
+	}
+	if !primitive.IsValidObjectID(req.Message_ID) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body", "details": "invalid message ID format"})
+		return
+	}
+
+	conversationID, partnerID, ok := resolveConversation(c, readerID, req.Partner_Number, req.Conversation_ID)
+	if !ok {
+		return
+	}
+
+	db := mongo_db.MongoClient.Database("chat-app")
+
+	var count int64
+	var err error
+	if partnerID == "" {
+		count, err = mongo_db.MarkGroupRead(db, readerID, conversationID, req.Message_ID)
+	} else {
+		count, err = mongo_db.MarkConversationRead(db, readerID, conversationID, req.Message_ID)
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to mark messages read", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Messages marked read", "count": count})
+}
@@ -0,0 +1,38 @@
+package message_handler
+
+import (
+	"errors"
+	"net/http"
+	"slices"
+
+	mongo_db "github.com/Ahmeds-Library/Chat-App/internal/database/Mongo_DB"
+	"github.com/gin-gonic/gin"
+)
+
+// MessageHistoryHandler returns every version of a message to the
+// conversation's participants. Messages deleted for everyone, or for the
+// caller, have no history.
+func MessageHistoryHandler(c *gin.Context) {
+	userID, ok := accessUserID(c)
+	if !ok {
+		return
+	}
+
+	db := mongo_db.MongoClient.Database("chat-app")
+
+	msg, err := mongo_db.GetMessage(db, c.Param("id"))
+	if errors.Is(err, mongo_db.ErrMessageNotFound) || (err == nil && (msg.Deleted || slices.Contains(msg.DeletedFor, userID))) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Message not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load message", "details": err.Error()})
+		return
+	}
+
+	if !requireMessageParticipant(c, msg, userID) {
+		return
+	}
+
+	c.JSON(http.StatusOK, msg.History())
+}
@@ -0,0 +1,72 @@
+package message_handler
+
+import (
+	"errors"
+	"net/http"
+	"slices"
+	"strings"
+
+	mongo_db "github.com/Ahmeds-Library/Chat-App/internal/database/Mongo_DB"
+	"github.com/Ahmeds-Library/Chat-App/internal/models"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// AddReactionHandler adds the caller's emoji to a message and returns the
+// message's reactions.
+func AddReactionHandler(c *gin.Context) {
+	var req models.React_Message
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input", "details": err.Error()})
+		return
+	}
+	changeReaction(c, strings.TrimSpace(req.Emoji), mongo_db.AddReaction)
+}
+
+// RemoveReactionHandler removes the caller's emoji, given as the emoji query
+// parameter, from a message and returns the message's reactions.
+func RemoveReactionHandler(c *gin.Context) {
+	changeReaction(c, strings.TrimSpace(c.Query("emoji")), mongo_db.RemoveReaction)
+}
+
+func changeReaction(c *gin.Context, emoji string, change func(*mongo.Database, primitive.ObjectID, string, string) error) {
+	userID, ok := accessUserID(c)
+	if !ok {
+		return
+	}
+
+	if emoji == "" || len(emoji) > 32 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input", "details": "emoji must be between 1 and 32 bytes"})
+		return
+	}
+
+	db := mongo_db.MongoClient.Database("chat-app")
+
+	msg, err := mongo_db.GetMessage(db, c.Param("id"))
+	if errors.Is(err, mongo_db.ErrMessageNotFound) || (err == nil && (msg.Deleted || slices.Contains(msg.DeletedFor, userID))) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Message not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load message", "details": err.Error()})
+		return
+	}
+
+	if !requireMessageParticipant(c, msg, userID) {
+		return
+	}
+
+	if err := change(db, msg.ID, userID, emoji); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update reactions", "details": err.Error()})
+		return
+	}
+
+	msg, err = mongo_db.GetMessage(db, msg.ID.Hex())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load message", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message_id": msg.ID.Hex(), "reactions": models.SummarizeReactions(msg.Reactions)})
+}
@@ -0,0 +1,118 @@
+package message_handler
+
+import (
+	"errors"
+	"net/http"
+	"slices"
+	"strconv"
+
+	mongo_db "github.com/Ahmeds-Library/Chat-App/internal/database/Mongo_DB"
+	"github.com/Ahmeds-Library/Chat-App/internal/models"
+	"github.com/Ahmeds-Library/Chat-App/internal/utils"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// resolveReply fills in the quoted message and thread of a new message from
+// the request. Both must belong to the message's conversation, and threads
+// only exist in groups. When either is invalid it writes the error response
+// and returns false.
+func resolveReply(c *gin.Context, db *mongo.Database, userID string, req *models.Request_Message, msg *models.Save_Message) bool {
+	if req.Reply_To != "" {
+		quoted, err := mongo_db.GetMessage(db, req.Reply_To)
+		if err != nil && !errors.Is(err, mongo_db.ErrMessageNotFound) {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load quoted message", "details": err.Error()})
+			return false
+		}
+		if err != nil || !visibleIn(quoted, msg.ConversationID, userID) || quoted.Deleted {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Quoted message not found"})
+			return false
+		}
+
+		msg.ReplyTo = &models.Message_Reply{
+			MessageID: quoted.ID,
+			SenderID:  quoted.SenderID,
+			Message:   quoted.Message,
+			CreatedAt: quoted.CreatedAt,
+		}
+	}
+
+	if req.Thread_ID != "" {
+		if utils.IsDirectConversation(msg.ConversationID) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request", "details": "Threads are only available in groups"})
+			return false
+		}
+
+		root, err := mongo_db.GetMessage(db, req.Thread_ID)
+		if err != nil && !errors.Is(err, mongo_db.ErrMessageNotFound) {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load thread", "details": err.Error()})
+			return false
+		}
+		if err != nil || !visibleIn(root, msg.ConversationID, userID) || root.Deleted {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Thread not found"})
+			return false
+		}
+		if root.ThreadID != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request", "details": "A thread reply cannot start a thread"})
+			return false
+		}
+
+		msg.ThreadID = &root.ID
+	}
+
+	return true
+}
+
+// visibleIn reports whether msg belongs to conversationID and has not been
+// deleted by userID for themselves.
+func visibleIn(msg *models.Save_Message, conversationID, userID string) bool {
+	return msg.ConversationID == conversationID && !slices.Contains(msg.DeletedFor, userID)
+}
+
+// GetThreadHandler returns one page of the replies to a group message. It
+// takes the same before, after and limit parameters as get_message, as query
+// parameters.
+func GetThreadHandler(c *gin.Context) {
+	userID, ok := accessUserID(c)
+	if !ok {
+		return
+	}
+
+	req := models.Get_Message{Before: c.Query("before"), After: c.Query("after")}
+	if value := c.Query("limit"); value != "" {
+		limit, err := strconv.ParseInt(value, 10, 64)
+		if err != nil || limit <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request", "details": "limit must be a positive number"})
+			return
+		}
+		req.Limit = limit
+	}
+
+	db := mongo_db.MongoClient.Database("chat-app")
+
+	root, err := mongo_db.GetMessage(db, c.Param("id"))
+	if errors.Is(err, mongo_db.ErrMessageNotFound) || (err == nil && (root.ThreadID != nil || slices.Contains(root.DeletedFor, userID))) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Thread not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load thread", "details": err.Error()})
+		return
+	}
+
+	if !requireMessageParticipant(c, root, userID) {
+		return
+	}
+
+	page, err := utils.Thread_Fetcher(mongo_db.MongoClient, userID, root.ID, req)
+	if errors.Is(err, utils.ErrInvalidPage) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request", "details": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get thread", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.Thread_Page{Root: *root, Message_Page: *page})
+}
@@ -0,0 +1,108 @@
+package message_handler
+
+import (
+	"net/http"
+	"time"
+
+	mongo_db "github.com/Ahmeds-Library/Chat-App/internal/database/Mongo_DB"
+	pg_admin "github.com/Ahmeds-Library/Chat-App/internal/database/Pg_Admin"
+	"github.com/Ahmeds-Library/Chat-App/internal/models"
+	"github.com/Ahmeds-Library/Chat-App/internal/utils"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// SearchMessagesHandler finds messages containing the words of q, newest
+// first, each with a snippet showing where they matched.
+func SearchMessagesHandler(c *gin.Context) {
+	userID, ok := accessUserID(c)
+	if !ok {
+		return
+	}
+
+	var req models.Search_Request
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input", "details": err.Error()})
+		return
+	}
+
+	search := models.Message_Search{
+		Query:  req.Q,
+		UserID: userID,
+		Limit:  min(req.Limit, utils.MaxPageSize),
+	}
+	if search.Limit == 0 {
+		search.Limit = utils.DefaultPageSize
+	}
+
+	var err error
+	if search.From, err = parseSearchTime(req.From); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request", "details": "from must be an RFC 3339 timestamp"})
+		return
+	}
+	if search.To, err = parseSearchTime(req.To); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request", "details": "to must be an RFC 3339 timestamp"})
+		return
+	}
+	if req.Before != "" {
+		if search.Before, err = primitive.ObjectIDFromHex(req.Before); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request", "details": "invalid before cursor"})
+			return
+		}
+	}
+
+	if req.Conversation_ID != "" || req.Receiver_Number != "" {
+		search.ConversationID, _, ok = resolveConversation(c, userID, req.Receiver_Number, req.Conversation_ID)
+		if !ok {
+			return
+		}
+	} else {
+		groups, err := pg_admin.GetUserConversations(userID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Postgres error: " + err.Error()})
+			return
+		}
+		for _, group := range groups {
+			search.GroupIDs = append(search.GroupIDs, group.ID)
+		}
+	}
+
+	if req.Sender_Number != "" {
+		sender, err := pg_admin.GetUserByPhone(req.Sender_Number)
+		if err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Sender not found", "details": err.Error()})
+			return
+		}
+		search.SenderID = sender.ID
+	}
+
+	messages, err := mongo_db.SearchMessages(mongo_db.MongoClient.Database("chat-app"), search)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to search messages", "details": err.Error()})
+		return
+	}
+
+	page := models.Search_Page{Results: []models.Search_Result{}}
+	if int64(len(messages)) > search.Limit {
+		messages = messages[:search.Limit]
+		page.Next_Cursor = messages[len(messages)-1].ID.Hex()
+	}
+
+	terms := utils.SearchTerms(req.Q)
+	for _, msg := range messages {
+		msg.ReactionSummary = models.SummarizeReactions(msg.Reactions)
+		page.Results = append(page.Results, models.Search_Result{
+			Message: msg,
+			Snippet: utils.Snippet(msg.Message, terms),
+		})
+	}
+
+	c.JSON(http.StatusOK, page)
+}
+
+func parseSearchTime(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	return time.Parse(time.RFC3339, value)
+}
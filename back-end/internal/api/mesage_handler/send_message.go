@@ -1,14 +1,17 @@
 package message_handler
 
 import (
+	"errors"
 	"fmt"
+	"log"
 	"net/http"
+	"strings"
 
 	mongo_db "github.com/Ahmeds-Library/Chat-App/internal/database/Mongo_DB"
-	pg_admin "github.com/Ahmeds-Library/Chat-App/internal/database/Pg_Admin"
 	"github.com/Ahmeds-Library/Chat-App/internal/models"
 	"github.com/Ahmeds-Library/Chat-App/internal/utils"
 	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
 )
 
@@ -41,17 +44,46 @@ func SendMessageHandler(mongoClient *mongo.Client) gin.HandlerFunc {
 			return
 		}
 
-		receiver, err := pg_admin.GetUserByPhone(req.Receiver_Number)
-		if err != nil {
-			c.JSON(http.StatusNotFound, gin.H{"error": "Receiver not found", "details": err.Error()})
+		conversationID, receiverID, ok := resolveConversation(c, senderID, req.Receiver_Number, req.Conversation_ID)
+		if !ok {
+			return
+		}
+		if receiverID != "" && !requireNotBlocked(c, senderID, receiverID) {
 			return
 		}
-		if senderID == receiver.ID {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request", "details": "Sender and receiver cannot be the same"})
+
+		message := models.Save_Message{
+			SenderID:       senderID,
+			ReceiverID:     receiverID,
+			ConversationID: conversationID,
+			Message:        req.Message,
+		}
+		db := mongoClient.Database("chat-app")
+		if !resolveReply(c, db, senderID, req, &message) {
 			return
 		}
 
-		mongo_db.SaveMessage(c, senderID, *receiver, *req)
+		if req.Attachment_ID != "" {
+			message.ID = primitive.NewObjectID()
+			attachment, err := mongo_db.ClaimAttachment(db, req.Attachment_ID, senderID, conversationID, message.ID)
+			if errors.Is(err, mongo_db.ErrAttachmentNotFound) {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Attachment not found", "details": "Upload the file to this conversation first"})
+				return
+			}
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to attach file", "details": err.Error()})
+				return
+			}
+			message.Attachment = attachment.MessageAttachment()
+		} else if strings.TrimSpace(req.Message) == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body", "details": "message cannot be empty"})
+			return
+		}
 
+		if err := mongo_db.SaveMessage(c, message); err != nil && message.Attachment != nil {
+			if err := mongo_db.ReleaseAttachment(db, message.Attachment.ID); err != nil {
+				log.Println("Failed to release attachment:", err)
+			}
+		}
 	}
 }
@@ -1,6 +1,7 @@
 package message_handler
 
 import (
+	"errors"
 	"net/http"
 	"time"
 
@@ -31,6 +32,7 @@ func UpdateMessageHandler(c *gin.Context) {
 
 	senderID := claims["id"].(string)
 	updatedTime := time.Now()
+	editWindow := utils.EditWindow()
 
 	db := mongo_db.MongoClient.Database("chat-app")
 
@@ -40,9 +42,20 @@ func UpdateMessageHandler(c *gin.Context) {
 		senderID,
 		req.New_Message,
 		updatedTime,
+		editWindow,
 	)
 
-	if err != nil {
+	switch {
+	case errors.Is(err, mongo_db.ErrMessageNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "no matching message found or unauthorized"})
+		return
+	case errors.Is(err, mongo_db.ErrEditWindowExpired):
+		c.JSON(http.StatusForbidden, gin.H{"error": "Edit window has passed", "details": "Messages can be edited up to " + editWindow.String() + " after sending"})
+		return
+	case errors.Is(err, mongo_db.ErrConcurrentEdit):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	case err != nil:
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
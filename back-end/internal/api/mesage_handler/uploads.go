@@ -0,0 +1,252 @@
+package message_handler
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+
+	mongo_db "github.com/Ahmeds-Library/Chat-App/internal/database/Mongo_DB"
+	"github.com/Ahmeds-Library/Chat-App/internal/media"
+	"github.com/Ahmeds-Library/Chat-App/internal/models"
+	"github.com/Ahmeds-Library/Chat-App/internal/storage"
+	"github.com/Ahmeds-Library/Chat-App/internal/utils"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// Resumable uploads are sent in chunks with PATCH, each carrying the offset it
+// starts at in the Upload-Offset header. A client that lost track resumes
+// from the received count returned by GET. Chunks are kept in the blob store
+// and joined once the last one arrives, so any replica can take any chunk.
+
+// StartUploadHandler begins a chunked upload.
+func StartUploadHandler(c *gin.Context) {
+	userID, ok := accessUserID(c)
+	if !ok {
+		return
+	}
+
+	var req models.Start_Upload
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input", "details": err.Error()})
+		return
+	}
+
+	if maxSize := utils.MaxAttachmentSize(); req.Size > maxSize {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "File too large", "details": "Attachments are limited to " + strconv.FormatInt(maxSize, 10) + " bytes"})
+		return
+	}
+	// The real type is sniffed from the content once it has all arrived;
+	// this only turns away uploads that are bound to fail.
+	if req.Mime_Type != "" && !utils.AttachmentTypeAllowed(req.Mime_Type) {
+		c.JSON(http.StatusUnsupportedMediaType, gin.H{"error": "File type not allowed"})
+		return
+	}
+
+	conversationID, _, ok := resolveConversation(c, userID, req.Receiver_Number, req.Conversation_ID)
+	if !ok {
+		return
+	}
+
+	attachment := newAttachment(userID, conversationID, req.File_Name, req.Size)
+	attachment.Status = models.UploadPending
+	attachment.MimeType = req.Mime_Type
+	attachment.Checksum = req.Checksum
+
+	db := mongo_db.MongoClient.Database("chat-app")
+	if err := mongo_db.CreateAttachment(db, attachment); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start upload", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"upload": attachment, "chunk_size": utils.MaxChunkSize})
+}
+
+// GetUploadHandler reports how far an upload has got.
+func GetUploadHandler(c *gin.Context) {
+	userID, ok := accessUserID(c)
+	if !ok {
+		return
+	}
+
+	attachment, ok := loadUpload(c, mongo_db.MongoClient.Database("chat-app"), userID)
+	if !ok {
+		return
+	}
+
+	c.Header("Upload-Offset", strconv.FormatInt(attachment.Received, 10))
+	c.JSON(http.StatusOK, attachment)
+}
+
+// UploadChunkHandler stores the request body as the next chunk of an upload.
+// The last chunk completes the upload and returns the finished attachment.
+func UploadChunkHandler(c *gin.Context) {
+	userID, ok := accessUserID(c)
+	if !ok {
+		return
+	}
+
+	db := mongo_db.MongoClient.Database("chat-app")
+
+	attachment, ok := loadUpload(c, db, userID)
+	if !ok {
+		return
+	}
+	if attachment.Status != models.UploadPending {
+		c.JSON(http.StatusConflict, gin.H{"error": "Upload already complete"})
+		return
+	}
+	// Every chunk arrived but joining them failed; try again.
+	if attachment.Received == attachment.Size {
+		finishUpload(c, db, attachment)
+		return
+	}
+
+	offset, err := strconv.ParseInt(c.GetHeader("Upload-Offset"), 10, 64)
+	if err != nil || offset != attachment.Received {
+		c.Header("Upload-Offset", strconv.FormatInt(attachment.Received, 10))
+		c.JSON(http.StatusConflict, gin.H{"error": "Wrong upload offset", "details": "Resume from offset " + strconv.FormatInt(attachment.Received, 10)})
+		return
+	}
+
+	chunk, err := spool(c.Request.Body, utils.MaxChunkSize)
+	if err != nil && !errors.Is(err, errTooLarge) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input", "details": err.Error()})
+		return
+	}
+	if err != nil || chunk.size == 0 || offset+chunk.size > attachment.Size {
+		if chunk != nil {
+			chunk.Close()
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid chunk", "details": "Chunks must hold 1 to " + strconv.Itoa(utils.MaxChunkSize) + " bytes and end within the declared size"})
+		return
+	}
+	defer chunk.Close()
+
+	key := "uploads/" + attachment.ID.Hex() + "/" + primitive.NewObjectID().Hex()
+	if err := storage.Blobs.Put(c.Request.Context(), key, io.NewSectionReader(chunk, 0, chunk.size), chunk.size, "application/octet-stream"); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to store chunk", "details": err.Error()})
+		return
+	}
+
+	attachment, err = mongo_db.RecordChunk(db, attachment.ID, offset, chunk.size, key)
+	if errors.Is(err, mongo_db.ErrUploadOffset) {
+		storage.Blobs.Delete(context.Background(), key)
+		c.JSON(http.StatusConflict, gin.H{"error": "Wrong upload offset", "details": "Another chunk was stored at this offset"})
+		return
+	}
+	if err != nil {
+		storage.Blobs.Delete(context.Background(), key)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to record chunk", "details": err.Error()})
+		return
+	}
+
+	if attachment.Received < attachment.Size {
+		c.Header("Upload-Offset", strconv.FormatInt(attachment.Received, 10))
+		c.JSON(http.StatusOK, attachment)
+		return
+	}
+
+	finishUpload(c, db, attachment)
+}
+
+// finishUpload joins the chunks of a fully received upload into the
+// attachment blob. An upload whose type, checksum or image structure is
+// rejected is thrown away; after any other failure the chunks are kept so the client can retry.
+func finishUpload(c *gin.Context, db *mongo.Database, attachment *models.Attachment) {
+	ctx := c.Request.Context()
+
+	chunks := &chunkReader{ctx: ctx, keys: attachment.ChunkKeys}
+	file, err := spool(chunks, attachment.Size)
+	chunks.Close()
+	if err == nil {
+		err = storeAttachment(ctx, attachment, file, attachment.MimeType, attachment.Checksum)
+		file.Close()
+	}
+
+	rejected := errors.Is(err, errTypeNotAllowed) || errors.Is(err, errChecksumMismatch) ||
+		errors.Is(err, media.ErrMalformedImage)
+	if err == nil {
+		attachment.Status = models.UploadComplete
+		err = mongo_db.CompleteAttachment(db, attachment)
+	} else if rejected {
+		err = errors.Join(err, mongo_db.DeleteAttachment(db, attachment.ID))
+	}
+
+	if err == nil || rejected {
+		for _, key := range attachment.ChunkKeys {
+			if err := storage.Blobs.Delete(context.Background(), key); err != nil {
+				log.Println("Failed to delete upload chunk:", err)
+			}
+		}
+		attachment.ChunkKeys = nil
+	}
+
+	if err != nil {
+		respondAttachmentError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, attachment)
+}
+
+// loadUpload loads the :id upload, which only its uploader can see.
+func loadUpload(c *gin.Context, db *mongo.Database, userID string) (*models.Attachment, bool) {
+	attachment, ok := loadAttachment(c, db, c.Param("id"))
+	if !ok {
+		return nil, false
+	}
+	if attachment.UploaderID != userID {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Attachment not found"})
+		return nil, false
+	}
+	return attachment, true
+}
+
+// chunkReader reads the chunks of an upload in order, opening each only when
+// the previous one is used up.
+type chunkReader struct {
+	ctx     context.Context
+	keys    []string
+	current io.ReadCloser
+}
+
+func (r *chunkReader) Read(p []byte) (int, error) {
+	for {
+		if r.current == nil {
+			if len(r.keys) == 0 {
+				return 0, io.EOF
+			}
+			blob, err := storage.Blobs.Get(r.ctx, r.keys[0])
+			if err != nil {
+				return 0, err
+			}
+			r.current = blob
+			r.keys = r.keys[1:]
+		}
+
+		n, err := r.current.Read(p)
+		if errors.Is(err, io.EOF) {
+			r.current.Close()
+			r.current = nil
+			if n > 0 {
+				return n, nil
+			}
+			continue
+		}
+		return n, err
+	}
+}
+
+func (r *chunkReader) Close() error {
+	if r.current == nil {
+		return nil
+	}
+	err := r.current.Close()
+	r.current = nil
+	return err
+}
@@ -0,0 +1,83 @@
+package user_handler
+
+import (
+	"net/http"
+	"strconv"
+
+	pg_admin "github.com/Ahmeds-Library/Chat-App/internal/database/Pg_Admin"
+	"github.com/Ahmeds-Library/Chat-App/internal/models"
+	"github.com/gin-gonic/gin"
+)
+
+// BlockUserHandler blocks the user with the given number. Until the block is
+// lifted neither user can message the other, and neither sees the other's
+// presence or typing.
+func BlockUserHandler(c *gin.Context) {
+	userID, ok := accessUserID(c)
+	if !ok {
+		return
+	}
+
+	var req models.Block_User
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body", "details": err.Error()})
+		return
+	}
+
+	blocked, err := pg_admin.GetUserByPhone(req.Number)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found", "details": err.Error()})
+		return
+	}
+	if blocked.ID == userID {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request", "details": "You cannot block yourself"})
+		return
+	}
+
+	if err := pg_admin.BlockUser(userID, blocked.ID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "User blocked", "user_id": blocked.ID})
+}
+
+func UnblockUserHandler(c *gin.Context) {
+	userID, ok := accessUserID(c)
+	if !ok {
+		return
+	}
+
+	blockedID := c.Param("user_id")
+	if _, err := strconv.Atoi(blockedID); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request", "details": "invalid user ID"})
+		return
+	}
+
+	removed, err := pg_admin.UnblockUser(userID, blockedID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error", "details": err.Error()})
+		return
+	}
+	if !removed {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User is not blocked"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "User unblocked"})
+}
+
+func ListBlockedUsersHandler(c *gin.Context) {
+	userID, ok := accessUserID(c)
+	if !ok {
+		return
+	}
+
+	blocked, err := pg_admin.GetBlockedUsers(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, blocked)
+}
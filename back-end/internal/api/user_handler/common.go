@@ -0,0 +1,30 @@
+package user_handler
+
+import (
+	"net/http"
+
+	"github.com/Ahmeds-Library/Chat-App/internal/utils"
+	"github.com/gin-gonic/gin"
+)
+
+// accessUserID returns the caller's user ID from a valid access token,
+// writing the error response itself when there is none.
+func accessUserID(c *gin.Context) (string, bool) {
+	claims, ok := utils.ValidateToken(c)
+	if !ok {
+		return "", false
+	}
+
+	token_type, ok := claims["token_type"].(string)
+	if !ok || token_type != "Access" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token type", "details": "Use a valid access token"})
+		return "", false
+	}
+
+	userID, ok := claims["id"].(string)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token", "details": "User ID not found in token"})
+		return "", false
+	}
+	return userID, true
+}
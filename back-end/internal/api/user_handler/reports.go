@@ -0,0 +1,108 @@
+package user_handler
+
+import (
+	"errors"
+	"net/http"
+
+	mongo_db "github.com/Ahmeds-Library/Chat-App/internal/database/Mongo_DB"
+	pg_admin "github.com/Ahmeds-Library/Chat-App/internal/database/Pg_Admin"
+	"github.com/Ahmeds-Library/Chat-App/internal/models"
+	"github.com/Ahmeds-Library/Chat-App/internal/utils"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ReportUserHandler puts a report in the moderation queue with a copy of the
+// offending messages, and blocks the reported user when asked to.
+func ReportUserHandler(c *gin.Context) {
+	userID, ok := accessUserID(c)
+	if !ok {
+		return
+	}
+
+	var req models.Report_User
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body", "details": err.Error()})
+		return
+	}
+
+	reported, err := pg_admin.GetUserByPhone(req.Number)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found", "details": err.Error()})
+		return
+	}
+	if reported.ID == userID {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request", "details": "You cannot report yourself"})
+		return
+	}
+
+	conversationID := utils.DirectConversationID(userID, reported.ID)
+	if req.Conversation_ID != "" && req.Conversation_ID != conversationID {
+		if utils.IsDirectConversation(req.Conversation_ID) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Not a participant in this conversation"})
+			return
+		}
+		if _, err := pg_admin.GetMemberRole(req.Conversation_ID, userID); err != nil {
+			respondMembershipError(c, err)
+			return
+		}
+		conversationID = req.Conversation_ID
+	}
+
+	messageIDs := make([]primitive.ObjectID, 0, len(req.Message_IDs))
+	for _, id := range req.Message_IDs {
+		objID, err := primitive.ObjectIDFromHex(id)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request", "details": "invalid message ID: " + id})
+			return
+		}
+		messageIDs = append(messageIDs, objID)
+	}
+
+	messages, err := mongo_db.GetReportedMessages(mongo_db.MongoClient.Database("chat-app"), userID, reported.ID, conversationID, messageIDs)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load messages", "details": err.Error()})
+		return
+	}
+	if len(messageIDs) > 0 && len(messages) == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Messages not found", "details": "None of the messages were sent by this user in this conversation"})
+		return
+	}
+
+	report := models.Report{
+		ReporterID:     userID,
+		ReportedID:     reported.ID,
+		ConversationID: conversationID,
+		Reason:         req.Reason,
+		Details:        req.Details,
+		Messages:       make([]models.Reported_Message, len(messages)),
+	}
+	for i := range messages {
+		report.Messages[i] = messages[i].Reported()
+	}
+
+	if err := pg_admin.CreateReport(&report); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error", "details": err.Error()})
+		return
+	}
+
+	if req.Block {
+		if err := pg_admin.BlockUser(userID, reported.ID); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Report saved but blocking failed", "details": err.Error()})
+			return
+		}
+	}
+
+	c.JSON(http.StatusCreated, report)
+}
+
+func respondMembershipError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, pg_admin.ErrConversationNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Group not found"})
+	case errors.Is(err, pg_admin.ErrNotMember):
+		c.JSON(http.StatusForbidden, gin.H{"error": "Not a member of this group"})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error", "details": err.Error()})
+	}
+}
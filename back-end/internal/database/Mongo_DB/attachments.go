@@ -0,0 +1,200 @@
+package mongo_db
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/Ahmeds-Library/Chat-App/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var (
+	ErrAttachmentNotFound = errors.New("attachment not found")
+	// ErrUploadOffset is returned when a chunk does not start where the
+	// upload left off.
+	ErrUploadOffset = errors.New("chunk does not start at the upload offset")
+)
+
+func CreateAttachment(db *mongo.Database, attachment *models.Attachment) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if attachment.ID.IsZero() {
+		attachment.ID = primitive.NewObjectID()
+	}
+	attachment.CreatedAt = time.Now()
+
+	_, err := db.Collection("attachments").InsertOne(ctx, attachment)
+	return err
+}
+
+func GetAttachment(db *mongo.Database, attachmentID string) (*models.Attachment, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	objID, err := primitive.ObjectIDFromHex(attachmentID)
+	if err != nil {
+		return nil, ErrAttachmentNotFound
+	}
+
+	var attachment models.Attachment
+	err = db.Collection("attachments").FindOne(ctx, bson.M{"_id": objID}).Decode(&attachment)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrAttachmentNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &attachment, nil
+}
+
+// RecordChunk adds a stored chunk to a pending upload, as long as no other
+// chunk was recorded at the same offset first. It returns the updated upload.
+func RecordChunk(db *mongo.Database, attachmentID primitive.ObjectID, offset, length int64, key string) (*models.Attachment, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var attachment models.Attachment
+	err := db.Collection("attachments").FindOneAndUpdate(ctx,
+		bson.M{"_id": attachmentID, "status": models.UploadPending, "received": offset},
+		bson.M{
+			"$inc":  bson.M{"received": length},
+			"$push": bson.M{"chunk_keys": key},
+		},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&attachment)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrUploadOffset
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &attachment, nil
+}
+
+// CompleteAttachment records what was learned about a finished upload, its
+// type, checksum, media metadata and thumbnail, and drops its chunk list.
+func CompleteAttachment(db *mongo.Database, attachment *models.Attachment) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	_, err := db.Collection("attachments").UpdateOne(ctx,
+		bson.M{"_id": attachment.ID},
+		bson.M{
+			"$set": bson.M{
+				"status":    models.UploadComplete,
+				"mime_type": attachment.MimeType,
+				"kind":      attachment.Kind,
+				"size":      attachment.Size,
+				"received":  attachment.Received,
+				"checksum":  attachment.Checksum,
+				"width":     attachment.Width,
+				"height":    attachment.Height,
+				"duration":  attachment.Duration,
+				"blurhash":  attachment.Blurhash,
+				"thumbnail": attachment.Thumbnail,
+			},
+			"$unset": bson.M{"chunk_keys": ""},
+		},
+	)
+	return err
+}
+
+func DeleteAttachment(db *mongo.Database, attachmentID primitive.ObjectID) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	_, err := db.Collection("attachments").DeleteOne(ctx, bson.M{"_id": attachmentID})
+	return err
+}
+
+// ClaimAttachment attaches a complete, unused upload to messageID. Only the
+// uploader can attach it, and only in the conversation it was uploaded to.
+func ClaimAttachment(db *mongo.Database, attachmentID, uploaderID, conversationID string, messageID primitive.ObjectID) (*models.Attachment, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	objID, err := primitive.ObjectIDFromHex(attachmentID)
+	if err != nil {
+		return nil, ErrAttachmentNotFound
+	}
+
+	var attachment models.Attachment
+	err = db.Collection("attachments").FindOneAndUpdate(ctx,
+		bson.M{
+			"_id":             objID,
+			"uploader_id":     uploaderID,
+			"conversation_id": conversationID,
+			"status":          models.UploadComplete,
+			"message_id":      bson.M{"$exists": false},
+		},
+		bson.M{"$set": bson.M{"message_id": messageID}},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&attachment)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrAttachmentNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &attachment, nil
+}
+
+// ReleaseAttachment undoes ClaimAttachment when the message could not be
+// saved.
+func ReleaseAttachment(db *mongo.Database, attachmentID primitive.ObjectID) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	_, err := db.Collection("attachments").UpdateOne(ctx,
+		bson.M{"_id": attachmentID},
+		bson.M{"$unset": bson.M{"message_id": ""}},
+	)
+	return err
+}
+
+// UnclaimedAttachments lists up to limit attachments created before cutoff
+// that were never sent with a message: complete ones nobody claimed and
+// uploads that never finished.
+func UnclaimedAttachments(db *mongo.Database, before time.Time, limit int64) ([]models.Attachment, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	cursor, err := db.Collection("attachments").Find(ctx,
+		bson.M{"message_id": bson.M{"$exists": false}, "created_at": bson.M{"$lt": before}},
+		options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}}).SetLimit(limit),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	var attachments []models.Attachment
+	if err := cursor.All(ctx, &attachments); err != nil {
+		return nil, err
+	}
+	return attachments, nil
+}
+
+// DeleteUnclaimedAttachment deletes an attachment unless a message claimed
+// it first, and returns it as it was when deleted, so the blobs it names can
+// be removed too.
+func DeleteUnclaimedAttachment(db *mongo.Database, attachmentID primitive.ObjectID) (*models.Attachment, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var attachment models.Attachment
+	err := db.Collection("attachments").FindOneAndDelete(ctx,
+		bson.M{"_id": attachmentID, "message_id": bson.M{"$exists": false}},
+	).Decode(&attachment)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrAttachmentNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &attachment, nil
+}
@@ -0,0 +1,77 @@
+package mongo_db
+
+import (
+	"context"
+
+	"github.com/Ahmeds-Library/Chat-App/internal/utils"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// BackfillConversationIDs sets conversation_id on direct messages stored
+// before messages carried one, writing batchSize updates at a time. It is
+// safe to run more than once and returns the number of messages updated.
+func BackfillConversationIDs(db *mongo.Database, batchSize int) (int64, error) {
+	collection := db.Collection("messages")
+	ctx := context.Background()
+
+	filter := bson.M{
+		"conversation_id": bson.M{"$exists": false},
+		"receiver_id":     bson.M{"$exists": true},
+	}
+	findOptions := options.Find().
+		SetProjection(bson.M{"sender_id": 1, "receiver_id": 1}).
+		SetBatchSize(int32(batchSize))
+
+	cursor, err := collection.Find(ctx, filter, findOptions)
+	if err != nil {
+		return 0, err
+	}
+	defer cursor.Close(ctx)
+
+	var updated int64
+	var batch []mongo.WriteModel
+
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		result, err := collection.BulkWrite(ctx, batch, options.BulkWrite().SetOrdered(false))
+		if err != nil {
+			return err
+		}
+		updated += result.ModifiedCount
+		batch = batch[:0]
+		return nil
+	}
+
+	for cursor.Next(ctx) {
+		var message struct {
+			ID         primitive.ObjectID `bson:"_id"`
+			SenderID   string             `bson:"sender_id"`
+			ReceiverID string             `bson:"receiver_id"`
+		}
+		if err := cursor.Decode(&message); err != nil {
+			return updated, err
+		}
+
+		batch = append(batch, mongo.NewUpdateOneModel().
+			SetFilter(bson.M{"_id": message.ID, "conversation_id": bson.M{"$exists": false}}).
+			SetUpdate(bson.M{"$set": bson.M{
+				"conversation_id": utils.DirectConversationID(message.SenderID, message.ReceiverID),
+			}}))
+
+		if len(batch) >= batchSize {
+			if err := flush(); err != nil {
+				return updated, err
+			}
+		}
+	}
+	if err := cursor.Err(); err != nil {
+		return updated, err
+	}
+
+	return updated, flush()
+}
@@ -6,55 +6,129 @@ import (
 
 	"github.com/Ahmeds-Library/Chat-App/internal/models"
 	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
-	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
-func GetChatPartners(db *mongo.Database, userID string) ([]models.ChatPartner, error) {
+// GetChatPartners returns one entry per conversation the user takes part in,
+// direct or one of groupIDs, with the latest message and the number of
+// unread messages addressed to the user, newest conversation first. Group
+// messages from others count as unread past the user's read cursor. Messages
+// the user deleted for themselves are skipped. Only conversations whose latest message is older than
+// before are returned when before is set. The returned cursor is empty on the
+// last page.
+func GetChatPartners(db *mongo.Database, userID string, groupIDs []string, before primitive.ObjectID, limit int64) ([]models.ChatPartner, string, error) {
 	collection := db.Collection("messages")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
 
-	filter := bson.M{
-		"$or": bson.A{
-			bson.M{"sender_id": userID},
-			bson.M{"receiver_id": userID},
-		},
+	readUpTo, err := groupReadCursors(ctx, db, userID, groupIDs)
+	if err != nil {
+		return nil, "", err
+	}
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{
+			"$or": bson.A{
+				bson.M{"receiver_id": userID},
+				bson.M{"sender_id": userID, "receiver_id": bson.M{"$exists": true}},
+				bson.M{"conversation_id": bson.M{"$in": groupIDs}},
+			},
+			"conversation_id": bson.M{"$exists": true},
+			"deleted_for":     bson.M{"$ne": userID},
+		}}},
+		{{Key: "$sort", Value: bson.M{"_id": -1}}},
+		{{Key: "$group", Value: bson.M{
+			"_id":                  "$conversation_id",
+			"last_message_id":      bson.M{"$first": "$_id"},
+			"last_message":         bson.M{"$first": "$message"},
+			"last_message_at":      bson.M{"$first": "$created_at"},
+			"last_message_deleted": bson.M{"$first": bson.M{"$ifNull": bson.A{"$deleted", false}}},
+			"unread_count": bson.M{"$sum": bson.M{"$cond": bson.A{
+				bson.M{"$and": bson.A{
+					bson.M{"$ne": bson.A{"$deleted", true}},
+					bson.M{"$or": bson.A{
+						bson.M{"$and": bson.A{
+							bson.M{"$eq": bson.A{"$receiver_id", userID}},
+							bson.M{"$eq": bson.A{bson.M{"$ifNull": bson.A{"$read_at", nil}}, nil}},
+						}},
+						bson.M{"$and": bson.A{
+							bson.M{"$in": bson.A{"$conversation_id", groupIDs}},
+							bson.M{"$ne": bson.A{"$sender_id", userID}},
+							bson.M{"$gt": bson.A{"$_id", groupReadUpTo(readUpTo)}},
+						}},
+					}},
+				}},
+				1,
+				0,
+			}}},
+		}}},
+	}
+	if !before.IsZero() {
+		pipeline = append(pipeline, bson.D{{Key: "$match", Value: bson.M{"last_message_id": bson.M{"$lt": before}}}})
 	}
-		
-	findOptions := options.Find().SetSort(bson.M{"created_at": -1})
+	pipeline = append(pipeline,
+		bson.D{{Key: "$sort", Value: bson.M{"last_message_id": -1}}},
+		bson.D{{Key: "$limit", Value: limit + 1}},
+	)
 
-	cursor, err := collection.Find(context.Background(), filter, findOptions)
+	cursor, err := collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, "", err
+	}
+
+	chatList := []models.ChatPartner{}
+	if err := cursor.All(ctx, &chatList); err != nil {
+		return nil, "", err
+	}
+
+	var next string
+	if int64(len(chatList)) > limit {
+		chatList = chatList[:limit]
+		next = chatList[len(chatList)-1].LastMessageID.Hex()
+	}
+
+	return chatList, next, nil
+}
+
+// groupReadCursors returns how far the user has read each of groupIDs.
+// Groups they never marked read are left out.
+func groupReadCursors(ctx context.Context, db *mongo.Database, userID string, groupIDs []string) (map[string]primitive.ObjectID, error) {
+	cursorIDs := make([]string, len(groupIDs))
+	for i, groupID := range groupIDs {
+		cursorIDs[i] = ReadCursorID(groupID, userID)
+	}
+
+	cursor, err := db.Collection("read_cursors").Find(ctx, bson.M{"_id": bson.M{"$in": cursorIDs}})
 	if err != nil {
 		return nil, err
 	}
-	defer cursor.Close(context.Background())
-
-	var chatList []models.ChatPartner
-
-	for cursor.Next(context.Background()) {
-		var message struct {
-			SenderID   string    `bson:"sender_id"`
-			ReceiverID string    `bson:"receiver_id"`
-			Message    string    `bson:"message"`
-			CreatedAt  time.Time `bson:"created_at"`
-		}
-
-		if err := cursor.Decode(&message); err != nil {
-			return nil, err
-		}
-
-		var partnerID string
-		if message.SenderID == userID {
-			partnerID = message.ReceiverID
-		} else {
-			partnerID = message.SenderID
-		}
-
-		chatList = append(chatList, models.ChatPartner{
-			PartnerID:     partnerID,
-			LastMessage:   message.Message,
-			LastMessageAt: message.CreatedAt,
-		})
+
+	var cursors []models.Read_Cursor
+	if err := cursor.All(ctx, &cursors); err != nil {
+		return nil, err
+	}
+
+	readUpTo := make(map[string]primitive.ObjectID, len(cursors))
+	for _, c := range cursors {
+		readUpTo[c.ConversationID] = c.ReadUpTo
+	}
+	return readUpTo, nil
+}
+
+// groupReadUpTo builds an expression giving the read cursor for the
+// message's group, or the zero ID for groups never marked read.
+func groupReadUpTo(readUpTo map[string]primitive.ObjectID) any {
+	if len(readUpTo) == 0 {
+		return primitive.NilObjectID
 	}
 
-	return chatList, nil
+	branches := make(bson.A, 0, len(readUpTo))
+	for groupID, upTo := range readUpTo {
+		branches = append(branches, bson.M{
+			"case": bson.M{"$eq": bson.A{"$conversation_id", groupID}},
+			"then": upTo,
+		})
+	}
+	return bson.M{"$switch": bson.M{"branches": branches, "default": primitive.NilObjectID}}
 }
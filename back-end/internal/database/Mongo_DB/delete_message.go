@@ -0,0 +1,71 @@
+package mongo_db
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/Ahmeds-Library/Chat-App/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+var ErrMessageNotFound = errors.New("message not found")
+
+// GetMessage loads a single message by its hex ID.
+func GetMessage(db *mongo.Database, messageID string) (*models.Save_Message, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	objID, err := primitive.ObjectIDFromHex(messageID)
+	if err != nil {
+		return nil, ErrMessageNotFound
+	}
+
+	var msg models.Save_Message
+	err = db.Collection("messages").FindOne(ctx, bson.M{"_id": objID}).Decode(&msg)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrMessageNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &msg, nil
+}
+
+// DeleteMessageForMe hides a message from userID only. Nothing is relayed,
+// since no one else sees a difference.
+func DeleteMessageForMe(db *mongo.Database, messageID primitive.ObjectID, userID string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	_, err := db.Collection("messages").UpdateOne(ctx,
+		bson.M{"_id": messageID},
+		bson.M{"$addToSet": bson.M{"deleted_for": userID}},
+	)
+	return err
+}
+
+// DeleteMessageForEveryone replaces a message's text with a tombstone, drops
+// its edit history, reactions and attachment, and stamps the change so the
+// websocket service tells the other participants. Deleting a tombstone again
+// is a no-op.
+func DeleteMessageForEveryone(db *mongo.Database, messageID primitive.ObjectID, deletedAt time.Time) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	_, err := db.Collection("messages").UpdateOne(ctx,
+		bson.M{"_id": messageID, "deleted": bson.M{"$ne": true}},
+		bson.M{
+			"$set": bson.M{
+				"message":    "",
+				"deleted":    true,
+				"deleted_at": deletedAt,
+				"change":     models.NewMessageChange(models.ChangeDeleted),
+			},
+			"$unset": bson.M{"revisions": "", "reactions": "", "attachment": ""},
+		},
+	)
+	return err
+}
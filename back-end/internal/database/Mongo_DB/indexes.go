@@ -0,0 +1,47 @@
+package mongo_db
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// EnsureIndexes creates the indexes the queries rely on. Creating an index
+// that already exists is a no-op, so this runs on every start.
+func EnsureIndexes() error {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	messages := MongoClient.Database("chat-app").Collection("messages")
+	_, err := messages.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "sender_id", Value: 1}, {Key: "receiver_id", Value: 1}, {Key: "_id", Value: 1}}},
+		{Keys: bson.D{{Key: "receiver_id", Value: 1}, {Key: "_id", Value: 1}}},
+		{Keys: bson.D{{Key: "conversation_id", Value: 1}, {Key: "_id", Value: 1}}},
+		// Websocket replays and delivery receipts go by creation time.
+		{Keys: bson.D{{Key: "receiver_id", Value: 1}, {Key: "created_at", Value: 1}, {Key: "_id", Value: 1}}},
+		{Keys: bson.D{{Key: "conversation_id", Value: 1}, {Key: "created_at", Value: 1}, {Key: "_id", Value: 1}}},
+		{
+			Keys:    bson.D{{Key: "thread_id", Value: 1}, {Key: "_id", Value: 1}},
+			Options: options.Index().SetPartialFilterExpression(bson.M{"thread_id": bson.M{"$exists": true}}),
+		},
+		// Chats mix languages and short words, so words are matched as they
+		// are, without stemming or stop words.
+		{
+			Keys:    bson.D{{Key: "message", Value: "text"}},
+			Options: options.Index().SetDefaultLanguage("none"),
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	// Lets the sweep find attachments that were never sent.
+	attachments := MongoClient.Database("chat-app").Collection("attachments")
+	_, err = attachments.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "message_id", Value: 1}, {Key: "created_at", Value: 1}},
+	})
+	return err
+}
@@ -0,0 +1,100 @@
+package mongo_db
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/Ahmeds-Library/Chat-App/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// MarkConversationRead marks every unread message addressed to readerID in
+// the direct conversation, up to and including messageID, as read. Messages that
+// were never marked delivered are marked delivered at the same time. It
+// returns the number of messages newly marked read. All of them share one
+// change stamp, so the websocket poller orders them by _id as well.
+func MarkConversationRead(db *mongo.Database, readerID, conversationID, messageID string) (int64, error) {
+	collection := db.Collection("messages")
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	upTo, err := primitive.ObjectIDFromHex(messageID)
+	if err != nil {
+		return 0, errors.New("invalid message ID format")
+	}
+
+	now := time.Now()
+
+	_, err = collection.UpdateMany(ctx, bson.M{
+		"conversation_id": conversationID,
+		"receiver_id":     readerID,
+		"_id":             bson.M{"$lte": upTo},
+		"delivered_at":    nil,
+	}, bson.M{"$set": bson.M{"delivered_at": now}})
+	if err != nil {
+		return 0, err
+	}
+
+	result, err := collection.UpdateMany(ctx, bson.M{
+		"conversation_id": conversationID,
+		"receiver_id":     readerID,
+		"_id":             bson.M{"$lte": upTo},
+		"read_at":         nil,
+	}, bson.M{
+		"$set": bson.M{
+			"read_at": now,
+			"change":  models.NewMessageChange(models.ChangeRead),
+		},
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return result.ModifiedCount, nil
+}
+
+// ReadCursorID is the ID of the member's read cursor in a group.
+func ReadCursorID(conversationID, userID string) string {
+	return conversationID + ":" + userID
+}
+
+// MarkGroupRead moves the reader's read cursor in the group forward to
+// messageID, along with their delivered cursor, and returns the number of
+// other members' messages it passed over. Cursors never move back. Senders
+// are not told over the websocket; read receipts for groups only go out when
+// the reader marks the group read there.
+func MarkGroupRead(db *mongo.Database, readerID, conversationID, messageID string) (int64, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	upTo, err := primitive.ObjectIDFromHex(messageID)
+	if err != nil {
+		return 0, errors.New("invalid message ID format")
+	}
+
+	var previous models.Read_Cursor
+	err = db.Collection("read_cursors").FindOneAndUpdate(ctx,
+		bson.M{"_id": ReadCursorID(conversationID, readerID)},
+		bson.M{
+			"$max": bson.M{"delivered_up_to": upTo, "read_up_to": upTo},
+			"$set": bson.M{"conversation_id": conversationID, "user_id": readerID, "updated_at": time.Now()},
+		},
+		options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.Before),
+	).Decode(&previous)
+	if err != nil && !errors.Is(err, mongo.ErrNoDocuments) {
+		return 0, err
+	}
+	if upTo.Hex() <= previous.ReadUpTo.Hex() {
+		return 0, nil
+	}
+
+	return db.Collection("messages").CountDocuments(ctx, bson.M{
+		"conversation_id": conversationID,
+		"sender_id":       bson.M{"$ne": readerID},
+		"_id":             bson.M{"$gt": previous.ReadUpTo, "$lte": upTo},
+	})
+}
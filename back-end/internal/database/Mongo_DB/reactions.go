@@ -0,0 +1,49 @@
+package mongo_db
+
+import (
+	"context"
+	"time"
+
+	"github.com/Ahmeds-Library/Chat-App/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// AddReaction adds userID's emoji to a message unless they already reacted
+// with it. Only a change is stamped, so repeating a reaction relays nothing.
+func AddReaction(db *mongo.Database, messageID primitive.ObjectID, userID, emoji string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	_, err := db.Collection("messages").UpdateOne(ctx,
+		bson.M{
+			"_id":       messageID,
+			"deleted":   bson.M{"$ne": true},
+			"reactions": bson.M{"$not": bson.M{"$elemMatch": bson.M{"user_id": userID, "emoji": emoji}}},
+		},
+		bson.M{
+			"$push": bson.M{"reactions": models.Message_Reaction{UserID: userID, Emoji: emoji, At: time.Now()}},
+			"$set":  bson.M{"change": models.NewMessageChange(models.ChangeReacted)},
+		},
+	)
+	return err
+}
+
+// RemoveReaction removes userID's emoji from a message, if it is there.
+func RemoveReaction(db *mongo.Database, messageID primitive.ObjectID, userID, emoji string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	_, err := db.Collection("messages").UpdateOne(ctx,
+		bson.M{
+			"_id":       messageID,
+			"reactions": bson.M{"$elemMatch": bson.M{"user_id": userID, "emoji": emoji}},
+		},
+		bson.M{
+			"$pull": bson.M{"reactions": bson.M{"user_id": userID, "emoji": emoji}},
+			"$set":  bson.M{"change": models.NewMessageChange(models.ChangeReacted)},
+		},
+	)
+	return err
+}
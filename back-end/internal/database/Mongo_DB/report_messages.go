@@ -0,0 +1,50 @@
+package mongo_db
+
+import (
+	"context"
+	"time"
+
+	"github.com/Ahmeds-Library/Chat-App/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// GetReportedMessages returns the messages senderID sent to conversationID
+// that reporterID can still see, oldest first: those in messageIDs, or the
+// latest ReportSnapshotSize when messageIDs is empty. Messages deleted for
+// everyone have no content left to report and are skipped.
+func GetReportedMessages(db *mongo.Database, reporterID, senderID, conversationID string, messageIDs []primitive.ObjectID) ([]models.Save_Message, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	filter := bson.M{
+		"conversation_id": conversationID,
+		"sender_id":       senderID,
+		"deleted":         bson.M{"$ne": true},
+		"deleted_for":     bson.M{"$ne": reporterID},
+	}
+	findOptions := options.Find().
+		SetProjection(bson.M{"revisions": 0, "reactions": 0}).
+		SetSort(bson.M{"_id": -1})
+	if len(messageIDs) > 0 {
+		filter["_id"] = bson.M{"$in": messageIDs}
+	} else {
+		findOptions.SetLimit(models.ReportSnapshotSize)
+	}
+
+	cursor, err := db.Collection("messages").Find(ctx, filter, findOptions)
+	if err != nil {
+		return nil, err
+	}
+
+	messages := []models.Save_Message{}
+	if err := cursor.All(ctx, &messages); err != nil {
+		return nil, err
+	}
+	for i, j := 0, len(messages)-1; i < j; i, j = i+1, j-1 {
+		messages[i], messages[j] = messages[j], messages[i]
+	}
+	return messages, nil
+}
@@ -3,28 +3,30 @@ package mongo_db
 import (
 	"context"
 	"net/http"
-	"time"
 
 	"github.com/Ahmeds-Library/Chat-App/internal/models"
 	"github.com/gin-gonic/gin"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
-func SaveMessage(c *gin.Context, senderID string, receiver models.User, req models.Request_Message) {
-	message := models.Save_Message{
-		ID:         primitive.NewObjectID(),
-		SenderID:   senderID,
-		ReceiverID: receiver.ID,
-		Message:    req.Message,
-		CreatedAt:  time.Now(),
+// SaveMessage stores a new message addressed to message.ReceiverID or
+// message.ConversationID and writes the response. The message keeps its ID
+// if the caller already picked one.
+func SaveMessage(c *gin.Context, message models.Save_Message) error {
+	change := models.NewMessageChange(models.ChangeCreated)
+	if message.ID.IsZero() {
+		message.ID = primitive.NewObjectID()
 	}
+	message.CreatedAt = change.At
+	message.Change = &change
 
 	collection := MongoClient.Database("chat-app").Collection("messages")
 	_, err := collection.InsertOne(context.Background(), message)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save message", "details": err.Error()})
-		return
+		return err
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": message.Message, "status": "Message sent successfully"})
+	c.JSON(http.StatusOK, gin.H{"id": message.ID.Hex(), "message": message.Message, "attachment": message.Attachment, "status": "Message sent successfully"})
+	return nil
 }
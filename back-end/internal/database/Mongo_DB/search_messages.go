@@ -0,0 +1,68 @@
+package mongo_db
+
+import (
+	"context"
+	"time"
+
+	"github.com/Ahmeds-Library/Chat-App/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// SearchMessages runs a text search over the messages the user can see,
+// newest first. Messages deleted for everyone, or by the user for
+// themselves, are never returned. It returns one more message than the limit
+// when there is another page.
+func SearchMessages(db *mongo.Database, search models.Message_Search) ([]models.Save_Message, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	filter := bson.M{
+		"$text":       bson.M{"$search": search.Query},
+		"deleted":     bson.M{"$ne": true},
+		"deleted_for": bson.M{"$ne": search.UserID},
+	}
+	if search.ConversationID != "" {
+		filter["conversation_id"] = search.ConversationID
+	} else {
+		filter["$or"] = bson.A{
+			bson.M{"receiver_id": search.UserID},
+			bson.M{"sender_id": search.UserID, "receiver_id": bson.M{"$exists": true}},
+			bson.M{"conversation_id": bson.M{"$in": search.GroupIDs}},
+		}
+	}
+	if search.SenderID != "" {
+		filter["sender_id"] = search.SenderID
+	}
+
+	createdAt := bson.M{}
+	if !search.From.IsZero() {
+		createdAt["$gte"] = search.From
+	}
+	if !search.To.IsZero() {
+		createdAt["$lte"] = search.To
+	}
+	if len(createdAt) > 0 {
+		filter["created_at"] = createdAt
+	}
+	if !search.Before.IsZero() {
+		filter["_id"] = bson.M{"$lt": search.Before}
+	}
+
+	findOptions := options.Find().
+		SetProjection(bson.M{"revisions": 0}).
+		SetSort(bson.M{"_id": -1}).
+		SetLimit(search.Limit + 1)
+
+	cursor, err := db.Collection("messages").Find(ctx, filter, findOptions)
+	if err != nil {
+		return nil, err
+	}
+
+	messages := []models.Save_Message{}
+	if err := cursor.All(ctx, &messages); err != nil {
+		return nil, err
+	}
+	return messages, nil
+}
@@ -1,48 +1,83 @@
 package mongo_db
 
 import (
-    "context"
-    "errors"
-    "time"
+	"context"
+	"errors"
+	"time"
 
-    "go.mongodb.org/mongo-driver/bson"
-    "go.mongodb.org/mongo-driver/bson/primitive"
-    "go.mongodb.org/mongo-driver/mongo"
+	"github.com/Ahmeds-Library/Chat-App/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
 )
 
-func Update_Message(db *mongo.Database, messageID, senderID, newMessage string, updatedTime time.Time) error {
-    collection := db.Collection("messages")
-    ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+var (
+	ErrEditWindowExpired = errors.New("edit window has passed")
+	ErrConcurrentEdit    = errors.New("message was edited concurrently, retry")
+)
+
+// Update_Message replaces the text of a message senderID sent, keeping the
+// text it replaces in the message's revisions. With a non-zero window the
+// message can only be edited that long after it was sent.
+func Update_Message(db *mongo.Database, messageID, senderID, newMessage string, updatedTime time.Time, window time.Duration) error {
+	collection := db.Collection("messages")
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 
 	defer cancel()
 
-    objID, err := primitive.ObjectIDFromHex(messageID)
-    if err != nil {
-        return errors.New("invalid message ID format")
-    }
-
-    filter := bson.M{
-        "_id":       objID,
-        "sender_id": senderID,
-    }
-
-    var oldMsg bson.M
-    err = collection.FindOne(ctx, filter).Decode(&oldMsg)
-    if err != nil {
-        return errors.New("no matching message found or unauthorized")
-    }
-
-    updateDoc := bson.M{
-        "$set": bson.M{
-            "message":      newMessage,
-            "edited":       true,
-            "updated_time": updatedTime,
-        },
-    }
-    _, err = collection.UpdateOne(ctx, filter, updateDoc)
-    if err != nil {
-        return err
-    }
-
-    return nil
-}
\ No newline at end of file
+	objID, err := primitive.ObjectIDFromHex(messageID)
+	if err != nil {
+		return errors.New("invalid message ID format")
+	}
+
+	filter := bson.M{
+		"_id":       objID,
+		"sender_id": senderID,
+		"deleted":   bson.M{"$ne": true},
+	}
+
+	var oldMsg models.Save_Message
+	err = collection.FindOne(ctx, filter).Decode(&oldMsg)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return ErrMessageNotFound
+	}
+	if err != nil {
+		return err
+	}
+
+	if window > 0 && updatedTime.Sub(oldMsg.CreatedAt) > window {
+		return ErrEditWindowExpired
+	}
+
+	previous := models.Message_Revision{Message: oldMsg.Message, At: oldMsg.CreatedAt}
+	if oldMsg.UpdatedTime != nil {
+		previous.At = *oldMsg.UpdatedTime
+	}
+
+	// Every edit pushes exactly one revision, so their count versions the
+	// message. Matching on it makes sure the revision we push is the one this
+	// edit replaces, even if the text was edited away and back meanwhile.
+	if n := len(oldMsg.Revisions); n == 0 {
+		filter["revisions"] = bson.M{"$exists": false}
+	} else {
+		filter["revisions"] = bson.M{"$size": n}
+	}
+	updateDoc := bson.M{
+		"$set": bson.M{
+			"message":      newMessage,
+			"edited":       true,
+			"updated_time": updatedTime,
+			"change":       models.NewMessageChange(models.ChangeEdited),
+		},
+		"$push": bson.M{"revisions": previous},
+	}
+	result, err := collection.UpdateOne(ctx, filter, updateDoc)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return ErrConcurrentEdit
+	}
+
+	return nil
+}
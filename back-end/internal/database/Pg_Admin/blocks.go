@@ -0,0 +1,62 @@
+package pg_admin
+
+import "github.com/Ahmeds-Library/Chat-App/internal/models"
+
+// BlockUser is a no-op when the block already exists.
+func BlockUser(blockerID, blockedID string) error {
+	_, err := Db.Exec(
+		"INSERT INTO user_blocks (blocker_id, blocked_id) VALUES ($1, $2) ON CONFLICT DO NOTHING",
+		blockerID, blockedID,
+	)
+	return err
+}
+
+// UnblockUser reports whether there was a block to remove.
+func UnblockUser(blockerID, blockedID string) (bool, error) {
+	result, err := Db.Exec(
+		"DELETE FROM user_blocks WHERE blocker_id = $1 AND blocked_id = $2",
+		blockerID, blockedID,
+	)
+	if err != nil {
+		return false, err
+	}
+	n, _ := result.RowsAffected()
+	return n > 0, nil
+}
+
+// IsBlocked reports whether either user has blocked the other. A block works
+// both ways: neither side can message the other until it is lifted.
+func IsBlocked(userID, otherID string) (bool, error) {
+	var blocked bool
+	err := Db.QueryRow(`
+		SELECT EXISTS (
+			SELECT 1 FROM user_blocks
+			WHERE (blocker_id = $1 AND blocked_id = $2) OR (blocker_id = $2 AND blocked_id = $1)
+		)`, userID, otherID,
+	).Scan(&blocked)
+	return blocked, err
+}
+
+// GetBlockedUsers lists the users userID has blocked, most recent first.
+func GetBlockedUsers(userID string) ([]models.Blocked_User, error) {
+	rows, err := Db.Query(`
+		SELECT u.id, u.username, u.number, b.created_at
+		FROM user_blocks b
+		JOIN users u ON u.id = b.blocked_id
+		WHERE b.blocker_id = $1
+		ORDER BY b.created_at DESC`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	blocked := []models.Blocked_User{}
+	for rows.Next() {
+		var user models.Blocked_User
+		if err := rows.Scan(&user.UserID, &user.Username, &user.Number, &user.BlockedAt); err != nil {
+			return nil, err
+		}
+		blocked = append(blocked, user)
+	}
+	return blocked, rows.Err()
+}
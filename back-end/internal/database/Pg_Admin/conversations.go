@@ -0,0 +1,211 @@
+package pg_admin
+
+import (
+	"database/sql"
+	"errors"
+	"strconv"
+
+	"github.com/Ahmeds-Library/Chat-App/internal/models"
+)
+
+var ErrConversationNotFound = errors.New("conversation not found")
+var ErrNotMember = errors.New("user is not a member of this conversation")
+
+// CreateConversation creates a group owned by ownerID with the given members
+// and returns it with its member list.
+func CreateConversation(name, ownerID string, memberIDs []string) (*models.Conversation, error) {
+	tx, err := Db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var conversation models.Conversation
+	err = tx.QueryRow(
+		"INSERT INTO conversations (name, created_by) VALUES ($1, $2) RETURNING id, name, created_by, created_at",
+		name, ownerID,
+	).Scan(&conversation.ID, &conversation.Name, &conversation.CreatedBy, &conversation.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := tx.Exec(
+		"INSERT INTO conversation_members (conversation_id, user_id, role) VALUES ($1, $2, $3)",
+		conversation.ID, ownerID, models.RoleOwner,
+	); err != nil {
+		return nil, err
+	}
+
+	for _, memberID := range memberIDs {
+		if memberID == ownerID {
+			continue
+		}
+		if _, err := tx.Exec(
+			"INSERT INTO conversation_members (conversation_id, user_id, role) VALUES ($1, $2, $3) ON CONFLICT DO NOTHING",
+			conversation.ID, memberID, models.RoleMember,
+		); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	conversation.Members, err = GetConversationMembers(conversation.ID)
+	if err != nil {
+		return nil, err
+	}
+	return &conversation, nil
+}
+
+func GetConversation(conversationID string) (*models.Conversation, error) {
+	if _, err := strconv.Atoi(conversationID); err != nil {
+		return nil, ErrConversationNotFound
+	}
+
+	var conversation models.Conversation
+	err := Db.QueryRow(
+		"SELECT id, name, created_by, created_at FROM conversations WHERE id = $1", conversationID,
+	).Scan(&conversation.ID, &conversation.Name, &conversation.CreatedBy, &conversation.CreatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrConversationNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	conversation.Members, err = GetConversationMembers(conversationID)
+	if err != nil {
+		return nil, err
+	}
+	return &conversation, nil
+}
+
+// GetUserConversations lists the groups the user belongs to, without members.
+func GetUserConversations(userID string) ([]models.Conversation, error) {
+	rows, err := Db.Query(`
+		SELECT c.id, c.name, c.created_by, c.created_at
+		FROM conversations c
+		JOIN conversation_members m ON m.conversation_id = c.id
+		WHERE m.user_id = $1
+		ORDER BY c.created_at DESC`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	conversations := []models.Conversation{}
+	for rows.Next() {
+		var conversation models.Conversation
+		if err := rows.Scan(&conversation.ID, &conversation.Name, &conversation.CreatedBy, &conversation.CreatedAt); err != nil {
+			return nil, err
+		}
+		conversations = append(conversations, conversation)
+	}
+	return conversations, rows.Err()
+}
+
+func GetConversationMembers(conversationID string) ([]models.Conversation_Member, error) {
+	rows, err := Db.Query(`
+		SELECT u.id, u.username, u.number, m.role, m.joined_at
+		FROM conversation_members m
+		JOIN users u ON u.id = m.user_id
+		WHERE m.conversation_id = $1
+		ORDER BY m.joined_at`, conversationID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var members []models.Conversation_Member
+	for rows.Next() {
+		var member models.Conversation_Member
+		if err := rows.Scan(&member.UserID, &member.Username, &member.Number, &member.Role, &member.JoinedAt); err != nil {
+			return nil, err
+		}
+		members = append(members, member)
+	}
+	return members, rows.Err()
+}
+
+// GetMemberRole returns the user's role in the conversation, ErrNotMember if
+// they are not in it, or ErrConversationNotFound if it does not exist.
+func GetMemberRole(conversationID, userID string) (string, error) {
+	if _, err := strconv.Atoi(conversationID); err != nil {
+		return "", ErrConversationNotFound
+	}
+
+	var role string
+	err := Db.QueryRow(
+		"SELECT role FROM conversation_members WHERE conversation_id = $1 AND user_id = $2",
+		conversationID, userID,
+	).Scan(&role)
+	if errors.Is(err, sql.ErrNoRows) {
+		if _, err := GetConversation(conversationID); err != nil {
+			return "", err
+		}
+		return "", ErrNotMember
+	}
+	if err != nil {
+		return "", err
+	}
+	return role, nil
+}
+
+func AddConversationMembers(conversationID string, memberIDs []string) error {
+	tx, err := Db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, memberID := range memberIDs {
+		if _, err := tx.Exec(
+			"INSERT INTO conversation_members (conversation_id, user_id, role) VALUES ($1, $2, $3) ON CONFLICT DO NOTHING",
+			conversationID, memberID, models.RoleMember,
+		); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+func RemoveConversationMember(conversationID, userID string) error {
+	result, err := Db.Exec(
+		"DELETE FROM conversation_members WHERE conversation_id = $1 AND user_id = $2",
+		conversationID, userID,
+	)
+	if err != nil {
+		return err
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return ErrNotMember
+	}
+	return nil
+}
+
+func SetMemberRole(conversationID, userID, role string) error {
+	result, err := Db.Exec(
+		"UPDATE conversation_members SET role = $3 WHERE conversation_id = $1 AND user_id = $2",
+		conversationID, userID, role,
+	)
+	if err != nil {
+		return err
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return ErrNotMember
+	}
+	return nil
+}
+
+func RenameConversation(conversationID, name string) error {
+	result, err := Db.Exec("UPDATE conversations SET name = $2 WHERE id = $1", conversationID, name)
+	if err != nil {
+		return err
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return ErrConversationNotFound
+	}
+	return nil
+}
@@ -0,0 +1,42 @@
+package pg_admin
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/Ahmeds-Library/Chat-App/internal/models"
+	"github.com/lib/pq"
+)
+
+// GetDataFromIDs looks up several users in one query. Users that do not exist
+// are missing from the returned map.
+func GetDataFromIDs(userIDs []string) (map[string]models.User, error) {
+	ids := make([]int64, 0, len(userIDs))
+	for _, userID := range userIDs {
+		id, err := strconv.ParseInt(userID, 10, 64)
+		if err != nil {
+			return nil, errors.New("invalid userID format: " + userID)
+		}
+		ids = append(ids, id)
+	}
+
+	users := make(map[string]models.User, len(ids))
+	if len(ids) == 0 {
+		return users, nil
+	}
+
+	rows, err := Db.Query("SELECT id, username, number FROM users WHERE id = ANY($1)", pq.Array(ids))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var user models.User
+		if err := rows.Scan(&user.ID, &user.Username, &user.Number); err != nil {
+			return nil, err
+		}
+		users[user.ID] = user
+	}
+	return users, rows.Err()
+}
@@ -5,14 +5,15 @@ import (
 	"errors"
 )
 
-func GetUserCredentials(username string) (string, string, string, error) {
+func GetUserCredentials(username string) (string, string, string, bool, error) {
 	var dbPassword, dbNumber, dbID string
-	err := Db.QueryRow("SELECT password, number, id FROM users WHERE username = $1", username).Scan(&dbPassword, &dbNumber, &dbID)
+	var verified bool
+	err := Db.QueryRow("SELECT password, number, id, phone_verified FROM users WHERE username = $1", username).Scan(&dbPassword, &dbNumber, &dbID, &verified)
 	if err == sql.ErrNoRows {
-		return "", "", "", errors.New("user not found")
+		return "", "", "", false, errors.New("user not found")
 	}
 	if err != nil {
-		return "", "", "", err
+		return "", "", "", false, err
 	}
-	return dbPassword, dbNumber, dbID, nil
+	return dbPassword, dbNumber, dbID, verified, nil
 }
@@ -16,9 +16,11 @@ func InitDB(dataSourceName string) error {
 	return Db.Ping()
 }
 
+// GetUserByPhone returns the account that confirmed the number. Unverified
+// signups are skipped, as anyone can sign up with any number.
 func GetUserByPhone(phone string) (*models.User, error) {
 	var user models.User
-	row := Db.QueryRow("SELECT id, username, number FROM users WHERE number=$1", phone)
+	row := Db.QueryRow("SELECT id, username, number FROM users WHERE number=$1 AND phone_verified", phone)
 	if err := row.Scan(&user.ID, &user.Username, &user.Number); err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return nil, errors.New("user not found")
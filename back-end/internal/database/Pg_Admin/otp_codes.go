@@ -0,0 +1,106 @@
+package pg_admin
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/Ahmeds-Library/Chat-App/internal/models"
+)
+
+// SaveOTP stores a code for the user, replacing their earlier one for the
+// purpose, unless a code for the purpose was sent to number after
+// sentBefore, for whichever account. It reports whether the code was saved.
+// The number's wrong attempts are kept.
+func SaveOTP(userID, number, purpose string, code models.OTP_Code, sentBefore time.Time) (bool, error) {
+	tx, err := Db.Begin()
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(`
+		INSERT INTO otp_limits (number, purpose, sent_at, attempts_since)
+		VALUES ($1, $2, $3, $3)
+		ON CONFLICT (number, purpose) DO UPDATE
+		SET sent_at = EXCLUDED.sent_at
+		WHERE otp_limits.sent_at < $4`,
+		number, purpose, code.SentAt, sentBefore,
+	)
+	if err != nil {
+		return false, err
+	}
+	if n, err := result.RowsAffected(); err != nil || n == 0 {
+		return false, err
+	}
+
+	_, err = tx.Exec(`
+		INSERT INTO otp_codes (user_id, purpose, code_hash, sent_at, expires_at)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (user_id, purpose) DO UPDATE
+		SET code_hash = EXCLUDED.code_hash, sent_at = EXCLUDED.sent_at, expires_at = EXCLUDED.expires_at`,
+		userID, purpose, code.Hash, code.SentAt, code.ExpiresAt,
+	)
+	if err != nil {
+		return false, err
+	}
+	return true, tx.Commit()
+}
+
+// UseOTP passes the user's code for the purpose to check, with the wrong
+// attempts made for number, or nil if there is no code. Checks for the same
+// number take turns. If check returns true the code is deleted and the
+// attempts are cleared, otherwise the attempts are saved.
+func UseOTP(userID, number, purpose string, check func(code *models.OTP_Code) bool) error {
+	tx, err := Db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := lockNumber(tx, number); err != nil {
+		return err
+	}
+
+	var code models.OTP_Code
+	err = tx.QueryRow(
+		"SELECT code_hash, sent_at, expires_at FROM otp_codes WHERE user_id = $1 AND purpose = $2",
+		userID, purpose,
+	).Scan(&code.Hash, &code.SentAt, &code.ExpiresAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		check(nil)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	err = tx.QueryRow(
+		"SELECT attempts, attempts_since FROM otp_limits WHERE number = $1 AND purpose = $2",
+		number, purpose,
+	).Scan(&code.Attempts, &code.AttemptsSince)
+	if errors.Is(err, sql.ErrNoRows) {
+		code.AttemptsSince = code.SentAt
+	} else if err != nil {
+		return err
+	}
+
+	if check(&code) {
+		if _, err := tx.Exec("DELETE FROM otp_codes WHERE user_id = $1 AND purpose = $2", userID, purpose); err != nil {
+			return err
+		}
+		_, err = tx.Exec("UPDATE otp_limits SET attempts = 0 WHERE number = $1 AND purpose = $2", number, purpose)
+	} else {
+		_, err = tx.Exec(`
+			INSERT INTO otp_limits (number, purpose, sent_at, attempts, attempts_since)
+			VALUES ($1, $2, $3, $4, $5)
+			ON CONFLICT (number, purpose) DO UPDATE
+			SET attempts = EXCLUDED.attempts, attempts_since = EXCLUDED.attempts_since`,
+			number, purpose, code.SentAt, code.Attempts, code.AttemptsSince,
+		)
+	}
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
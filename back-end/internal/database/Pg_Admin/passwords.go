@@ -0,0 +1,50 @@
+package pg_admin
+
+import (
+	"database/sql"
+	"errors"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// CheckPassword reports whether password is the user's current password.
+func CheckPassword(userID, password string) (bool, error) {
+	var hash string
+	err := Db.QueryRow("SELECT password FROM users WHERE id = $1", userID).Scan(&hash)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, errors.New("user not found")
+	}
+	if err != nil {
+		return false, err
+	}
+	return bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)) == nil, nil
+}
+
+// SetPassword replaces the user's password and ends all their sessions
+// except keepSessionID, which may be empty, in one transaction, so a
+// password is never changed without logging out the devices that knew the
+// old one.
+func SetPassword(userID, password, keepSessionID string) error {
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return errors.New("failed to hash password")
+	}
+
+	tx, err := Db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec("UPDATE users SET password = $2 WHERE id = $1", userID, string(hashedPassword))
+	if err != nil {
+		return err
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return errors.New("user not found")
+	}
+	if err := revokeOtherSessions(tx, userID, keepSessionID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,99 @@
+package pg_admin
+
+import (
+	"database/sql"
+	"errors"
+)
+
+var (
+	ErrNumberNotFound = errors.New("no account uses this number")
+	// ErrNumberTaken is returned when another account has confirmed the
+	// number.
+	ErrNumberTaken = errors.New("number belongs to another account")
+)
+
+// lockNumber makes signups and verifications of the number take turns until
+// tx ends, since a plain unique index cannot tell them apart.
+func lockNumber(tx *sql.Tx, number string) error {
+	_, err := tx.Exec("SELECT pg_advisory_xact_lock(hashtext('users.number:' || $1))", number)
+	return err
+}
+
+// GetPendingSignup returns the ID and username of the account waiting to
+// confirm the number. CreateUser keeps there from being more than one.
+func GetPendingSignup(number string) (string, string, error) {
+	var id, username string
+	err := Db.QueryRow(
+		"SELECT id, username FROM users WHERE number = $1 AND NOT phone_verified ORDER BY id DESC LIMIT 1",
+		number,
+	).Scan(&id, &username)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", "", ErrNumberNotFound
+	}
+	if err != nil {
+		return "", "", err
+	}
+	return id, username, nil
+}
+
+// NumberVerified reports whether an account has confirmed the number.
+func NumberVerified(number string) (bool, error) {
+	var verified bool
+	err := Db.QueryRow("SELECT EXISTS (SELECT 1 FROM users WHERE number = $1 AND phone_verified)", number).Scan(&verified)
+	return verified, err
+}
+
+// MarkPhoneVerified records that the user has confirmed their number. It
+// returns ErrNumberTaken if another account confirmed it first, and
+// ErrNumberNotFound if the signup is gone or was already confirmed.
+func MarkPhoneVerified(userID string) error {
+	tx, err := Db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var number string
+	err = tx.QueryRow("SELECT number FROM users WHERE id = $1 AND NOT phone_verified", userID).Scan(&number)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrNumberNotFound
+	}
+	if err != nil {
+		return err
+	}
+	if err := lockNumber(tx, number); err != nil {
+		return err
+	}
+
+	var taken bool
+	if err := tx.QueryRow("SELECT EXISTS (SELECT 1 FROM users WHERE number = $1 AND phone_verified)", number).Scan(&taken); err != nil {
+		return err
+	}
+	if taken {
+		return ErrNumberTaken
+	}
+
+	// The signup may have been replaced while waiting for the lock.
+	result, err := tx.Exec("UPDATE users SET phone_verified = TRUE WHERE id = $1 AND NOT phone_verified", userID)
+	if err != nil {
+		return err
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return ErrNumberNotFound
+	}
+	return tx.Commit()
+}
+
+// GetVerifiedUser returns the ID of the account with the username, if it has
+// confirmed the number, and ErrNumberNotFound otherwise.
+func GetVerifiedUser(username, number string) (string, error) {
+	var id string
+	err := Db.QueryRow(
+		"SELECT id FROM users WHERE username = $1 AND number = $2 AND phone_verified",
+		username, number,
+	).Scan(&id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", ErrNumberNotFound
+	}
+	return id, err
+}
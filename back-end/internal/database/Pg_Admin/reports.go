@@ -0,0 +1,23 @@
+package pg_admin
+
+import (
+	"encoding/json"
+
+	"github.com/Ahmeds-Library/Chat-App/internal/models"
+)
+
+// CreateReport adds a report to the moderation queue, filling in its ID,
+// status and creation time.
+func CreateReport(report *models.Report) error {
+	messages, err := json.Marshal(report.Messages)
+	if err != nil {
+		return err
+	}
+
+	return Db.QueryRow(`
+		INSERT INTO reports (reporter_id, reported_id, conversation_id, reason, details, messages)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id, status, created_at`,
+		report.ReporterID, report.ReportedID, report.ConversationID, report.Reason, report.Details, messages,
+	).Scan(&report.ID, &report.Status, &report.CreatedAt)
+}
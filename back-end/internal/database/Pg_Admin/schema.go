@@ -0,0 +1,99 @@
+package pg_admin
+
+import "fmt"
+
+// schemaStatements bring an existing database up to date. Each statement must
+// be safe to run on every start.
+var schemaStatements = []string{
+	`ALTER TABLE users ADD COLUMN IF NOT EXISTS last_seen TIMESTAMPTZ`,
+	`CREATE TABLE IF NOT EXISTS conversations (
+		id SERIAL PRIMARY KEY,
+		name VARCHAR(100) NOT NULL,
+		created_by INTEGER NOT NULL REFERENCES users(id),
+		created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+	)`,
+	`CREATE TABLE IF NOT EXISTS conversation_members (
+		conversation_id INTEGER NOT NULL REFERENCES conversations(id) ON DELETE CASCADE,
+		user_id INTEGER NOT NULL REFERENCES users(id),
+		role VARCHAR(10) NOT NULL CHECK (role IN ('owner', 'admin', 'member')),
+		joined_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+		PRIMARY KEY (conversation_id, user_id)
+	)`,
+	`CREATE INDEX IF NOT EXISTS conversation_members_user_id_idx ON conversation_members (user_id)`,
+	`CREATE TABLE IF NOT EXISTS user_blocks (
+		blocker_id INTEGER NOT NULL REFERENCES users(id),
+		blocked_id INTEGER NOT NULL REFERENCES users(id),
+		created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+		PRIMARY KEY (blocker_id, blocked_id),
+		CHECK (blocker_id <> blocked_id)
+	)`,
+	`CREATE INDEX IF NOT EXISTS user_blocks_blocked_id_idx ON user_blocks (blocked_id)`,
+	`CREATE TABLE IF NOT EXISTS reports (
+		id SERIAL PRIMARY KEY,
+		reporter_id INTEGER NOT NULL REFERENCES users(id),
+		reported_id INTEGER NOT NULL REFERENCES users(id),
+		conversation_id VARCHAR(64) NOT NULL,
+		reason VARCHAR(20) NOT NULL,
+		details TEXT NOT NULL DEFAULT '',
+		messages JSONB NOT NULL DEFAULT '[]',
+		status VARCHAR(10) NOT NULL DEFAULT 'open' CHECK (status IN ('open', 'resolved', 'dismissed')),
+		created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+	)`,
+	`CREATE INDEX IF NOT EXISTS reports_status_idx ON reports (status, created_at)`,
+	`CREATE TABLE IF NOT EXISTS sessions (
+		id CHAR(32) PRIMARY KEY,
+		user_id INTEGER NOT NULL REFERENCES users(id),
+		created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+		revoked_at TIMESTAMPTZ
+	)`,
+	`CREATE INDEX IF NOT EXISTS sessions_user_id_idx ON sessions (user_id)`,
+	`CREATE TABLE IF NOT EXISTS refresh_tokens (
+		token_hash CHAR(64) PRIMARY KEY,
+		session_id CHAR(32) NOT NULL REFERENCES sessions(id) ON DELETE CASCADE,
+		created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+		expires_at TIMESTAMPTZ NOT NULL,
+		used_at TIMESTAMPTZ
+	)`,
+	`CREATE INDEX IF NOT EXISTS refresh_tokens_session_id_idx ON refresh_tokens (session_id)`,
+	`ALTER TABLE sessions ADD COLUMN IF NOT EXISTS user_agent TEXT NOT NULL DEFAULT ''`,
+	`ALTER TABLE sessions ADD COLUMN IF NOT EXISTS ip VARCHAR(45) NOT NULL DEFAULT ''`,
+	`ALTER TABLE sessions ADD COLUMN IF NOT EXISTS last_used_at TIMESTAMPTZ NOT NULL DEFAULT NOW()`,
+	// Accounts created before numbers were verified keep working; new ones
+	// start unverified.
+	`ALTER TABLE users ADD COLUMN IF NOT EXISTS phone_verified BOOLEAN NOT NULL DEFAULT TRUE`,
+	`ALTER TABLE users ALTER COLUMN phone_verified SET DEFAULT FALSE`,
+	// When each account signed up.
+	`ALTER TABLE users ADD COLUMN IF NOT EXISTS created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()`,
+	`CREATE INDEX IF NOT EXISTS users_number_idx ON users (number)`,
+	// Codes belong to an account rather than a number, since an unverified
+	// signup may share its number with other accounts.
+	`CREATE TABLE IF NOT EXISTS otp_codes (
+		user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+		purpose VARCHAR(16) NOT NULL,
+		code_hash TEXT NOT NULL,
+		attempts INTEGER NOT NULL DEFAULT 0,
+		sent_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+		expires_at TIMESTAMPTZ NOT NULL,
+		PRIMARY KEY (user_id, purpose)
+	)`,
+	// Resends and wrong codes are limited per number instead, so neither a
+	// new code nor a new signup for the number starts them over.
+	`CREATE TABLE IF NOT EXISTS otp_limits (
+		number VARCHAR(11) NOT NULL,
+		purpose VARCHAR(16) NOT NULL,
+		sent_at TIMESTAMPTZ NOT NULL,
+		attempts INTEGER NOT NULL DEFAULT 0,
+		attempts_since TIMESTAMPTZ NOT NULL,
+		PRIMARY KEY (number, purpose)
+	)`,
+	`ALTER TABLE otp_codes DROP COLUMN IF EXISTS attempts`,
+}
+
+func EnsureSchema() error {
+	for _, stmt := range schemaStatements {
+		if _, err := Db.Exec(stmt); err != nil {
+			return fmt.Errorf("%s: %w", stmt, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,249 @@
+package pg_admin
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/Ahmeds-Library/Chat-App/internal/models"
+)
+
+// SessionRevokedChannel is the NOTIFY channel revocations are announced on,
+// so the websocket service can drop the connections of revoked sessions. The
+// payload is a JSON object with user_id and session_id; an empty session_id
+// means every session of the user.
+const SessionRevokedChannel = "session_revoked"
+
+// sessionTouchInterval limits how often last_used_at is written for a
+// session, so authenticating a request is usually a single read.
+const sessionTouchInterval = time.Minute
+
+var (
+	ErrRefreshTokenInvalid = errors.New("refresh token is invalid or expired")
+	ErrRefreshTokenReused  = errors.New("refresh token was already used")
+	ErrSessionNotFound     = errors.New("session not found")
+)
+
+// CreateSession starts a login session from the given device with its first
+// refresh token.
+func CreateSession(sessionID, userID, userAgent, ip, tokenHash string, expiresAt time.Time) error {
+	tx, err := Db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(
+		"INSERT INTO sessions (id, user_id, user_agent, ip) VALUES ($1, $2, $3, $4)",
+		sessionID, userID, userAgent, ip,
+	); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(
+		"INSERT INTO refresh_tokens (token_hash, session_id, expires_at) VALUES ($1, $2, $3)",
+		tokenHash, sessionID, expiresAt,
+	); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// RotateRefreshToken swaps a session's current refresh token for a new one.
+// Every refresh token works once. Presenting one that was already used means
+// it was copied, so the whole session is revoked and ErrRefreshTokenReused
+// returned; both the thief and the owner then have to log in again.
+func RotateRefreshToken(sessionID, oldHash, newHash string, expiresAt time.Time) error {
+	tx, err := Db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var userID string
+	var usedAt, revokedAt sql.NullTime
+	var expired bool
+	err = tx.QueryRow(`
+		SELECT s.user_id, r.used_at, s.revoked_at, r.expires_at <= NOW()
+		FROM refresh_tokens r
+		JOIN sessions s ON s.id = r.session_id
+		WHERE r.token_hash = $1 AND r.session_id = $2
+		FOR UPDATE`, oldHash, sessionID,
+	).Scan(&userID, &usedAt, &revokedAt, &expired)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrRefreshTokenInvalid
+	}
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case revokedAt.Valid || expired:
+		return ErrRefreshTokenInvalid
+	case usedAt.Valid:
+		if _, err := revokeSessions(tx, userID, sessionID); err != nil {
+			return err
+		}
+		if err := tx.Commit(); err != nil {
+			return err
+		}
+		return ErrRefreshTokenReused
+	}
+
+	if _, err := tx.Exec("UPDATE refresh_tokens SET used_at = NOW() WHERE token_hash = $1", oldHash); err != nil {
+		return err
+	}
+	if _, err := tx.Exec("UPDATE sessions SET last_used_at = NOW() WHERE id = $1", sessionID); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(
+		"INSERT INTO refresh_tokens (token_hash, session_id, expires_at) VALUES ($1, $2, $3)",
+		newHash, sessionID, expiresAt,
+	); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// UseSession reports whether a session can still be used, and records that
+// it was.
+func UseSession(sessionID string) (bool, error) {
+	var active, stale bool
+	err := Db.QueryRow(
+		"SELECT revoked_at IS NULL, last_used_at < $2 FROM sessions WHERE id = $1",
+		sessionID, time.Now().Add(-sessionTouchInterval),
+	).Scan(&active, &stale)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	if active && stale {
+		if _, err := Db.Exec("UPDATE sessions SET last_used_at = NOW() WHERE id = $1", sessionID); err != nil {
+			return false, err
+		}
+	}
+	return active, nil
+}
+
+// GetUserSessions lists the user's sessions that can still refresh, most
+// recently used first.
+func GetUserSessions(userID string) ([]models.Session, error) {
+	rows, err := Db.Query(`
+		SELECT s.id, s.user_agent, s.ip, s.created_at, s.last_used_at
+		FROM sessions s
+		WHERE s.user_id = $1 AND s.revoked_at IS NULL AND EXISTS (
+			SELECT 1 FROM refresh_tokens r
+			WHERE r.session_id = s.id AND r.used_at IS NULL AND r.expires_at > NOW()
+		)
+		ORDER BY s.last_used_at DESC`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	sessions := []models.Session{}
+	for rows.Next() {
+		var session models.Session
+		if err := rows.Scan(&session.ID, &session.UserAgent, &session.IP, &session.CreatedAt, &session.LastUsedAt); err != nil {
+			return nil, err
+		}
+		sessions = append(sessions, session)
+	}
+	return sessions, rows.Err()
+}
+
+// RevokeSession ends one of the user's sessions, or returns
+// ErrSessionNotFound if they have no such active session.
+func RevokeSession(userID, sessionID string) error {
+	n, err := revokeInTx(userID, sessionID)
+	if err == nil && n == 0 {
+		return ErrSessionNotFound
+	}
+	return err
+}
+
+// RevokeUserSessions ends every session of the user.
+func RevokeUserSessions(userID string) error {
+	_, err := revokeInTx(userID, "")
+	return err
+}
+
+// revokeOtherSessions ends every session of the user except keepID, and
+// announces each once the transaction commits.
+func revokeOtherSessions(tx *sql.Tx, userID, keepID string) error {
+	rows, err := tx.Query(
+		"UPDATE sessions SET revoked_at = NOW() WHERE user_id = $1 AND id <> $2 AND revoked_at IS NULL RETURNING id",
+		userID, keepID,
+	)
+	if err != nil {
+		return err
+	}
+	var sessionIDs []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return err
+		}
+		sessionIDs = append(sessionIDs, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, id := range sessionIDs {
+		if err := notifyRevoked(tx, userID, id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func revokeInTx(userID, sessionID string) (int64, error) {
+	tx, err := Db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	n, err := revokeSessions(tx, userID, sessionID)
+	if err != nil {
+		return 0, err
+	}
+	return n, tx.Commit()
+}
+
+// revokeSessions revokes one session of the user, or all of them when
+// sessionID is empty, and announces it once the transaction commits. It
+// returns how many sessions were still active.
+func revokeSessions(tx *sql.Tx, userID, sessionID string) (int64, error) {
+	var result sql.Result
+	var err error
+	if sessionID == "" {
+		result, err = tx.Exec("UPDATE sessions SET revoked_at = NOW() WHERE user_id = $1 AND revoked_at IS NULL", userID)
+	} else {
+		result, err = tx.Exec("UPDATE sessions SET revoked_at = NOW() WHERE id = $1 AND user_id = $2 AND revoked_at IS NULL", sessionID, userID)
+	}
+	if err != nil {
+		return 0, err
+	}
+	n, _ := result.RowsAffected()
+	if n == 0 {
+		return 0, nil
+	}
+
+	return n, notifyRevoked(tx, userID, sessionID)
+}
+
+func notifyRevoked(tx *sql.Tx, userID, sessionID string) error {
+	payload, err := json.Marshal(map[string]string{"user_id": userID, "session_id": sessionID})
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec("SELECT pg_notify($1, $2)", SessionRevokedChannel, string(payload))
+	return err
+}
@@ -7,19 +7,51 @@ import (
 	"golang.org/x/crypto/bcrypt"
 )
 
-func CreateUser(username, password, number string) error {
+// CreateUser adds an account that has yet to confirm its number and returns
+// its ID. It returns ErrNumberTaken if an account has confirmed the number.
+// Unconfirmed signups with the number are replaced, so nobody can hold a
+// number they cannot confirm; the owner can always sign up again, and
+// replacing a signup does not reset the number's limits on codes.
+func CreateUser(username, password, number string) (string, error) {
 
 	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
 	if err != nil {
-		return errors.New("failed to hash password")
+		return "", errors.New("failed to hash password")
 	}
 
-	_, err = Db.Exec("INSERT INTO users (username, password, number) VALUES ($1, $2, $3)", username, string(hashedPassword), number)
+	tx, err := Db.Begin()
+	if err != nil {
+		return "", err
+	}
+	defer tx.Rollback()
+
+	if err := lockNumber(tx, number); err != nil {
+		return "", err
+	}
+
+	var verified bool
+	err = tx.QueryRow("SELECT EXISTS (SELECT 1 FROM users WHERE number = $1 AND phone_verified)", number).Scan(&verified)
+	if err != nil {
+		return "", err
+	}
+	if verified {
+		return "", ErrNumberTaken
+	}
+
+	if _, err := tx.Exec("DELETE FROM users WHERE number = $1 AND NOT phone_verified", number); err != nil {
+		return "", err
+	}
+
+	var id string
+	err = tx.QueryRow("INSERT INTO users (username, password, number) VALUES ($1, $2, $3) RETURNING id", username, string(hashedPassword), number).Scan(&id)
 	if err != nil {
 		if strings.Contains(err.Error(), "unique constraint") {
-			return errors.New("username already exists")
+			return "", errors.New("username already exists")
 		}
-		return err
+		return "", err
+	}
+	if err := tx.Commit(); err != nil {
+		return "", err
 	}
-	return nil
+	return id, nil
 }
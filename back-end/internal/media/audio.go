@@ -0,0 +1,157 @@
+package media
+
+import (
+	"bytes"
+	"encoding/binary"
+)
+
+var (
+	mp3BitratesV1 = [16]int{0, 32, 40, 48, 56, 64, 80, 96, 112, 128, 160, 192, 224, 256, 320, 0}
+	mp3BitratesV2 = [16]int{0, 8, 16, 24, 32, 40, 48, 56, 64, 80, 96, 112, 128, 144, 160, 0}
+	mp3Rates      = [3]int{44100, 48000, 32000}
+)
+
+// probeMP3 works out the duration of an MPEG layer III file from its Xing or
+// VBRI header, or from the bitrate of its first frame for constant bitrate
+// files.
+func probeMP3(data []byte) (float64, error) {
+	start := 0
+	if len(data) >= 10 && bytes.HasPrefix(data, []byte("ID3")) {
+		// The tag size is syncsafe: 7 bits per byte.
+		size := int(data[6])<<21 | int(data[7])<<14 | int(data[8])<<7 | int(data[9])
+		start = 10 + size
+		if data[5]&0x10 != 0 {
+			start += 10
+		}
+	}
+
+	for ; start+4 <= len(data); start++ {
+		if data[start] == 0xFF && data[start+1]&0xE0 == 0xE0 {
+			break
+		}
+	}
+	if start+4 > len(data) {
+		return 0, errMalformed
+	}
+
+	header := data[start:]
+	version := (header[1] >> 3) & 3 // 3: MPEG 1, 2: MPEG 2, 0: MPEG 2.5
+	layer := (header[1] >> 1) & 3   // 1: layer III
+	bitrateIndex := header[2] >> 4
+	rateIndex := (header[2] >> 2) & 3
+	mono := header[3]>>6 == 3
+	if version == 1 || layer != 1 || rateIndex == 3 {
+		return 0, errMalformed
+	}
+
+	rate := mp3Rates[rateIndex]
+	bitrate := mp3BitratesV1[bitrateIndex]
+	samplesPerFrame := 1152
+	sideInfo := 32
+	if mono {
+		sideInfo = 17
+	}
+	if version != 3 {
+		rate /= 2
+		if version == 0 {
+			rate /= 2
+		}
+		bitrate = mp3BitratesV2[bitrateIndex]
+		samplesPerFrame = 576
+		sideInfo = 17
+		if mono {
+			sideInfo = 9
+		}
+	}
+
+	if xing := start + 4 + sideInfo; xing+12 <= len(data) {
+		tag := string(data[xing : xing+4])
+		if (tag == "Xing" || tag == "Info") && binary.BigEndian.Uint32(data[xing+4:])&1 != 0 {
+			frames := binary.BigEndian.Uint32(data[xing+8:])
+			return float64(frames) * float64(samplesPerFrame) / float64(rate), nil
+		}
+	}
+	if vbri := start + 36; vbri+18 <= len(data) && string(data[vbri:vbri+4]) == "VBRI" {
+		frames := binary.BigEndian.Uint32(data[vbri+14:])
+		return float64(frames) * float64(samplesPerFrame) / float64(rate), nil
+	}
+
+	// Free format files give no bitrate to go by.
+	if bitrate == 0 {
+		return 0, nil
+	}
+	return float64(len(data)-start) * 8 / float64(bitrate*1000), nil
+}
+
+// probeOgg works out the duration of an Opus or Vorbis file from the granule
+// position of its last page.
+func probeOgg(data []byte) (float64, error) {
+	if len(data) < 27 || !bytes.HasPrefix(data, []byte("OggS")) {
+		return 0, errMalformed
+	}
+	// The first page's segment table gives the length of its header.
+	headerLen := 27 + int(data[26])
+	if len(data) < headerLen {
+		return 0, errMalformed
+	}
+
+	packet := data[headerLen:]
+	var rate, preSkip float64
+	switch {
+	case bytes.HasPrefix(packet, []byte("OpusHead")):
+		if len(packet) < 12 {
+			return 0, errMalformed
+		}
+		// Opus granule positions always count 48 kHz samples.
+		rate = 48000
+		preSkip = float64(binary.LittleEndian.Uint16(packet[10:]))
+	case bytes.HasPrefix(packet, []byte("\x01vorbis")):
+		if len(packet) < 16 {
+			return 0, errMalformed
+		}
+		rate = float64(binary.LittleEndian.Uint32(packet[12:]))
+	default:
+		return 0, nil
+	}
+
+	last := bytes.LastIndex(data, []byte("OggS"))
+	if rate == 0 || last+14 > len(data) {
+		return 0, errMalformed
+	}
+	granule := float64(binary.LittleEndian.Uint64(data[last+6:]))
+	if granule <= preSkip {
+		return 0, nil
+	}
+	return (granule - preSkip) / rate, nil
+}
+
+// probeWAV works out the duration of a RIFF WAVE file from its byte rate and
+// the size of its data chunk.
+func probeWAV(data []byte) (float64, error) {
+	if len(data) < 12 || string(data[:4]) != "RIFF" || string(data[8:12]) != "WAVE" {
+		return 0, errMalformed
+	}
+
+	var byteRate uint32
+	pos := 12
+	for pos+8 <= len(data) {
+		chunkType := string(data[pos : pos+4])
+		size := int(binary.LittleEndian.Uint32(data[pos+4:]))
+		body := data[pos+8:]
+		switch chunkType {
+		case "fmt ":
+			if len(body) < 12 {
+				return 0, errMalformed
+			}
+			byteRate = binary.LittleEndian.Uint32(body[8:])
+		case "data":
+			if byteRate == 0 {
+				return 0, errMalformed
+			}
+			return float64(size) / float64(byteRate), nil
+		}
+		// Chunks are padded to an even size.
+		pos += 8 + size + size%2
+	}
+	return 0, errMalformed
+}
@@ -0,0 +1,124 @@
+package media
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"math"
+	"testing"
+)
+
+// mp3File is an MPEG 1 layer III stereo frame at 44.1 kHz with a Xing header
+// counting frames frames.
+func mp3File(frames uint32) []byte {
+	data := []byte{0xFF, 0xFB, 0x90, 0x64}
+	data = append(data, make([]byte, 32)...)
+	data = append(data, "Xing"...)
+	data = binary.BigEndian.AppendUint32(data, 1)
+	data = binary.BigEndian.AppendUint32(data, frames)
+	return append(data, make([]byte, 400)...)
+}
+
+// oggPage builds an Ogg page holding a single packet.
+func oggPage(granule uint64, packet []byte) []byte {
+	page := []byte("OggS\x00\x00")
+	page = binary.LittleEndian.AppendUint64(page, granule)
+	page = append(page, make([]byte, 12)...) // serial, sequence, CRC
+	page = append(page, 1, byte(len(packet)))
+	return append(page, packet...)
+}
+
+// opusFile is an Ogg Opus file lasting seconds, with a pre-skip of 312.
+func opusFile(seconds float64) []byte {
+	head := []byte("OpusHead\x01\x02")
+	head = binary.LittleEndian.AppendUint16(head, 312)
+	head = binary.LittleEndian.AppendUint32(head, 48000)
+	head = append(head, 0, 0, 0)
+	return append(oggPage(0, head), oggPage(uint64(seconds*48000)+312, []byte("audio"))...)
+}
+
+// wavFile is a RIFF WAVE file with byteRate bytes per second of silence.
+func wavFile(byteRate uint32, size int) []byte {
+	data := []byte("RIFF\x00\x00\x00\x00WAVEfmt ")
+	data = binary.LittleEndian.AppendUint32(data, 16)
+	data = append(data, 1, 0, 2, 0)
+	data = binary.LittleEndian.AppendUint32(data, byteRate/4)
+	data = binary.LittleEndian.AppendUint32(data, byteRate)
+	data = append(data, 4, 0, 16, 0)
+	data = append(data, "data"...)
+	data = binary.LittleEndian.AppendUint32(data, uint32(size))
+	return append(data, make([]byte, size)...)
+}
+
+func TestProbeMP3(t *testing.T) {
+	duration, err := probeMP3(mp3File(100))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := 100 * 1152 / 44100.0; math.Abs(duration-want) > 1e-9 {
+		t.Fatalf("duration %v, want %v", duration, want)
+	}
+}
+
+func TestProbeOgg(t *testing.T) {
+	duration, err := probeOgg(opusFile(2.5))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if math.Abs(duration-2.5) > 1e-9 {
+		t.Fatalf("duration %v, want 2.5", duration)
+	}
+}
+
+func TestProbeWAV(t *testing.T) {
+	duration, err := probeWAV(wavFile(8000, 12000))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if duration != 1.5 {
+		t.Fatalf("duration %v, want 1.5", duration)
+	}
+}
+
+func TestAudioProbesRejectMalformedFiles(t *testing.T) {
+	// The segment table says the header runs past the end of the file.
+	shortOgg := oggPage(0, []byte("OpusHead"))
+	shortOgg[26] = 200
+	shortOgg = shortOgg[:28]
+
+	probes := map[string]func([]byte) (float64, error){
+		"mp3": probeMP3,
+		"ogg": probeOgg,
+		"wav": probeWAV,
+	}
+	tests := []struct {
+		probe string
+		name  string
+		data  []byte
+	}{
+		{"mp3", "empty", nil},
+		{"mp3", "no frame sync", bytes.Repeat([]byte{0x12}, 64)},
+		{"mp3", "reserved version", []byte{0xFF, 0xEB, 0x90, 0x64, 0, 0, 0, 0}},
+		{"mp3", "tag larger than file", []byte("ID3\x04\x00\x00\x7f\x7f\x7f\x7f")},
+		{"ogg", "empty", nil},
+		{"ogg", "not ogg", bytes.Repeat([]byte("x"), 64)},
+		{"ogg", "header only", []byte("OggS\x00\x02\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00")},
+		{"ogg", "segment table past end", shortOgg},
+		{"ogg", "truncated opus header", oggPage(0, []byte("OpusHead\x01"))},
+		{"ogg", "truncated vorbis header", oggPage(0, []byte("\x01vorbis\x00"))},
+		{"wav", "empty", nil},
+		{"wav", "not wave", []byte("RIFF\x00\x00\x00\x00AVI LIST")},
+		{"wav", "truncated fmt", []byte("RIFF\x00\x00\x00\x00WAVEfmt \x10\x00\x00\x00\x01\x00")},
+		{"wav", "data before fmt", []byte("RIFF\x00\x00\x00\x00WAVEdata\x04\x00\x00\x00\x00\x00\x00\x00")},
+		{"wav", "no data chunk", wavFile(8000, 0)[:36]},
+		{"wav", "chunk size overflows", []byte("RIFF\x00\x00\x00\x00WAVEJUNK\xff\xff\xff\xff")},
+	}
+	for _, test := range tests {
+		t.Run(test.probe+"/"+test.name, func(t *testing.T) {
+			duration, err := probes[test.probe](test.data)
+			if !errors.Is(err, errMalformed) || duration != 0 {
+				t.Fatalf("got %v, %v; want 0, errMalformed", duration, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,259 @@
+package media
+
+import (
+	"bytes"
+	"encoding/binary"
+)
+
+const (
+	tagOrientation = 0x0112
+	tagGPSInfo     = 0x8825
+
+	// webpFlagXMP is set in the VP8X chunk of a WebP file with XMP data.
+	webpFlagXMP = 0x04
+)
+
+var (
+	exifHeader = []byte("Exif\x00\x00")
+	xmpHeader  = []byte("http://ns.adobe.com/xap/1.0/\x00")
+	pngMagic   = []byte("\x89PNG\r\n\x1a\n")
+)
+
+// stripJPEGLocation returns a copy of a JPEG with the EXIF GPS block emptied
+// and any XMP packet, which can repeat the location, dropped. The rest of the
+// EXIF data is kept. It also returns the EXIF orientation, or 1. A file whose
+// segments cannot be followed up to the image data is errMalformed, since
+// its location might be where this cannot see it.
+func stripJPEGLocation(data []byte) ([]byte, int, error) {
+	if len(data) < 4 || data[0] != 0xFF || data[1] != 0xD8 {
+		return nil, 1, errMalformed
+	}
+
+	out := make([]byte, 0, len(data))
+	out = append(out, data[:2]...)
+	orientation := 1
+
+	pos := 2
+	for {
+		// Any number of 0xFF fill bytes may come before a marker.
+		for pos+1 < len(data) && data[pos] == 0xFF && data[pos+1] == 0xFF {
+			pos++
+		}
+		if pos+2 > len(data) || data[pos] != 0xFF {
+			return nil, 1, errMalformed
+		}
+		marker := data[pos+1]
+		// Start of scan: the rest is image data.
+		if marker == 0xDA {
+			break
+		}
+		// These markers stand alone, without a length.
+		if marker == 0x01 || (marker >= 0xD0 && marker <= 0xD7) {
+			out = append(out, data[pos:pos+2]...)
+			pos += 2
+			continue
+		}
+		if marker == 0x00 || marker == 0xD8 || marker == 0xD9 || pos+4 > len(data) {
+			return nil, 1, errMalformed
+		}
+		length := int(binary.BigEndian.Uint16(data[pos+2:]))
+		end := pos + 2 + length
+		if length < 2 || end > len(data) {
+			return nil, 1, errMalformed
+		}
+
+		segment := append([]byte(nil), data[pos:end]...)
+		payload := segment[4:]
+		if marker == 0xE1 {
+			if bytes.HasPrefix(payload, xmpHeader) {
+				pos = end
+				continue
+			}
+			if bytes.HasPrefix(payload, exifHeader) {
+				var ok bool
+				if orientation, ok = stripTIFFLocation(payload[len(exifHeader):]); !ok {
+					return nil, 1, errMalformed
+				}
+			}
+		}
+
+		out = append(out, segment...)
+		pos = end
+	}
+
+	return append(out, data[pos:]...), orientation, nil
+}
+
+// stripWebPLocation does for a WebP file what stripJPEGLocation does for a
+// JPEG: it returns a copy with the GPS block of the EXIF chunk emptied and
+// any XMP chunk dropped. Viewers do not agree on honouring the EXIF
+// orientation of a WebP, so the thumbnail ignores it and it is not returned.
+func stripWebPLocation(data []byte) ([]byte, error) {
+	if len(data) < 12 || string(data[:4]) != "RIFF" || string(data[8:12]) != "WEBP" {
+		return nil, errMalformed
+	}
+
+	out := make([]byte, 0, len(data))
+	out = append(out, data[:12]...)
+
+	pos := 12
+	for pos+8 <= len(data) {
+		chunkType := string(data[pos : pos+4])
+		size := int(binary.LittleEndian.Uint32(data[pos+4:]))
+		if size > len(data)-pos-8 {
+			return nil, errMalformed
+		}
+		// Chunks are padded to an even size, though the last one may not be.
+		end := min(pos+8+size+size%2, len(data))
+
+		chunk := append([]byte(nil), data[pos:end]...)
+		payload := chunk[8 : 8+size]
+		switch chunkType {
+		case "XMP ":
+			pos = end
+			continue
+		case "EXIF":
+			// Some encoders keep the JPEG style header.
+			if _, ok := stripTIFFLocation(bytes.TrimPrefix(payload, exifHeader)); !ok {
+				return nil, errMalformed
+			}
+		case "VP8X":
+			if size > 0 {
+				payload[0] &^= webpFlagXMP
+			}
+		}
+
+		out = append(out, chunk...)
+		pos = end
+	}
+
+	out = append(out, data[pos:]...)
+	binary.LittleEndian.PutUint32(out[4:], uint32(len(out)-8))
+	return out, nil
+}
+
+// stripTIFFLocation empties the GPS IFD of a TIFF structure in place and
+// returns the orientation from IFD0. It reports false if IFD0 or the GPS IFD
+// cannot be read, in which case the location may still be there.
+func stripTIFFLocation(tiff []byte) (int, bool) {
+	if len(tiff) < 8 {
+		return 1, false
+	}
+	var order binary.ByteOrder
+	switch string(tiff[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return 1, false
+	}
+
+	orientation := 1
+	ifd := int(order.Uint32(tiff[4:]))
+	entries, ok := ifdEntries(tiff, ifd, order)
+	if !ok {
+		return 1, false
+	}
+
+	for i := 0; i < entries; i++ {
+		entry := tiff[ifd+2+i*12:]
+		switch order.Uint16(entry) {
+		case tagOrientation:
+			if value := int(order.Uint16(entry[8:])); value >= 1 && value <= 8 {
+				orientation = value
+			}
+		case tagGPSInfo:
+			if !clearIFD(tiff, int(order.Uint32(entry[8:])), order) {
+				return 1, false
+			}
+		}
+	}
+	return orientation, true
+}
+
+// clearIFD zeroes every entry of an IFD, and the values they point to, and
+// leaves it with no entries. It reports false, having cleared nothing, if an
+// entry or its value cannot be found.
+func clearIFD(tiff []byte, ifd int, order binary.ByteOrder) bool {
+	entries, ok := ifdEntries(tiff, ifd, order)
+	if !ok {
+		return false
+	}
+
+	type span struct{ offset, size int }
+	var values []span
+	for i := 0; i < entries; i++ {
+		entry := tiff[ifd+2+i*12:]
+		typeSize := tiffTypeSize(order.Uint16(entry[2:]))
+		count := int(order.Uint32(entry[4:]))
+		if typeSize == 0 || count > len(tiff)/typeSize {
+			return false
+		}
+		if size := typeSize * count; size > 4 {
+			offset := int(order.Uint32(entry[8:]))
+			if offset < 0 || offset > len(tiff)-size {
+				return false
+			}
+			values = append(values, span{offset, size})
+		}
+	}
+
+	for _, value := range values {
+		clear(tiff[value.offset : value.offset+value.size])
+	}
+	clear(tiff[ifd : ifd+2+entries*12])
+	return true
+}
+
+func ifdEntries(tiff []byte, ifd int, order binary.ByteOrder) (int, bool) {
+	if ifd < 8 || ifd+2 > len(tiff) {
+		return 0, false
+	}
+	entries := int(order.Uint16(tiff[ifd:]))
+	if ifd+2+entries*12 > len(tiff) {
+		return 0, false
+	}
+	return entries, true
+}
+
+func tiffTypeSize(fieldType uint16) int {
+	switch fieldType {
+	case 1, 2, 6, 7:
+		return 1
+	case 3, 8:
+		return 2
+	case 4, 9, 11:
+		return 4
+	case 5, 10, 12:
+		return 8
+	}
+	return 0
+}
+
+// stripPNGMetadata drops the eXIf chunk and any XMP text chunk from a PNG.
+func stripPNGMetadata(data []byte) ([]byte, error) {
+	if !bytes.HasPrefix(data, pngMagic) {
+		return nil, errMalformed
+	}
+
+	out := append(make([]byte, 0, len(data)), pngMagic...)
+	pos := len(pngMagic)
+	for pos+12 <= len(data) {
+		length := int(binary.BigEndian.Uint32(data[pos:]))
+		end := pos + 12 + length
+		if length < 0 || end > len(data) {
+			return nil, errMalformed
+		}
+
+		chunkType := string(data[pos+4 : pos+8])
+		chunkData := data[pos+8 : pos+8+length]
+		drop := chunkType == "eXIf" ||
+			(chunkType == "iTXt" && bytes.HasPrefix(chunkData, []byte("XML:com.adobe.xmp\x00")))
+		if !drop {
+			out = append(out, data[pos:end]...)
+		}
+		pos = end
+	}
+	return append(out, data[pos:]...), nil
+}
@@ -0,0 +1,179 @@
+package media
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"testing"
+)
+
+// gpsTIFF is a big endian TIFF structure whose IFD0 points at a GPS IFD with
+// one ASCII value, "secret location".
+func gpsTIFF() []byte {
+	tiff := []byte("MM\x00\x2A\x00\x00\x00\x08")
+	tiff = append(tiff, 0, 1)
+	tiff = append(tiff, 0x88, 0x25, 0, 4, 0, 0, 0, 1, 0, 0, 0, 26)
+	tiff = append(tiff, 0, 0, 0, 0)
+	tiff = append(tiff, 0, 1)
+	tiff = append(tiff, 0, 2, 0, 2, 0, 0, 0, 16, 0, 0, 0, 44)
+	tiff = append(tiff, 0, 0, 0, 0)
+	return append(tiff, "secret location\x00"...)
+}
+
+func riffChunk(chunkType string, payload []byte) []byte {
+	chunk := append([]byte(chunkType), binary.LittleEndian.AppendUint32(nil, uint32(len(payload)))...)
+	chunk = append(chunk, payload...)
+	if len(payload)%2 == 1 {
+		chunk = append(chunk, 0)
+	}
+	return chunk
+}
+
+func webpFile(chunks ...[]byte) []byte {
+	data := []byte("RIFF\x00\x00\x00\x00WEBP")
+	for _, chunk := range chunks {
+		data = append(data, chunk...)
+	}
+	binary.LittleEndian.PutUint32(data[4:], uint32(len(data)-8))
+	return data
+}
+
+func TestStripWebPLocation(t *testing.T) {
+	const flagEXIF = 0x08
+	vp8x := []byte{flagEXIF | webpFlagXMP, 0, 0, 0, 9, 0, 0, 9, 0, 0}
+	data := webpFile(
+		riffChunk("VP8X", vp8x),
+		riffChunk("VP8L", []byte("odd sized image data")[:19]),
+		riffChunk("EXIF", gpsTIFF()),
+		riffChunk("XMP ", []byte("<x:xmpmeta>secret location</x:xmpmeta>")),
+	)
+	original := append([]byte(nil), data...)
+
+	stripped, err := stripWebPLocation(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(data, original) {
+		t.Fatal("the input was modified")
+	}
+	if bytes.Contains(stripped, []byte("secret location")) {
+		t.Fatal("location survived")
+	}
+	if bytes.Contains(stripped, []byte("XMP ")) {
+		t.Fatal("XMP chunk kept")
+	}
+	if flags := stripped[20]; flags != flagEXIF {
+		t.Fatalf("VP8X flags %#x, want only EXIF", flags)
+	}
+	if size := binary.LittleEndian.Uint32(stripped[4:]); int(size) != len(stripped)-8 {
+		t.Fatalf("RIFF size %d for %d bytes", size, len(stripped))
+	}
+	if !bytes.Contains(stripped, []byte("odd sized image data")[:19]) {
+		t.Fatal("image data lost")
+	}
+}
+
+func TestStripWebPLocationRejectsBrokenFiles(t *testing.T) {
+	data := webpFile(riffChunk("EXIF", gpsTIFF()))
+	binary.LittleEndian.PutUint32(data[16:], 1<<20)
+
+	if _, err := stripWebPLocation(data); !errors.Is(err, errMalformed) {
+		t.Fatalf("a chunk running past the end of the file: got %v, want errMalformed", err)
+	}
+}
+
+// jpegFile is a JPEG with an EXIF segment holding tiff, and a scan.
+func jpegFile(tiff []byte) []byte {
+	app1 := append(append([]byte(nil), exifHeader...), tiff...)
+	data := []byte{0xFF, 0xD8, 0xFF, 0xE1}
+	data = binary.BigEndian.AppendUint16(data, uint16(len(app1)+2))
+	data = append(data, app1...)
+	return append(data, 0xFF, 0xDA, 0, 2, 1, 2, 3, 0xFF, 0xD9)
+}
+
+func TestStripJPEGLocation(t *testing.T) {
+	data := jpegFile(gpsTIFF())
+
+	stripped, orientation, err := stripJPEGLocation(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if bytes.Contains(stripped, []byte("secret location")) {
+		t.Fatal("location survived")
+	}
+	if len(stripped) != len(data) || orientation != 1 {
+		t.Fatalf("got %d bytes, orientation %d; want %d, 1", len(stripped), orientation, len(data))
+	}
+}
+
+// Markers may be preceded by 0xFF fill bytes, which must not hide the EXIF
+// segment behind them.
+func TestStripJPEGLocationSkipsFillBytes(t *testing.T) {
+	plain := jpegFile(gpsTIFF())
+	data := append([]byte{0xFF, 0xD8, 0xFF, 0xFF, 0xFF}, plain[2:]...)
+	// A restart marker, which has no length, before the scan.
+	scan := bytes.Index(data, []byte{0xFF, 0xDA})
+	data = append(data[:scan:scan], append([]byte{0xFF, 0xFF, 0xD0}, data[scan:]...)...)
+
+	stripped, _, err := stripJPEGLocation(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(stripped, []byte("secret location")) {
+		t.Fatal("location survived")
+	}
+	if !bytes.HasSuffix(stripped, []byte{0xFF, 0xDA, 0, 2, 1, 2, 3, 0xFF, 0xD9}) {
+		t.Fatal("image data lost")
+	}
+}
+
+func TestStripJPEGLocationRejectsBrokenFiles(t *testing.T) {
+	// The GPS IFD offset, at byte 18 of the TIFF, points past the end.
+	badGPSOffset := gpsTIFF()
+	binary.BigEndian.PutUint32(badGPSOffset[18:], 1<<20)
+	// The GPS value has a type of unknown size, at byte 30, ...
+	badGPSType := gpsTIFF()
+	binary.BigEndian.PutUint16(badGPSType[30:], 0x7F)
+	// ... is longer than the TIFF, going by its count at byte 32, ...
+	badGPSCount := gpsTIFF()
+	binary.BigEndian.PutUint32(badGPSCount[32:], 1<<20)
+	// ... or is at an offset, at byte 36, past the end.
+	badGPSValue := gpsTIFF()
+	binary.BigEndian.PutUint32(badGPSValue[36:], 1<<20)
+
+	overrun := jpegFile(gpsTIFF())
+	binary.BigEndian.PutUint16(overrun[4:], 0xFFF0)
+
+	files := map[string][]byte{
+		"segment past the end": overrun,
+		"no scan":              jpegFile(gpsTIFF())[:len(jpegFile(gpsTIFF()))-9],
+		"byte between markers": append([]byte{0xFF, 0xD8, 0x00}, jpegFile(gpsTIFF())[2:]...),
+		"bad GPS offset":       jpegFile(badGPSOffset),
+		"bad GPS type":         jpegFile(badGPSType),
+		"bad GPS count":        jpegFile(badGPSCount),
+		"bad GPS value":        jpegFile(badGPSValue),
+	}
+	for name, data := range files {
+		t.Run(name, func(t *testing.T) {
+			if _, _, err := stripJPEGLocation(data); !errors.Is(err, errMalformed) {
+				t.Fatalf("got %v, want errMalformed", err)
+			}
+			if _, err := Process("image/jpeg", data); !errors.Is(err, ErrMalformedImage) {
+				t.Fatalf("Process: got %v, want ErrMalformedImage", err)
+			}
+		})
+	}
+}
+
+func TestStripPNGMetadataRejectsBrokenFiles(t *testing.T) {
+	data := append([]byte(nil), pngMagic...)
+	data = binary.BigEndian.AppendUint32(data, 1<<20)
+	data = append(data, "eXIf"...)
+	data = append(data, gpsTIFF()...)
+
+	if _, err := stripPNGMetadata(data); !errors.Is(err, errMalformed) {
+		t.Fatalf("a chunk running past the end of the file: got %v, want errMalformed", err)
+	}
+}
@@ -0,0 +1,115 @@
+package media
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/draw"
+	_ "image/gif"
+	"image/jpeg"
+	_ "image/png"
+
+	xdraw "golang.org/x/image/draw"
+	_ "golang.org/x/image/webp"
+)
+
+// processImage removes location data from an image and makes its thumbnail.
+// An image whose location cannot be removed is ErrMalformedImage.
+func processImage(result *Result, mimeType string) error {
+	orientation := 1
+	var err error
+	switch mimeType {
+	case "image/jpeg":
+		result.Data, orientation, err = stripJPEGLocation(result.Data)
+	case "image/png":
+		result.Data, err = stripPNGMetadata(result.Data)
+	case "image/webp":
+		result.Data, err = stripWebPLocation(result.Data)
+	}
+	if err != nil {
+		return ErrMalformedImage
+	}
+
+	config, _, err := image.DecodeConfig(bytes.NewReader(result.Data))
+	if err != nil || config.Width*config.Height > MaxImagePixels {
+		return nil
+	}
+	img, _, err := image.Decode(bytes.NewReader(result.Data))
+	if err != nil {
+		return nil
+	}
+
+	result.Width, result.Height = config.Width, config.Height
+	if orientation >= 5 {
+		result.Width, result.Height = result.Height, result.Width
+	}
+
+	thumb := orient(scaleDown(img, ThumbnailSize), orientation)
+
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, thumb, &jpeg.Options{Quality: 75}); err != nil {
+		return nil
+	}
+	result.Thumbnail = buf.Bytes()
+	result.ThumbnailWidth = thumb.Bounds().Dx()
+	result.ThumbnailHeight = thumb.Bounds().Dy()
+	result.Blurhash = encodeBlurhash(thumb, 4, 3)
+	return nil
+}
+
+// scaleDown fits img within size×size on a white background, which is what
+// transparent areas become in a JPEG. Smaller images are not enlarged.
+func scaleDown(img image.Image, size int) *image.RGBA {
+	bounds := img.Bounds()
+	w, h := bounds.Dx(), bounds.Dy()
+	if w > size || h > size {
+		if w >= h {
+			w, h = size, max(1, h*size/w)
+		} else {
+			w, h = max(1, w*size/h), size
+		}
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, w, h))
+	draw.Draw(dst, dst.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+	xdraw.ApproxBiLinear.Scale(dst, dst.Bounds(), img, bounds, draw.Over, nil)
+	return dst
+}
+
+// orient applies an EXIF orientation so the image displays upright.
+func orient(img *image.RGBA, orientation int) *image.RGBA {
+	if orientation < 2 || orientation > 8 {
+		return img
+	}
+
+	w, h := img.Bounds().Dx(), img.Bounds().Dy()
+	dw, dh := w, h
+	if orientation >= 5 {
+		dw, dh = h, w
+	}
+	dst := image.NewRGBA(image.Rect(0, 0, dw, dh))
+
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			var dx, dy int
+			switch orientation {
+			case 2:
+				dx, dy = w-1-x, y
+			case 3:
+				dx, dy = w-1-x, h-1-y
+			case 4:
+				dx, dy = x, h-1-y
+			case 5:
+				dx, dy = y, x
+			case 6:
+				dx, dy = h-1-y, x
+			case 7:
+				dx, dy = h-1-y, w-1-x
+			case 8:
+				dx, dy = y, w-1-x
+			}
+			dst.SetRGBA(dx, dy, img.RGBAAt(x, y))
+		}
+	}
+	return dst
+}
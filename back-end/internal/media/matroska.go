@@ -0,0 +1,156 @@
+package media
+
+import (
+	"encoding/binary"
+	"math"
+)
+
+// Matroska and WebM element IDs, including their length marker bits.
+const (
+	ebmlSegment       = 0x18538067
+	ebmlInfo          = 0x1549A966
+	ebmlTimecodeScale = 0x2AD7B1
+	ebmlDuration      = 0x4489
+	ebmlTracks        = 0x1654AE6B
+	ebmlTrackEntry    = 0xAE
+	ebmlVideo         = 0xE0
+	ebmlPixelWidth    = 0xB0
+	ebmlPixelHeight   = 0xBA
+	ebmlCluster       = 0x1F43B675
+)
+
+// probeMatroska reads the duration and the first video track's size from a
+// WebM or Matroska file. Recordings made by browsers often carry no
+// duration; it is zero then.
+func probeMatroska(data []byte) (width, height int, duration float64, err error) {
+	segment, ok := findElement(data, ebmlSegment)
+	if !ok {
+		return 0, 0, 0, errMalformed
+	}
+
+	timecodeScale := uint64(1_000_000)
+	var units float64
+	eachElement(segment, func(id uint64, body []byte) bool {
+		switch id {
+		case ebmlInfo:
+			eachElement(body, func(id uint64, body []byte) bool {
+				switch id {
+				case ebmlTimecodeScale:
+					timecodeScale = readUint(body)
+				case ebmlDuration:
+					units = readFloat(body)
+				}
+				return true
+			})
+		case ebmlTracks:
+			eachElement(body, func(id uint64, entry []byte) bool {
+				if id != ebmlTrackEntry {
+					return true
+				}
+				if video, ok := findElement(entry, ebmlVideo); ok {
+					if value, ok := findElement(video, ebmlPixelWidth); ok {
+						width = int(readUint(value))
+					}
+					if value, ok := findElement(video, ebmlPixelHeight); ok {
+						height = int(readUint(value))
+					}
+				}
+				return width == 0
+			})
+		case ebmlCluster:
+			// Media data follows, and live recordings give clusters no size.
+			return false
+		}
+		return true
+	})
+
+	return width, height, units * float64(timecodeScale) / 1e9, nil
+}
+
+func findElement(data []byte, want uint64) ([]byte, bool) {
+	var found []byte
+	eachElement(data, func(id uint64, body []byte) bool {
+		if id == want {
+			found = body
+			return false
+		}
+		return true
+	})
+	return found, found != nil
+}
+
+// eachElement calls fn with each EBML element in data until fn returns false.
+// An element of unknown size runs to the end of data.
+func eachElement(data []byte, fn func(id uint64, body []byte) bool) {
+	for len(data) > 0 {
+		id, idLen := readVint(data, true)
+		if idLen == 0 {
+			return
+		}
+		size, sizeLen := readVint(data[idLen:], false)
+		if sizeLen == 0 {
+			return
+		}
+
+		start := idLen + sizeLen
+		end := uint64(len(data))
+		if size != math.MaxUint64 && size <= end-uint64(start) {
+			end = uint64(start) + size
+		}
+
+		if !fn(id, data[start:end]) {
+			return
+		}
+		data = data[end:]
+	}
+}
+
+// readVint reads an EBML variable length integer. IDs keep their length
+// marker; sizes with every value bit set are unknown and come back as
+// math.MaxUint64.
+func readVint(data []byte, keepMarker bool) (uint64, int) {
+	if len(data) == 0 || data[0] == 0 {
+		return 0, 0
+	}
+
+	length := 1
+	for mask := byte(0x80); data[0]&mask == 0; mask >>= 1 {
+		length++
+	}
+	if length > 8 || length > len(data) {
+		return 0, 0
+	}
+
+	value := uint64(data[0])
+	if !keepMarker {
+		value &= uint64(0xFF >> length)
+	}
+	allOnes := value == uint64(0xFF>>length)
+	for _, b := range data[1:length] {
+		value = value<<8 | uint64(b)
+		allOnes = allOnes && b == 0xFF
+	}
+
+	if !keepMarker && allOnes {
+		return math.MaxUint64, length
+	}
+	return value, length
+}
+
+func readUint(data []byte) uint64 {
+	var value uint64
+	for _, b := range data {
+		value = value<<8 | uint64(b)
+	}
+	return value
+}
+
+func readFloat(data []byte) float64 {
+	switch len(data) {
+	case 4:
+		return float64(math.Float32frombits(binary.BigEndian.Uint32(data)))
+	case 8:
+		return math.Float64frombits(binary.BigEndian.Uint64(data))
+	}
+	return 0
+}
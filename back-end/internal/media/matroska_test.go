@@ -0,0 +1,88 @@
+package media
+
+import (
+	"encoding/binary"
+	"errors"
+	"math"
+	"testing"
+)
+
+// ebmlElement encodes an element with a one byte size, enough for tests.
+func ebmlElement(id uint64, body ...[]byte) []byte {
+	var element []byte
+	for shift := 24; shift >= 0; shift -= 8 {
+		if b := byte(id >> shift); b != 0 || len(element) > 0 {
+			element = append(element, b)
+		}
+	}
+	size := 0
+	for _, b := range body {
+		size += len(b)
+	}
+	element = append(element, 0x80|byte(size))
+	for _, b := range body {
+		element = append(element, b...)
+	}
+	return element
+}
+
+func webmFile(seconds float64, width, height byte) []byte {
+	duration := binary.BigEndian.AppendUint64(nil, math.Float64bits(seconds*1000))
+	return ebmlElement(ebmlSegment,
+		ebmlElement(ebmlInfo,
+			ebmlElement(ebmlTimecodeScale, []byte{0x0F, 0x42, 0x40}),
+			ebmlElement(ebmlDuration, duration),
+		),
+		ebmlElement(ebmlTracks,
+			ebmlElement(ebmlTrackEntry,
+				ebmlElement(ebmlVideo,
+					ebmlElement(ebmlPixelWidth, []byte{width}),
+					ebmlElement(ebmlPixelHeight, []byte{height}),
+				),
+			),
+		),
+	)
+}
+
+func TestProbeMatroska(t *testing.T) {
+	width, height, duration, err := probeMatroska(webmFile(4.5, 160, 90))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if width != 160 || height != 90 || math.Abs(duration-4.5) > 1e-9 {
+		t.Fatalf("got %dx%d, %vs; want 160x90, 4.5s", width, height, duration)
+	}
+}
+
+func TestProbeMatroskaRejectsMalformedFiles(t *testing.T) {
+	tests := map[string][]byte{
+		"empty":          nil,
+		"no segment":     ebmlElement(ebmlInfo),
+		"zero byte id":   {0x00, 0x18, 0x53, 0x80, 0x67},
+		"id too long":    {0x01, 0x02, 0x03},
+		"truncated size": {0x18, 0x53, 0x80, 0x67, 0x40},
+		"truncated id":   {0x18, 0x53},
+		"nine byte vint": {0x18, 0x53, 0x80, 0x67, 0x00, 0xff},
+	}
+	for name, data := range tests {
+		t.Run(name, func(t *testing.T) {
+			width, height, duration, err := probeMatroska(data)
+			if !errors.Is(err, errMalformed) || width != 0 || height != 0 || duration != 0 {
+				t.Fatalf("got %dx%d, %v, %v; want errMalformed and no metadata", width, height, duration, err)
+			}
+		})
+	}
+}
+
+// Browser recordings are live streams: the segment has an unknown size and
+// no duration.
+func TestProbeMatroskaLiveRecording(t *testing.T) {
+	data := []byte{0x18, 0x53, 0x80, 0x67, 0xFF}
+	data = append(data, ebmlElement(ebmlTracks, ebmlElement(ebmlTrackEntry, ebmlElement(ebmlVideo, ebmlElement(ebmlPixelWidth, []byte{64}))))...)
+	data = append(data, 0x1F, 0x43, 0xB6, 0x75, 0xFF, 0xA3, 0x81, 0x00)
+
+	width, _, duration, err := probeMatroska(data)
+	if err != nil || width != 64 || duration != 0 {
+		t.Fatalf("got width %d, %v, %v; want 64, 0, nil", width, duration, err)
+	}
+}
@@ -0,0 +1,67 @@
+// Package media extracts metadata from uploaded files and builds the
+// variants clients use to render them, without any non-Go dependencies.
+package media
+
+import (
+	"errors"
+	"strings"
+)
+
+const (
+	// ThumbnailSize is the longest side of a thumbnail, in pixels.
+	ThumbnailSize     = 320
+	ThumbnailMimeType = "image/jpeg"
+	// MaxImagePixels guards against decompression bombs. Larger images are
+	// stored as they are, without a thumbnail.
+	MaxImagePixels = 50_000_000
+)
+
+// errMalformed is returned by the probes for a file whose structure is
+// broken, as opposed to one that is sound but lacks what they look for.
+var errMalformed = errors.New("malformed media file")
+
+// ErrMalformedImage is returned by Process for an image too broken to remove
+// its location data from. It must not be stored.
+var ErrMalformedImage = errors.New("malformed image")
+
+// Result is what Process learned about a file. Fields it could not work out
+// are left zero.
+type Result struct {
+	// Data is the file to store. For images it has location data removed.
+	Data     []byte
+	Width    int
+	Height   int
+	Duration float64 // seconds
+	Blurhash string
+
+	Thumbnail       []byte
+	ThumbnailWidth  int
+	ThumbnailHeight int
+}
+
+// Process inspects a file of the given MIME type. Audio and video it cannot
+// parse, which the probes report as errMalformed, comes back unchanged with
+// no metadata. Images are the exception: one that cannot be stripped of its
+// location fails with ErrMalformedImage rather than being passed through.
+func Process(mimeType string, data []byte) (Result, error) {
+	result := Result{Data: data}
+
+	switch {
+	case strings.HasPrefix(mimeType, "image/"):
+		if err := processImage(&result, mimeType); err != nil {
+			return Result{}, err
+		}
+	case mimeType == "video/mp4" || mimeType == "audio/mp4" || mimeType == "video/quicktime":
+		result.Width, result.Height, result.Duration, _ = probeMP4(data)
+	case mimeType == "video/webm" || mimeType == "audio/webm":
+		result.Width, result.Height, result.Duration, _ = probeMatroska(data)
+	case mimeType == "audio/mpeg":
+		result.Duration, _ = probeMP3(data)
+	case mimeType == "audio/ogg":
+		result.Duration, _ = probeOgg(data)
+	case mimeType == "audio/wave" || mimeType == "audio/wav":
+		result.Duration, _ = probeWAV(data)
+	}
+
+	return result, nil
+}
@@ -0,0 +1,54 @@
+package media
+
+import (
+	"bytes"
+	"image"
+	"image/png"
+	"testing"
+)
+
+func pngFile(t *testing.T) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewGray(image.Rect(0, 0, 8, 8))); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// Uploads are untrusted, so no cut or corrupted file may make Process panic.
+// No cut image it accepts may still hold the location.
+func TestProcessSurvivesMalformedFiles(t *testing.T) {
+	jpeg := append([]byte{0xFF, 0xD8, 0xFF, 0xE1, 0, byte(len(exifHeader) + len(gpsTIFF()) + 2)}, exifHeader...)
+	jpeg = append(jpeg, gpsTIFF()...)
+
+	files := map[string][]byte{
+		"audio/mpeg": mp3File(10),
+		"audio/ogg":  opusFile(1),
+		"audio/wave": wavFile(8000, 16),
+		"video/mp4":  mp4File(1, 64, 48),
+		"video/webm": webmFile(1, 64, 48),
+		"image/jpeg": jpeg,
+		"image/png":  pngFile(t),
+		"image/webp": webpFile(riffChunk("VP8X", make([]byte, 10)), riffChunk("EXIF", gpsTIFF())),
+	}
+
+	for mimeType, data := range files {
+		t.Run(mimeType, func(t *testing.T) {
+			for n := range len(data) {
+				result, err := Process(mimeType, data[:n])
+				if err == nil && bytes.Contains(result.Data, []byte("secret location")) {
+					t.Fatalf("location kept in the first %d bytes", n)
+				}
+			}
+			for i := range data {
+				for _, value := range []byte{0x00, 0x7F, 0xFF} {
+					corrupt := append([]byte(nil), data...)
+					corrupt[i] = value
+					Process(mimeType, corrupt)
+				}
+			}
+		})
+	}
+}
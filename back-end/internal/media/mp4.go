@@ -0,0 +1,91 @@
+package media
+
+import "encoding/binary"
+
+// probeMP4 reads the duration from the movie header and the display size of
+// the first video track of an MP4 or QuickTime file.
+func probeMP4(data []byte) (width, height int, duration float64, err error) {
+	moov, ok := findBox(data, "moov")
+	if !ok {
+		return 0, 0, 0, errMalformed
+	}
+
+	if mvhd, ok := findBox(moov, "mvhd"); ok && len(mvhd) >= 32 {
+		var timescale uint32
+		var units uint64
+		if mvhd[0] == 1 {
+			timescale = binary.BigEndian.Uint32(mvhd[20:])
+			units = binary.BigEndian.Uint64(mvhd[24:])
+		} else {
+			timescale = binary.BigEndian.Uint32(mvhd[12:])
+			units = uint64(binary.BigEndian.Uint32(mvhd[16:]))
+		}
+		if timescale > 0 {
+			duration = float64(units) / float64(timescale)
+		}
+	}
+
+	eachBox(moov, func(boxType string, trak []byte) bool {
+		if boxType != "trak" {
+			return true
+		}
+		tkhd, ok := findBox(trak, "tkhd")
+		if !ok || len(tkhd) < 84 {
+			return true
+		}
+		offset := 76
+		if tkhd[0] == 1 {
+			offset = 88
+		}
+		if len(tkhd) < offset+8 {
+			return true
+		}
+		// Fixed point 16.16.
+		width = int(binary.BigEndian.Uint32(tkhd[offset:]) >> 16)
+		height = int(binary.BigEndian.Uint32(tkhd[offset+4:]) >> 16)
+		return width == 0
+	})
+
+	return width, height, duration, nil
+}
+
+// findBox returns the payload of the first box of the given type directly
+// inside data.
+func findBox(data []byte, want string) ([]byte, bool) {
+	var found []byte
+	eachBox(data, func(boxType string, payload []byte) bool {
+		if boxType == want {
+			found = payload
+			return false
+		}
+		return true
+	})
+	return found, found != nil
+}
+
+// eachBox calls fn with the type and payload of each box in data until fn
+// returns false.
+func eachBox(data []byte, fn func(boxType string, payload []byte) bool) {
+	for len(data) >= 8 {
+		size := uint64(binary.BigEndian.Uint32(data))
+		header := uint64(8)
+		switch size {
+		case 0:
+			size = uint64(len(data))
+		case 1:
+			if len(data) < 16 {
+				return
+			}
+			size = binary.BigEndian.Uint64(data[8:])
+			header = 16
+		}
+		if size < header || size > uint64(len(data)) {
+			return
+		}
+
+		if !fn(string(data[4:8]), data[header:size]) {
+			return
+		}
+		data = data[size:]
+	}
+}
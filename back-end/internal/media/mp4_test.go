@@ -0,0 +1,81 @@
+package media
+
+import (
+	"encoding/binary"
+	"errors"
+	"testing"
+)
+
+func mp4Box(boxType string, payload ...[]byte) []byte {
+	size := 8
+	for _, p := range payload {
+		size += len(p)
+	}
+	box := binary.BigEndian.AppendUint32(nil, uint32(size))
+	box = append(box, boxType...)
+	for _, p := range payload {
+		box = append(box, p...)
+	}
+	return box
+}
+
+// mp4File is an MP4 file lasting seconds with one video track of the given
+// size.
+func mp4File(seconds float64, width, height int) []byte {
+	mvhd := make([]byte, 100)
+	binary.BigEndian.PutUint32(mvhd[12:], 1000)
+	binary.BigEndian.PutUint32(mvhd[16:], uint32(seconds*1000))
+
+	tkhd := make([]byte, 84)
+	binary.BigEndian.PutUint32(tkhd[76:], uint32(width)<<16)
+	binary.BigEndian.PutUint32(tkhd[80:], uint32(height)<<16)
+
+	return append(
+		mp4Box("ftyp", []byte("isom\x00\x00\x02\x00")),
+		mp4Box("moov", mp4Box("mvhd", mvhd), mp4Box("trak", mp4Box("tkhd", tkhd)))...,
+	)
+}
+
+func TestProbeMP4(t *testing.T) {
+	width, height, duration, err := probeMP4(mp4File(3.25, 1280, 720))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if width != 1280 || height != 720 || duration != 3.25 {
+		t.Fatalf("got %dx%d, %vs; want 1280x720, 3.25s", width, height, duration)
+	}
+}
+
+func TestProbeMP4RejectsMalformedFiles(t *testing.T) {
+	oversized := mp4File(1, 640, 480)
+	binary.BigEndian.PutUint32(oversized[16:], 1<<30)
+
+	largeSize := mp4Box("moov")
+	binary.BigEndian.PutUint32(largeSize, 1)
+
+	tests := map[string][]byte{
+		"empty":             nil,
+		"no moov":           mp4Box("ftyp", []byte("isom")),
+		"box past end":      oversized,
+		"truncated 64 bit":  largeSize,
+		"size under header": {0, 0, 0, 4, 'm', 'o', 'o', 'v'},
+	}
+	for name, data := range tests {
+		t.Run(name, func(t *testing.T) {
+			width, height, duration, err := probeMP4(data)
+			if !errors.Is(err, errMalformed) || width != 0 || height != 0 || duration != 0 {
+				t.Fatalf("got %dx%d, %v, %v; want errMalformed and no metadata", width, height, duration, err)
+			}
+		})
+	}
+}
+
+// A moov box too short to hold its headers is not an error, just a file
+// with nothing to report.
+func TestProbeMP4ShortHeaders(t *testing.T) {
+	data := mp4Box("moov", mp4Box("mvhd", make([]byte, 8)), mp4Box("trak", mp4Box("tkhd", make([]byte, 20))))
+	width, height, duration, err := probeMP4(data)
+	if err != nil || width != 0 || height != 0 || duration != 0 {
+		t.Fatalf("got %dx%d, %v, %v", width, height, duration, err)
+	}
+}
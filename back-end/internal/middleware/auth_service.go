@@ -1,38 +1,46 @@
 package middleware
 
 import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
 	"time"
 
-	"github.com/golang-jwt/jwt"
+	"github.com/golang-jwt/jwt/v5"
 )
 
-func Create_Refresh_Token(id, username, number string) (string, error) {
-	token := jwt.NewWithClaims(jwt.SigningMethodHS512,
-		jwt.MapClaims{
-			"id":       id,
-			"username": username,
-			"number":   number,
-			"token_type": "refresh",
-			"exp":      time.Now().Add(time.Hour * 24 *7).Unix(),
-		})
-
-	tokenString, err := token.SignedString(SecretKey)
+const (
+	AccessTokenLifetime  = 30 * time.Minute
+	RefreshTokenLifetime = 7 * 24 * time.Hour
+)
+
+// Create_Refresh_Token returns a refresh token for the session and when it
+// expires. Each one carries a random jti, so no two tokens are alike.
+func Create_Refresh_Token(id, username, number, sessionID string) (string, time.Time, error) {
+	expiresAt := time.Now().Add(RefreshTokenLifetime)
+	tokenString, err := Keys.Sign(jwt.MapClaims{
+		"id":         id,
+		"username":   username,
+		"number":     number,
+		"sid":        sessionID,
+		"jti":        randomHex(16),
+		"token_type": "refresh",
+		"exp":        expiresAt.Unix(),
+	})
 	if err != nil {
-		return "", err
+		return "", time.Time{}, err
 	}
 
-	return tokenString, nil
-}	
-
-func Create_Access_Token(id string) (string, error) {
-	token := jwt.NewWithClaims(jwt.SigningMethodHS512,
-		jwt.MapClaims{
-			"id": id,
-			"token_type": "Access",
-			"exp": time.Now().Add(time.Minute * 30).Unix(),
-		})
+	return tokenString, expiresAt, nil
+}
 
-	tokenString, err := token.SignedString(SecretKey)
+func Create_Access_Token(id, sessionID string) (string, error) {
+	tokenString, err := Keys.Sign(jwt.MapClaims{
+		"id":         id,
+		"sid":        sessionID,
+		"token_type": "Access",
+		"exp":        time.Now().Add(AccessTokenLifetime).Unix(),
+	})
 	if err != nil {
 		return "", err
 	}
@@ -40,3 +48,21 @@ func Create_Access_Token(id string) (string, error) {
 	return tokenString, nil
 }
 
+func NewSessionID() string {
+	return randomHex(16)
+}
+
+// HashToken is how refresh tokens are stored, so a database leak does not
+// leak usable tokens.
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+func randomHex(n int) string {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	return hex.EncodeToString(b)
+}
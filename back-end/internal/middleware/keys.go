@@ -0,0 +1,277 @@
+package middleware
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Keys signs and verifies every token the back-end issues.
+var Keys *KeySet
+
+// SigningKey is one key of a KeySet. Public is nil for HMAC secrets, which
+// never leave the server.
+type SigningKey struct {
+	ID      string
+	Method  jwt.SigningMethod
+	Private any
+	Public  crypto.PublicKey
+}
+
+// KeySet holds the key new tokens are signed with and every key tokens are
+// still accepted from. Rotating is a matter of adding the new key, making it
+// the signing key once every verifier has fetched it, and dropping the old
+// one after the longest token lifetime has passed.
+type KeySet struct {
+	signing *SigningKey
+	keys    map[string]*SigningKey
+}
+
+// NewKeySetFromEnv loads the signing keys from, in order of preference:
+//
+//   - JWT_KEYS_DIR, a directory of PEM files named <kid>.pem. Private keys
+//     (RSA or Ed25519) can sign; public keys are only accepted for
+//     verification, which is how a retired key is kept until its tokens
+//     expire. JWT_SIGNING_KEY_ID picks the signing key, by default the
+//     private key whose ID sorts last.
+//   - JWT_PRIVATE_KEY, a single PEM encoded private key, or
+//     JWT_PRIVATE_KEY_FILE, the path to one. JWT_KEY_ID names it.
+//   - JWT_SECRET, a shared HS512 secret. Every verifier then needs the secret,
+//     and the JWKS endpoint publishes nothing.
+//
+// With none of these set it fails, unless JWT_ALLOW_EPHEMERAL_KEY is "true":
+// then a throwaway Ed25519 key is generated, which is fine for development
+// but logs everyone out on restart and cannot be shared between replicas.
+func NewKeySetFromEnv() (*KeySet, error) {
+	if dir := os.Getenv("JWT_KEYS_DIR"); dir != "" {
+		return loadKeyDir(dir, os.Getenv("JWT_SIGNING_KEY_ID"))
+	}
+
+	keyPEM := []byte(os.Getenv("JWT_PRIVATE_KEY"))
+	if path := os.Getenv("JWT_PRIVATE_KEY_FILE"); len(keyPEM) == 0 && path != "" {
+		var err error
+		if keyPEM, err = os.ReadFile(path); err != nil {
+			return nil, err
+		}
+	}
+	if len(keyPEM) > 0 {
+		key, err := parseKey(os.Getenv("JWT_KEY_ID"), keyPEM)
+		if err != nil {
+			return nil, err
+		}
+		if key.Private == nil {
+			return nil, errors.New("JWT_PRIVATE_KEY holds a public key")
+		}
+		return NewKeySet(key, key)
+	}
+
+	if secret := os.Getenv("JWT_SECRET"); secret != "" {
+		key := &SigningKey{ID: "hs512", Method: jwt.SigningMethodHS512, Private: []byte(secret)}
+		return NewKeySet(key, key)
+	}
+
+	if os.Getenv("JWT_ALLOW_EPHEMERAL_KEY") != "true" {
+		return nil, errors.New("no JWT signing key configured: set JWT_KEYS_DIR, JWT_PRIVATE_KEY, JWT_PRIVATE_KEY_FILE or JWT_SECRET, or JWT_ALLOW_EPHEMERAL_KEY=true for development")
+	}
+	log.Println("⚠️ No JWT signing key configured, using a temporary one")
+	_, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	key := newSigningKey("", private)
+	return NewKeySet(key, key)
+}
+
+// NewKeySet signs with signing and accepts tokens from any of keys, which
+// must include it.
+func NewKeySet(signing *SigningKey, keys ...*SigningKey) (*KeySet, error) {
+	set := &KeySet{signing: signing, keys: make(map[string]*SigningKey, len(keys))}
+	for _, key := range keys {
+		if _, ok := set.keys[key.ID]; ok {
+			return nil, fmt.Errorf("duplicate JWT key ID %q", key.ID)
+		}
+		set.keys[key.ID] = key
+	}
+	if signing == nil || signing.Private == nil || set.keys[signing.ID] != signing {
+		return nil, errors.New("the JWT signing key must be one of the private keys")
+	}
+	return set, nil
+}
+
+// Sign returns a token for claims, with the signing key's ID in its kid
+// header.
+func (s *KeySet) Sign(claims jwt.Claims) (string, error) {
+	token := jwt.NewWithClaims(s.signing.Method, claims)
+	token.Header["kid"] = s.signing.ID
+	return token.SignedString(s.signing.Private)
+}
+
+// Keyfunc finds the key a token was signed with. Tokens must name a known
+// key and use that key's algorithm, so a public key can never be passed off
+// as an HMAC secret.
+func (s *KeySet) Keyfunc(token *jwt.Token) (any, error) {
+	kid, _ := token.Header["kid"].(string)
+	key, ok := s.keys[kid]
+	if !ok {
+		return nil, fmt.Errorf("unknown signing key %q", kid)
+	}
+	if token.Method.Alg() != key.Method.Alg() {
+		return nil, fmt.Errorf("unexpected signing method %s", token.Method.Alg())
+	}
+	if key.Public == nil {
+		return key.Private, nil
+	}
+	return key.Public, nil
+}
+
+// JWK is a public key in JSON Web Key form.
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	// RSA
+	N string `json:"n,omitempty"`
+	E string `json:"e,omitempty"`
+	// Ed25519
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+}
+
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// JWKS returns the public keys tokens are accepted from, signing key first.
+func (s *KeySet) JWKS() JWKS {
+	ids := make([]string, 0, len(s.keys))
+	for id := range s.keys {
+		if id != s.signing.ID {
+			ids = append(ids, id)
+		}
+	}
+	slices.Sort(ids)
+	ids = append([]string{s.signing.ID}, ids...)
+
+	set := JWKS{Keys: []JWK{}}
+	for _, id := range ids {
+		key := s.keys[id]
+		jwk := JWK{Kid: key.ID, Use: "sig", Alg: key.Method.Alg()}
+		switch public := key.Public.(type) {
+		case *rsa.PublicKey:
+			jwk.Kty = "RSA"
+			jwk.N = base64.RawURLEncoding.EncodeToString(public.N.Bytes())
+			jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(public.E)).Bytes())
+		case ed25519.PublicKey:
+			jwk.Kty = "OKP"
+			jwk.Crv = "Ed25519"
+			jwk.X = base64.RawURLEncoding.EncodeToString(public)
+		default:
+			continue
+		}
+		set.Keys = append(set.Keys, jwk)
+	}
+	return set
+}
+
+func loadKeyDir(dir, signingID string) (*KeySet, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.pem"))
+	if err != nil {
+		return nil, err
+	}
+
+	var keys []*SigningKey
+	var signing *SigningKey
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		key, err := parseKey(strings.TrimSuffix(filepath.Base(path), ".pem"), data)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		keys = append(keys, key)
+
+		if key.Private == nil {
+			continue
+		}
+		// Glob sorts, so without a configured ID the last private key wins.
+		if signingID == "" || key.ID == signingID {
+			signing = key
+		}
+	}
+
+	if signing == nil {
+		return nil, fmt.Errorf("no private JWT key %q in %s", signingID, dir)
+	}
+	return NewKeySet(signing, keys...)
+}
+
+// parseKey reads a PKCS #8 or PKCS #1 private key, or a PKIX public key. An
+// empty id is replaced by one derived from the public key.
+func parseKey(id string, data []byte) (*SigningKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("no PEM data found")
+	}
+
+	var parsed any
+	var err error
+	switch block.Type {
+	case "PRIVATE KEY":
+		parsed, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	case "RSA PRIVATE KEY":
+		parsed, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "PUBLIC KEY":
+		parsed, err = x509.ParsePKIXPublicKey(block.Bytes)
+	default:
+		return nil, fmt.Errorf("unsupported PEM block %q", block.Type)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	switch parsed.(type) {
+	case *rsa.PrivateKey, ed25519.PrivateKey, *rsa.PublicKey, ed25519.PublicKey:
+		return newSigningKey(id, parsed), nil
+	}
+	return nil, fmt.Errorf("unsupported key type %T, use RSA or Ed25519", parsed)
+}
+
+func newSigningKey(id string, key any) *SigningKey {
+	signingKey := &SigningKey{ID: id}
+	switch key := key.(type) {
+	case *rsa.PrivateKey:
+		signingKey.Method, signingKey.Private, signingKey.Public = jwt.SigningMethodRS256, key, &key.PublicKey
+	case *rsa.PublicKey:
+		signingKey.Method, signingKey.Public = jwt.SigningMethodRS256, key
+	case ed25519.PrivateKey:
+		signingKey.Method, signingKey.Private, signingKey.Public = jwt.SigningMethodEdDSA, key, key.Public()
+	case ed25519.PublicKey:
+		signingKey.Method, signingKey.Public = jwt.SigningMethodEdDSA, key
+	}
+
+	if signingKey.ID == "" {
+		der, _ := x509.MarshalPKIXPublicKey(signingKey.Public)
+		sum := sha256.Sum256(der)
+		signingKey.ID = hex.EncodeToString(sum[:8])
+	}
+	return signingKey
+}
@@ -0,0 +1,30 @@
+package middleware
+
+import "testing"
+
+func clearKeyEnv(t *testing.T) {
+	for _, name := range []string{"JWT_KEYS_DIR", "JWT_PRIVATE_KEY", "JWT_PRIVATE_KEY_FILE", "JWT_SECRET", "JWT_ALLOW_EPHEMERAL_KEY"} {
+		t.Setenv(name, "")
+	}
+}
+
+func TestNewKeySetFromEnvRequiresAKey(t *testing.T) {
+	clearKeyEnv(t)
+
+	if _, err := NewKeySetFromEnv(); err == nil {
+		t.Fatal("started without a signing key")
+	}
+}
+
+func TestNewKeySetFromEnvEphemeralKey(t *testing.T) {
+	clearKeyEnv(t)
+	t.Setenv("JWT_ALLOW_EPHEMERAL_KEY", "true")
+
+	keys, err := NewKeySetFromEnv()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(keys.JWKS().Keys) != 1 {
+		t.Fatalf("JWKS %+v, want the generated key", keys.JWKS())
+	}
+}
@@ -5,25 +5,35 @@ import (
 	"net/http"
 
 	"github.com/gin-gonic/gin"
-	"github.com/golang-jwt/jwt"
+	"github.com/golang-jwt/jwt/v5"
 )
 
-var SecretKey = []byte("secret-key")
+// CheckSession reports whether a token's session is still active. main points
+// it at the session store, which this package cannot import; when it is nil
+// tokens are trusted until they expire.
+var CheckSession func(sessionID string) (bool, error)
 
 func VerifyToken(tokenString string) error {
-	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-		return SecretKey, nil
-	})
+	_, err := parseToken(tokenString)
+	return err
+}
+
+func parseToken(tokenString string) (jwt.MapClaims, error) {
+	token, err := jwt.Parse(tokenString, Keys.Keyfunc)
 
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	if !token.Valid {
-		return fmt.Errorf("invalid token")
+		return nil, fmt.Errorf("invalid token")
 	}
 
-	return nil
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok {
+		return nil, fmt.Errorf("invalid token claims")
+	}
+	return claims, nil
 }
 
 func AuthMiddleware() gin.HandlerFunc {
@@ -36,11 +46,29 @@ func AuthMiddleware() gin.HandlerFunc {
 
 		tokenString = tokenString[len("Bearer "):]
 
-		if err := VerifyToken(tokenString); err != nil {
+		claims, err := parseToken(tokenString)
+		if err != nil {
 			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid token"})
 			return
 		}
 
+		if CheckSession != nil {
+			sessionID, _ := claims["sid"].(string)
+			if sessionID == "" {
+				c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid token", "details": "Log in again"})
+				return
+			}
+			active, err := CheckSession(sessionID)
+			if err != nil {
+				c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Database error", "details": err.Error()})
+				return
+			}
+			if !active {
+				c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Session ended", "details": "Log in again"})
+				return
+			}
+		}
+
 		c.Next()
 	}
 }
@@ -0,0 +1,101 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Attachment kinds, worked out from the MIME type.
+const (
+	AttachmentImage = "image"
+	AttachmentVoice = "voice"
+	AttachmentVideo = "video"
+	AttachmentFile  = "file"
+)
+
+// Upload statuses. A multipart upload is complete as soon as it is stored; a
+// chunked upload stays pending until its last chunk arrives.
+const (
+	UploadPending  = "pending"
+	UploadComplete = "complete"
+)
+
+// Attachment is an uploaded file. It belongs to the conversation it was
+// uploaded to and can be attached to one message there.
+type Attachment struct {
+	ID             primitive.ObjectID  `bson:"_id,omitempty" json:"id"`
+	UploaderID     string              `bson:"uploader_id" json:"uploader_id"`
+	ConversationID string              `bson:"conversation_id" json:"conversation_id"`
+	MessageID      *primitive.ObjectID `bson:"message_id,omitempty" json:"message_id,omitempty"`
+	FileName       string              `bson:"file_name" json:"file_name"`
+	MimeType       string              `bson:"mime_type" json:"mime_type"`
+	Kind           string              `bson:"kind" json:"kind"`
+	Size           int64               `bson:"size" json:"size"`
+	Checksum       string              `bson:"checksum,omitempty" json:"checksum,omitempty"`
+	Width          int                 `bson:"width,omitempty" json:"width,omitempty"`
+	Height         int                 `bson:"height,omitempty" json:"height,omitempty"`
+	Duration       float64             `bson:"duration,omitempty" json:"duration,omitempty"`
+	Blurhash       string              `bson:"blurhash,omitempty" json:"blurhash,omitempty"`
+	Thumbnail      *Attachment_Variant `bson:"thumbnail,omitempty" json:"thumbnail,omitempty"`
+	StorageKey     string              `bson:"storage_key" json:"-"`
+	Status         string              `bson:"status" json:"status"`
+	Received       int64               `bson:"received" json:"received"`
+	ChunkKeys      []string            `bson:"chunk_keys,omitempty" json:"-"`
+	CreatedAt      time.Time           `bson:"created_at" json:"created_at"`
+}
+
+// Attachment_Variant is another rendition of an attachment, such as the
+// thumbnail of an image, served with ?variant=<name> on download.
+type Attachment_Variant struct {
+	StorageKey string `bson:"storage_key" json:"-"`
+	MimeType   string `bson:"mime_type" json:"mime_type"`
+	Size       int64  `bson:"size" json:"size"`
+	Width      int    `bson:"width" json:"width"`
+	Height     int    `bson:"height" json:"height"`
+}
+
+// Message_Attachment is the attachment metadata copied onto a message. Width,
+// Height and Blurhash let clients lay out and fill the space for an image
+// before its thumbnail arrives.
+type Message_Attachment struct {
+	ID           primitive.ObjectID `bson:"id" json:"id"`
+	FileName     string             `bson:"file_name" json:"file_name"`
+	MimeType     string             `bson:"mime_type" json:"mime_type"`
+	Kind         string             `bson:"kind" json:"kind"`
+	Size         int64              `bson:"size" json:"size"`
+	Checksum     string             `bson:"checksum" json:"checksum"`
+	Width        int                `bson:"width,omitempty" json:"width,omitempty"`
+	Height       int                `bson:"height,omitempty" json:"height,omitempty"`
+	Duration     float64            `bson:"duration,omitempty" json:"duration,omitempty"`
+	Blurhash     string             `bson:"blurhash,omitempty" json:"blurhash,omitempty"`
+	HasThumbnail bool               `bson:"has_thumbnail,omitempty" json:"has_thumbnail"`
+}
+
+func (a *Attachment) MessageAttachment() *Message_Attachment {
+	return &Message_Attachment{
+		ID:           a.ID,
+		FileName:     a.FileName,
+		MimeType:     a.MimeType,
+		Kind:         a.Kind,
+		Size:         a.Size,
+		Checksum:     a.Checksum,
+		Width:        a.Width,
+		Height:       a.Height,
+		Duration:     a.Duration,
+		Blurhash:     a.Blurhash,
+		HasThumbnail: a.Thumbnail != nil,
+	}
+}
+
+// Start_Upload begins a chunked upload to Conversation_ID, or to the direct
+// conversation with Receiver_Number. Checksum, when given, is the SHA-256 of
+// the whole file in hex and is checked once the last chunk arrives.
+type Start_Upload struct {
+	Receiver_Number string `json:"receiver_number"`
+	Conversation_ID string `json:"conversation_id,omitempty"`
+	File_Name       string `json:"file_name" binding:"required,max=255"`
+	Mime_Type       string `json:"mime_type"`
+	Size            int64  `json:"size" binding:"required,gt=0"`
+	Checksum        string `json:"checksum,omitempty"`
+}
@@ -0,0 +1,14 @@
+package models
+
+import "time"
+
+type Block_User struct {
+	Number string `json:"number" binding:"required"`
+}
+
+type Blocked_User struct {
+	UserID    string    `json:"user_id"`
+	Username  string    `json:"username"`
+	Number    string    `json:"number"`
+	BlockedAt time.Time `json:"blocked_at"`
+}
@@ -1,18 +1,41 @@
 package models
 
-import "time"
+import (
+	"time"
 
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Chatlist_Item is one conversation in the chat list. Partner fields are set
+// for direct conversations and GroupName for groups.
 type Chatlist_Item struct {
-	PartnerID     string `json:"partner_id"`
-	PartnerName   string `json:"partner_name"`
-	PartnerNumber string `json:"partner_number"`
-	LastMessage   string `json:"last_message"`
-	LastMessageAt string `json:"last_message_at"`
+	ConversationID string `json:"conversation_id"`
+	IsGroup        bool   `json:"is_group"`
+	GroupName      string `json:"group_name,omitempty"`
+	PartnerID      string `json:"partner_id,omitempty"`
+	PartnerName    string `json:"partner_name,omitempty"`
+	PartnerNumber  string `json:"partner_number,omitempty"`
+	LastMessageID  string `json:"last_message_id"`
+	LastMessage    string `json:"last_message"`
+	LastMessageAt  string `json:"last_message_at"`
+	// LastMessageDeleted is set when the latest message was deleted for
+	// everyone; LastMessage is then empty.
+	LastMessageDeleted bool  `json:"last_message_deleted,omitempty"`
+	UnreadCount        int64 `json:"unread_count"`
+}
+
+// Chatlist_Page is one page of the chat list, newest conversation first. Pass
+// Next_Cursor back as "before" to get the next page.
+type Chatlist_Page struct {
+	Chats       []Chatlist_Item `json:"chats"`
+	Next_Cursor string          `json:"next_cursor,omitempty"`
 }
 
 type ChatPartner struct {
-	PartnerID     string
-	LastMessage   string
-	LastMessageAt time.Time
+	ConversationID     string             `bson:"_id"`
+	LastMessageID      primitive.ObjectID `bson:"last_message_id"`
+	LastMessage        string             `bson:"last_message"`
+	LastMessageAt      time.Time          `bson:"last_message_at"`
+	LastMessageDeleted bool               `bson:"last_message_deleted"`
+	UnreadCount        int64              `bson:"unread_count"`
 }
-	
\ No newline at end of file
@@ -0,0 +1,43 @@
+package models
+
+import "time"
+
+// Member roles, from most to least privileged.
+const (
+	RoleOwner  = "owner"
+	RoleAdmin  = "admin"
+	RoleMember = "member"
+)
+
+type Conversation struct {
+	ID        string                `json:"id"`
+	Name      string                `json:"name"`
+	CreatedBy string                `json:"created_by"`
+	CreatedAt time.Time             `json:"created_at"`
+	Members   []Conversation_Member `json:"members,omitempty"`
+}
+
+type Conversation_Member struct {
+	UserID   string    `json:"user_id"`
+	Username string    `json:"username"`
+	Number   string    `json:"number"`
+	Role     string    `json:"role"`
+	JoinedAt time.Time `json:"joined_at"`
+}
+
+type Create_Group struct {
+	Name           string   `json:"name" binding:"required,max=100"`
+	Member_Numbers []string `json:"member_numbers"`
+}
+
+type Rename_Group struct {
+	Name string `json:"name" binding:"required,max=100"`
+}
+
+type Group_Members struct {
+	Member_Numbers []string `json:"member_numbers" binding:"required,min=1"`
+}
+
+type Member_Role struct {
+	Role string `json:"role" binding:"required,oneof=admin member"`
+}
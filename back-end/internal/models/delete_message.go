@@ -0,0 +1,13 @@
+package models
+
+// Delete scopes. A message deleted for me is hidden from that user only; a
+// message deleted for everyone is replaced by a tombstone.
+const (
+	DeleteForMe       = "me"
+	DeleteForEveryone = "everyone"
+)
+
+type Delete_Message struct {
+	ID    string `json:"id" binding:"required"`
+	Scope string `json:"scope" binding:"required,oneof=me everyone"`
+}
@@ -1,5 +1,21 @@
 package models
 
+// Get_Message selects a page of Conversation_ID, or of the direct
+// conversation with Receiver_Number when no conversation ID is given. Before
+// and After are cursors: a message ID or an RFC 3339 timestamp. With neither
+// set the newest page is returned.
 type Get_Message struct {
 	Receiver_Number string `json:"receiver_number" bson:"receiver_number" `
+	Conversation_ID string `json:"conversation_id,omitempty" bson:"conversation_id,omitempty"`
+	Before          string `json:"before,omitempty" bson:"before,omitempty"`
+	After           string `json:"after,omitempty" bson:"after,omitempty"`
+	Limit           int64  `json:"limit,omitempty" bson:"limit,omitempty"`
+}
+
+// Message_Page is one page of a conversation in chronological order.
+// Next_Cursor is set when more messages exist in the direction requested and
+// should be passed back as Before (or After, when paging forward).
+type Message_Page struct {
+	Messages    []Save_Message `json:"messages"`
+	Next_Cursor string         `json:"next_cursor,omitempty"`
 }
@@ -0,0 +1,27 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Mark_Read marks Conversation_ID, or the direct conversation with
+// Partner_Number when no conversation ID is given, read up to Message_ID.
+type Mark_Read struct {
+	Partner_Number  string `json:"partner_number"`
+	Conversation_ID string `json:"conversation_id,omitempty"`
+	Message_ID      string `json:"message_id" binding:"required"`
+}
+
+// Read_Cursor records how far one member of a group has received and read
+// it. Group messages are shared by every member, so their delivered_at and
+// read_at cannot say who has seen them.
+type Read_Cursor struct {
+	ID             string             `bson:"_id"`
+	ConversationID string             `bson:"conversation_id"`
+	UserID         string             `bson:"user_id"`
+	DeliveredUpTo  primitive.ObjectID `bson:"delivered_up_to,omitempty"`
+	ReadUpTo       primitive.ObjectID `bson:"read_up_to,omitempty"`
+	UpdatedAt      time.Time          `bson:"updated_at"`
+}
@@ -0,0 +1,33 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Kinds of message changes relayed to the websocket service.
+const (
+	ChangeCreated = "created"
+	ChangeEdited  = "edited"
+	ChangeRead    = "read"
+	ChangeDeleted = "deleted"
+	ChangeReacted = "reacted"
+)
+
+// Message_Change stamps a message with the last change made through the REST
+// API. The websocket service watches this field to push the change to
+// connected clients.
+type Message_Change struct {
+	Kind string             `bson:"kind" json:"kind"`
+	ID   primitive.ObjectID `bson:"id" json:"id"`
+	At   time.Time          `bson:"at" json:"at"`
+}
+
+func NewMessageChange(kind string) Message_Change {
+	return Message_Change{
+		Kind: kind,
+		ID:   primitive.NewObjectID(),
+		At:   time.Now(),
+	}
+}
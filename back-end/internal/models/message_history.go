@@ -0,0 +1,31 @@
+package models
+
+import "time"
+
+// Message_Revision is one version of a message's text and when it was
+// written.
+type Message_Revision struct {
+	Message string    `bson:"message" json:"message"`
+	At      time.Time `bson:"at" json:"at"`
+}
+
+// Message_History lists every version of a message, oldest first. The last
+// revision is the current text.
+type Message_History struct {
+	MessageID string             `json:"message_id"`
+	Revisions []Message_Revision `json:"revisions"`
+}
+
+// History returns every version of the message, oldest first.
+func (m *Save_Message) History() Message_History {
+	revisions := make([]Message_Revision, 0, len(m.Revisions)+1)
+	revisions = append(revisions, m.Revisions...)
+
+	current := Message_Revision{Message: m.Message, At: m.CreatedAt}
+	if m.UpdatedTime != nil {
+		current.At = *m.UpdatedTime
+	}
+	revisions = append(revisions, current)
+
+	return Message_History{MessageID: m.ID.Hex(), Revisions: revisions}
+}
@@ -0,0 +1,23 @@
+package models
+
+import "time"
+
+type Send_Code struct {
+	Number string `json:"number" binding:"required,len=11"`
+}
+
+type Verify_Code struct {
+	Number string `json:"number" binding:"required,len=11"`
+	Code   string `json:"code" binding:"required,len=6,numeric"`
+}
+
+// OTP_Code is a one-time code as stored. Only its hash is kept. Attempts
+// counts the wrong codes tried for the number since AttemptsSince, whichever
+// code or account they were for.
+type OTP_Code struct {
+	Hash          string
+	Attempts      int
+	AttemptsSince time.Time
+	SentAt        time.Time
+	ExpiresAt     time.Time
+}
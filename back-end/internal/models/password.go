@@ -0,0 +1,20 @@
+package models
+
+type Change_Password struct {
+	Old_Password string `json:"old_password" binding:"required"`
+	New_Password string `json:"new_password" binding:"required,min=8,max=72"`
+}
+
+// Forgot_Password names the account as well as the number, since an old
+// number can belong to more than one account.
+type Forgot_Password struct {
+	Username string `json:"username" binding:"required"`
+	Number   string `json:"number" binding:"required,len=11"`
+}
+
+type Reset_Password struct {
+	Username     string `json:"username" binding:"required"`
+	Number       string `json:"number" binding:"required,len=11"`
+	Code         string `json:"code" binding:"required,len=6,numeric"`
+	New_Password string `json:"new_password" binding:"required,min=8,max=72"`
+}
@@ -0,0 +1,24 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Message_Reply is a snapshot of the quoted message taken when the reply is
+// sent. It keeps the quoted text even if the original is later edited or
+// deleted.
+type Message_Reply struct {
+	MessageID primitive.ObjectID `bson:"message_id" json:"message_id"`
+	SenderID  string             `bson:"sender_id" json:"sender_id"`
+	Message   string             `bson:"message" json:"message"`
+	CreatedAt time.Time          `bson:"created_at" json:"created_at"`
+}
+
+// Thread_Page is one page of replies in a group thread, in chronological
+// order, with the root message they reply to.
+type Thread_Page struct {
+	Root Save_Message `json:"root"`
+	Message_Page
+}
@@ -0,0 +1,62 @@
+package models
+
+import "time"
+
+// Report statuses. New reports wait in the moderation queue as open until a
+// moderator resolves or dismisses them.
+const (
+	ReportOpen      = "open"
+	ReportResolved  = "resolved"
+	ReportDismissed = "dismissed"
+)
+
+// ReportSnapshotSize is how many of the reported user's latest messages are
+// kept when a report names no messages.
+const ReportSnapshotSize = 20
+
+// Report_User reports Number for messages in Conversation_ID, or in the
+// direct conversation with them when no conversation is given. Message_IDs
+// picks the offending messages; without them the latest ones are taken.
+// Block also blocks the reported user.
+type Report_User struct {
+	Number          string   `json:"number" binding:"required"`
+	Conversation_ID string   `json:"conversation_id,omitempty"`
+	Message_IDs     []string `json:"message_ids" binding:"max=50"`
+	Reason          string   `json:"reason" binding:"required,oneof=spam harassment inappropriate other"`
+	Details         string   `json:"details" binding:"max=1000"`
+	Block           bool     `json:"block"`
+}
+
+// Report is an entry in the moderation queue. Messages is a copy taken when
+// the report was made, so it survives later edits and deletes.
+type Report struct {
+	ID             string             `json:"id"`
+	ReporterID     string             `json:"reporter_id"`
+	ReportedID     string             `json:"reported_id"`
+	ConversationID string             `json:"conversation_id"`
+	Reason         string             `json:"reason"`
+	Details        string             `json:"details,omitempty"`
+	Messages       []Reported_Message `json:"messages"`
+	Status         string             `json:"status"`
+	CreatedAt      time.Time          `json:"created_at"`
+}
+
+type Reported_Message struct {
+	ID         string              `json:"id"`
+	SenderID   string              `json:"sender_id"`
+	Message    string              `json:"message"`
+	Attachment *Message_Attachment `json:"attachment,omitempty"`
+	Edited     bool                `json:"edited,omitempty"`
+	CreatedAt  time.Time           `json:"created_at"`
+}
+
+func (m *Save_Message) Reported() Reported_Message {
+	return Reported_Message{
+		ID:         m.ID.Hex(),
+		SenderID:   m.SenderID,
+		Message:    m.Message,
+		Attachment: m.Attachment,
+		Edited:     m.Edited,
+		CreatedAt:  m.CreatedAt,
+	}
+}
@@ -1,6 +1,15 @@
 package models
 
+// Request_Message is addressed to Conversation_ID, a group or direct
+// conversation, or to Receiver_Number for a direct message. Reply_To quotes
+// another message in the conversation and Thread_ID posts the message as a
+// thread reply to a group message. Attachment_ID attaches a file uploaded to
+// the same conversation, in which case Message may be empty.
 type Request_Message struct {
 	Receiver_Number string `json:"receiver_number" bson:"receiver_number"`
+	Conversation_ID string `json:"conversation_id,omitempty" bson:"conversation_id,omitempty"`
 	Message         string `json:"message" bson:"message"`
+	Reply_To        string `json:"reply_to,omitempty" bson:"reply_to,omitempty"`
+	Thread_ID       string `json:"thread_id,omitempty" bson:"thread_id,omitempty"`
+	Attachment_ID   string `json:"attachment_id,omitempty" bson:"attachment_id,omitempty"`
 }
@@ -6,10 +6,29 @@ import (
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
+// Save_Message is a stored message. ReplyCount, the number of thread replies
+// to a group message, and ReactionSummary are worked out when messages are
+// fetched and never stored.
 type Save_Message struct {
-	ID         primitive.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
-	SenderID   string             `bson:"sender_id" json:"sender_id"`
-	ReceiverID string             `bson:"receiver_id" json:"receiver_id"`
-	Message    string             `bson:"message" json:"message"`
-	CreatedAt  time.Time          `bson:"created_at" json:"created_at"`
+	ID              primitive.ObjectID  `bson:"_id,omitempty" json:"id,omitempty"`
+	SenderID        string              `bson:"sender_id" json:"sender_id"`
+	ReceiverID      string              `bson:"receiver_id,omitempty" json:"receiver_id,omitempty"`
+	ConversationID  string              `bson:"conversation_id,omitempty" json:"conversation_id,omitempty"`
+	Message         string              `bson:"message" json:"message"`
+	Attachment      *Message_Attachment `bson:"attachment,omitempty" json:"attachment,omitempty"`
+	ReplyTo         *Message_Reply      `bson:"reply_to,omitempty" json:"reply_to,omitempty"`
+	ThreadID        *primitive.ObjectID `bson:"thread_id,omitempty" json:"thread_id,omitempty"`
+	ReplyCount      int64               `bson:"-" json:"reply_count,omitempty"`
+	Reactions       []Message_Reaction  `bson:"reactions,omitempty" json:"-"`
+	ReactionSummary []Reaction_Summary  `bson:"-" json:"reactions,omitempty"`
+	CreatedAt       time.Time           `bson:"created_at" json:"created_at"`
+	Edited          bool                `bson:"edited,omitempty" json:"edited,omitempty"`
+	UpdatedTime     *time.Time          `bson:"updated_time,omitempty" json:"updated_time,omitempty"`
+	DeliveredAt     *time.Time          `bson:"delivered_at,omitempty" json:"delivered_at,omitempty"`
+	ReadAt          *time.Time          `bson:"read_at,omitempty" json:"read_at,omitempty"`
+	Deleted         bool                `bson:"deleted,omitempty" json:"deleted,omitempty"`
+	DeletedAt       *time.Time          `bson:"deleted_at,omitempty" json:"deleted_at,omitempty"`
+	DeletedFor      []string            `bson:"deleted_for,omitempty" json:"-"`
+	Revisions       []Message_Revision  `bson:"revisions,omitempty" json:"-"`
+	Change          *Message_Change     `bson:"change,omitempty" json:"-"`
 }
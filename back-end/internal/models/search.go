@@ -0,0 +1,57 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Search_Request is the query string of a message search. Q uses the text
+// search syntax: words, "quoted phrases" and -excluded words. The search
+// covers all of the caller's conversations unless Conversation_ID or
+// Receiver_Number picks one. From and To are RFC 3339 timestamps; Before is
+// the Next_Cursor of the previous page.
+type Search_Request struct {
+	Q               string `form:"q" binding:"required,max=256"`
+	Conversation_ID string `form:"conversation_id"`
+	Receiver_Number string `form:"receiver_number"`
+	Sender_Number   string `form:"sender_number"`
+	From            string `form:"from"`
+	To              string `form:"to"`
+	Before          string `form:"before"`
+	Limit           int64  `form:"limit" binding:"omitempty,gt=0"`
+}
+
+// Message_Search is a resolved search. ConversationID, when set, has already
+// been checked against the caller; otherwise the search covers the caller's
+// direct messages and GroupIDs.
+type Message_Search struct {
+	Query          string
+	UserID         string
+	GroupIDs       []string
+	ConversationID string
+	SenderID       string
+	From           time.Time
+	To             time.Time
+	Before         primitive.ObjectID
+	Limit          int64
+}
+
+// Snippet_Part is a piece of a search snippet. Parts with Match set are the
+// words that matched the query, for clients to highlight.
+type Snippet_Part struct {
+	Text  string `json:"text"`
+	Match bool   `json:"match,omitempty"`
+}
+
+type Search_Result struct {
+	Message Save_Message   `json:"message"`
+	Snippet []Snippet_Part `json:"snippet"`
+}
+
+// Search_Page is one page of search results, newest first. Pass Next_Cursor
+// back as "before" to get the next page.
+type Search_Page struct {
+	Results     []Search_Result `json:"results"`
+	Next_Cursor string          `json:"next_cursor,omitempty"`
+}
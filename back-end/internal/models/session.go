@@ -0,0 +1,14 @@
+package models
+
+import "time"
+
+// Session is a device the user is logged in on. Current marks the session
+// of the token the request was made with.
+type Session struct {
+	ID         string    `json:"id"`
+	UserAgent  string    `json:"user_agent"`
+	IP         string    `json:"ip"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastUsedAt time.Time `json:"last_used_at"`
+	Current    bool      `json:"current"`
+}
@@ -0,0 +1,116 @@
+package otp
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/Ahmeds-Library/Chat-App/internal/models"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Purposes keep codes for different flows apart, so a code sent for one
+// cannot be used for another.
+const (
+	PurposeVerify = "verify"
+	PurposeReset  = "reset"
+)
+
+const (
+	CodeLength     = 6
+	CodeLifetime   = 10 * time.Minute
+	ResendCooldown = time.Minute
+	MaxAttempts    = 5
+	// AttemptWindow is how long MaxAttempts wrong codes lock a number for
+	// the purpose. New codes and new signups do not lift the lock.
+	AttemptWindow = 24 * time.Hour
+)
+
+var (
+	ErrCooldown        = errors.New("a code was sent recently")
+	ErrInvalid         = errors.New("code is invalid")
+	ErrExpired         = errors.New("code has expired")
+	ErrTooManyAttempts = errors.New("too many incorrect codes")
+)
+
+// Send generates a new code for the user, replacing any earlier one for the
+// purpose, and texts it to number. It returns ErrCooldown if a code for the
+// purpose was sent to the number less than ResendCooldown ago, for this
+// account or another.
+func Send(userID, number, purpose string) error {
+	code, err := newCode()
+	if err != nil {
+		return err
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte(code), bcrypt.DefaultCost)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	saved, err := Codes.SaveOTP(userID, number, purpose, models.OTP_Code{
+		Hash:      string(hash),
+		SentAt:    now,
+		ExpiresAt: now.Add(CodeLifetime),
+	}, now.Add(-ResendCooldown))
+	if err != nil {
+		return err
+	}
+	if !saved {
+		return ErrCooldown
+	}
+
+	message := fmt.Sprintf("Your Chat-App code is %s. It expires in %d minutes.", code, int(CodeLifetime.Minutes()))
+	return Sender.Send(number, message)
+}
+
+// Verify consumes the user's code for the purpose, which was sent to number,
+// if it matches. Otherwise it returns ErrInvalid and counts the attempt
+// against the number; once MaxAttempts have failed within AttemptWindow, no
+// code for the purpose works for the number and ErrTooManyAttempts is
+// returned until the window is over.
+func Verify(userID, number, purpose, code string) error {
+	var result error
+	err := Codes.UseOTP(userID, number, purpose, func(stored *models.OTP_Code) bool {
+		if stored == nil {
+			result = ErrInvalid
+			return false
+		}
+
+		now := time.Now()
+		windowOver := !now.Before(stored.AttemptsSince.Add(AttemptWindow))
+		switch {
+		case stored.Attempts >= MaxAttempts && !windowOver:
+			result = ErrTooManyAttempts
+		case now.After(stored.ExpiresAt):
+			result = ErrExpired
+		case bcrypt.CompareHashAndPassword([]byte(stored.Hash), []byte(code)) != nil:
+			if windowOver {
+				stored.Attempts, stored.AttemptsSince = 0, now
+			}
+			stored.Attempts++
+			result = ErrInvalid
+		default:
+			return true
+		}
+		return false
+	})
+	if err != nil {
+		return err
+	}
+	return result
+}
+
+func newCode() (string, error) {
+	max := big.NewInt(1)
+	for i := 0; i < CodeLength; i++ {
+		max.Mul(max, big.NewInt(10))
+	}
+	n, err := rand.Int(rand.Reader, max)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%0*d", CodeLength, n), nil
+}
@@ -0,0 +1,177 @@
+package otp
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/Ahmeds-Library/Chat-App/internal/otp/otptest"
+)
+
+const testNumber = "03001234567"
+
+func useTestStore(t *testing.T) (*otptest.Store, *otptest.Outbox) {
+	t.Helper()
+	store, outbox := otptest.NewStore(), &otptest.Outbox{}
+	oldCodes, oldSender := Codes, Sender
+	Codes, Sender = store, outbox
+	t.Cleanup(func() { Codes, Sender = oldCodes, oldSender })
+	return store, outbox
+}
+
+// wrongCode returns a code that is not code.
+func wrongCode(code string) string {
+	if code == "000000" {
+		return "111111"
+	}
+	return "000000"
+}
+
+func TestVerifyConsumesCode(t *testing.T) {
+	_, outbox := useTestStore(t)
+
+	if err := Send("1", testNumber, PurposeVerify); err != nil {
+		t.Fatal(err)
+	}
+	code := outbox.LastCode(testNumber)
+	if len(code) != CodeLength {
+		t.Fatalf("texted code %q", code)
+	}
+
+	if err := Verify("1", testNumber, PurposeVerify, code); err != nil {
+		t.Fatalf("correct code: %v", err)
+	}
+	if err := Verify("1", testNumber, PurposeVerify, code); !errors.Is(err, ErrInvalid) {
+		t.Fatalf("reused code: %v, want ErrInvalid", err)
+	}
+}
+
+func TestVerifyIsTiedToUserAndPurpose(t *testing.T) {
+	_, outbox := useTestStore(t)
+
+	// Two signups with the same number get their own codes.
+	if err := Send("1", testNumber, PurposeVerify); err != nil {
+		t.Fatal(err)
+	}
+	code := outbox.LastCode(testNumber)
+
+	if err := Verify("2", testNumber, PurposeVerify, code); !errors.Is(err, ErrInvalid) {
+		t.Fatalf("other user: %v, want ErrInvalid", err)
+	}
+	if err := Verify("1", testNumber, PurposeReset, code); !errors.Is(err, ErrInvalid) {
+		t.Fatalf("other purpose: %v, want ErrInvalid", err)
+	}
+	if err := Verify("1", testNumber, PurposeVerify, code); err != nil {
+		t.Fatalf("owner: %v", err)
+	}
+}
+
+func TestVerifyExpiredCode(t *testing.T) {
+	store, outbox := useTestStore(t)
+
+	if err := Send("1", testNumber, PurposeVerify); err != nil {
+		t.Fatal(err)
+	}
+	store.Age(testNumber, PurposeVerify, CodeLifetime+1)
+
+	if err := Verify("1", testNumber, PurposeVerify, outbox.LastCode(testNumber)); !errors.Is(err, ErrExpired) {
+		t.Fatalf("got %v, want ErrExpired", err)
+	}
+}
+
+func TestVerifyAttemptLimit(t *testing.T) {
+	_, outbox := useTestStore(t)
+
+	if err := Send("1", testNumber, PurposeVerify); err != nil {
+		t.Fatal(err)
+	}
+	code := outbox.LastCode(testNumber)
+
+	for i := 0; i < MaxAttempts; i++ {
+		if err := Verify("1", testNumber, PurposeVerify, wrongCode(code)); !errors.Is(err, ErrInvalid) {
+			t.Fatalf("attempt %d: %v, want ErrInvalid", i+1, err)
+		}
+	}
+	if err := Verify("1", testNumber, PurposeVerify, code); !errors.Is(err, ErrTooManyAttempts) {
+		t.Fatalf("correct code after the limit: %v, want ErrTooManyAttempts", err)
+	}
+}
+
+func TestSendCooldown(t *testing.T) {
+	store, outbox := useTestStore(t)
+
+	if err := Send("1", testNumber, PurposeVerify); err != nil {
+		t.Fatal(err)
+	}
+	first := outbox.LastCode(testNumber)
+
+	if err := Send("1", testNumber, PurposeVerify); !errors.Is(err, ErrCooldown) {
+		t.Fatalf("resend: %v, want ErrCooldown", err)
+	}
+	if outbox.Count(testNumber) != 1 {
+		t.Fatalf("sent %d messages, want 1", outbox.Count(testNumber))
+	}
+	// The cooldown is the number's, whichever account asks.
+	if err := Send("2", testNumber, PurposeVerify); !errors.Is(err, ErrCooldown) {
+		t.Fatalf("other user: %v, want ErrCooldown", err)
+	}
+	// Other purposes have their own cooldown.
+	if err := Send("1", testNumber, PurposeReset); err != nil {
+		t.Fatalf("other purpose: %v", err)
+	}
+
+	// A new code replaces the old one.
+	store.Age(testNumber, PurposeVerify, ResendCooldown+1)
+	if err := Send("1", testNumber, PurposeVerify); err != nil {
+		t.Fatalf("resend after cooldown: %v", err)
+	}
+	second := outbox.LastCode(testNumber)
+	if second != first {
+		if err := Verify("1", testNumber, PurposeVerify, first); !errors.Is(err, ErrInvalid) {
+			t.Fatalf("replaced code: %v, want ErrInvalid", err)
+		}
+	}
+	if err := Verify("1", testNumber, PurposeVerify, second); err != nil {
+		t.Fatalf("new code: %v", err)
+	}
+}
+
+// Neither a new code nor a new account for the number gives more guesses.
+func TestVerifyAttemptLimitSurvivesResend(t *testing.T) {
+	store, outbox := useTestStore(t)
+
+	if err := Send("1", testNumber, PurposeVerify); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < MaxAttempts; i++ {
+		if err := Verify("1", testNumber, PurposeVerify, wrongCode(outbox.LastCode(testNumber))); !errors.Is(err, ErrInvalid) {
+			t.Fatalf("attempt %d: %v, want ErrInvalid", i+1, err)
+		}
+	}
+
+	for _, userID := range []string{"1", "2"} {
+		store.Age(testNumber, PurposeVerify, ResendCooldown+1)
+		if err := Send(userID, testNumber, PurposeVerify); err != nil {
+			t.Fatalf("resend for %s: %v", userID, err)
+		}
+		if err := Verify(userID, testNumber, PurposeVerify, outbox.LastCode(testNumber)); !errors.Is(err, ErrTooManyAttempts) {
+			t.Fatalf("new code for %s: %v, want ErrTooManyAttempts", userID, err)
+		}
+	}
+
+	// Other purposes are not held up.
+	if err := Send("1", testNumber, PurposeReset); err != nil {
+		t.Fatal(err)
+	}
+	if err := Verify("1", testNumber, PurposeReset, outbox.LastCode(testNumber)); err != nil {
+		t.Fatalf("other purpose: %v", err)
+	}
+
+	// The lock lifts once the window is over, for a fresh code.
+	store.Age(testNumber, PurposeVerify, AttemptWindow)
+	if err := Send("2", testNumber, PurposeVerify); err != nil {
+		t.Fatal(err)
+	}
+	if err := Verify("2", testNumber, PurposeVerify, outbox.LastCode(testNumber)); err != nil {
+		t.Fatalf("after the window: %v", err)
+	}
+}
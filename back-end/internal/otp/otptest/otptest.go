@@ -0,0 +1,143 @@
+// Package otptest has in-memory stand-ins for the code store and SMS sender,
+// so code that sends and checks one-time codes can be tested without a
+// database.
+package otptest
+
+import (
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Ahmeds-Library/Chat-App/internal/models"
+)
+
+// Store is an otp.CodeStore kept in memory.
+type Store struct {
+	mu     sync.Mutex
+	codes  map[string]storedCode
+	limits map[string]limit
+}
+
+type storedCode struct {
+	models.OTP_Code
+	number string
+}
+
+// limit is what is kept per number and purpose.
+type limit struct {
+	sentAt        time.Time
+	attempts      int
+	attemptsSince time.Time
+}
+
+func NewStore() *Store {
+	return &Store{codes: make(map[string]storedCode), limits: make(map[string]limit)}
+}
+
+func (s *Store) SaveOTP(userID, number, purpose string, code models.OTP_Code, sentBefore time.Time) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	numberKey := number + "/" + purpose
+	l, ok := s.limits[numberKey]
+	if ok && !l.sentAt.Before(sentBefore) {
+		return false, nil
+	}
+	if !ok {
+		l.attemptsSince = code.SentAt
+	}
+	l.sentAt = code.SentAt
+	s.limits[numberKey] = l
+
+	code.Attempts, code.AttemptsSince = 0, time.Time{}
+	s.codes[userID+"/"+purpose] = storedCode{OTP_Code: code, number: number}
+	return true, nil
+}
+
+func (s *Store) UseOTP(userID, number, purpose string, check func(code *models.OTP_Code) bool) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key, numberKey := userID+"/"+purpose, number+"/"+purpose
+	stored, ok := s.codes[key]
+	if !ok {
+		check(nil)
+		return nil
+	}
+
+	code := stored.OTP_Code
+	l, ok := s.limits[numberKey]
+	if !ok {
+		l = limit{sentAt: code.SentAt, attemptsSince: code.SentAt}
+	}
+	code.Attempts, code.AttemptsSince = l.attempts, l.attemptsSince
+
+	if check(&code) {
+		delete(s.codes, key)
+		l.attempts = 0
+	} else {
+		l.attempts, l.attemptsSince = code.Attempts, code.AttemptsSince
+	}
+	s.limits[numberKey] = l
+	return nil
+}
+
+// Age moves everything about codes for the purpose sent to number back in
+// time, as if it had all happened d earlier.
+func (s *Store) Age(number, purpose string, d time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for key, stored := range s.codes {
+		if stored.number == number && strings.HasSuffix(key, "/"+purpose) {
+			stored.SentAt = stored.SentAt.Add(-d)
+			stored.ExpiresAt = stored.ExpiresAt.Add(-d)
+			s.codes[key] = stored
+		}
+	}
+	if l, ok := s.limits[number+"/"+purpose]; ok {
+		l.sentAt = l.sentAt.Add(-d)
+		l.attemptsSince = l.attemptsSince.Add(-d)
+		s.limits[number+"/"+purpose] = l
+	}
+}
+
+var codePattern = regexp.MustCompile(`\b\d{6}\b`)
+
+// Outbox is an otp.SMSSender that keeps the messages it is given.
+type Outbox struct {
+	mu       sync.Mutex
+	messages map[string][]string
+}
+
+func (o *Outbox) Send(number, message string) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if o.messages == nil {
+		o.messages = make(map[string][]string)
+	}
+	o.messages[number] = append(o.messages[number], message)
+	return nil
+}
+
+// Count returns how many messages were sent to number.
+func (o *Outbox) Count(number string) int {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return len(o.messages[number])
+}
+
+// LastCode returns the code in the last message sent to number, or "" if
+// there is none.
+func (o *Outbox) LastCode(number string) string {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	messages := o.messages[number]
+	if len(messages) == 0 {
+		return ""
+	}
+	return codePattern.FindString(messages[len(messages)-1])
+}
@@ -0,0 +1,67 @@
+package otp
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// SMSSender delivers a text message to a phone number.
+type SMSSender interface {
+	Send(number, message string) error
+}
+
+// Sender is the sender used by the handlers, set up by NewSenderFromEnv.
+var Sender SMSSender
+
+// NewSenderFromEnv builds the sender named by SMS_SENDER: "console" (the
+// default) logs messages, and "file" appends them to SMS_OUTBOX_FILE so
+// codes can be read back during local development and in scripts.
+func NewSenderFromEnv() (SMSSender, error) {
+	switch kind := strings.ToLower(os.Getenv("SMS_SENDER")); kind {
+	case "", "console":
+		return ConsoleSender{}, nil
+	case "file":
+		path := os.Getenv("SMS_OUTBOX_FILE")
+		if path == "" {
+			path = "sms_outbox.log"
+		}
+		return &FileSender{Path: path}, nil
+	default:
+		return nil, fmt.Errorf("unknown SMS_SENDER %q", kind)
+	}
+}
+
+// ConsoleSender writes messages to the log instead of sending them.
+type ConsoleSender struct{}
+
+func (ConsoleSender) Send(number, message string) error {
+	log.Printf("SMS to %s: %s", number, message)
+	return nil
+}
+
+// FileSender appends each message to a file as one line of
+// "<RFC 3339 time> <number> <message>".
+type FileSender struct {
+	Path string
+
+	mu sync.Mutex
+}
+
+func (s *FileSender) Send(number, message string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	f, err := os.OpenFile(s.Path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintf(f, "%s %s %s\n", time.Now().UTC().Format(time.RFC3339), number, message); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
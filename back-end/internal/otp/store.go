@@ -0,0 +1,30 @@
+package otp
+
+import (
+	"time"
+
+	pg_admin "github.com/Ahmeds-Library/Chat-App/internal/database/Pg_Admin"
+	"github.com/Ahmeds-Library/Chat-App/internal/models"
+)
+
+// CodeStore keeps the hashes of sent codes, at most one per user and
+// purpose, and when codes were last sent to each number and how many wrong
+// ones were tried. See pg_admin.SaveOTP and pg_admin.UseOTP for the
+// contract.
+type CodeStore interface {
+	SaveOTP(userID, number, purpose string, code models.OTP_Code, sentBefore time.Time) (bool, error)
+	UseOTP(userID, number, purpose string, check func(code *models.OTP_Code) bool) error
+}
+
+// Codes is the store used by Send and Verify.
+var Codes CodeStore = pgCodes{}
+
+type pgCodes struct{}
+
+func (pgCodes) SaveOTP(userID, number, purpose string, code models.OTP_Code, sentBefore time.Time) (bool, error) {
+	return pg_admin.SaveOTP(userID, number, purpose, code, sentBefore)
+}
+
+func (pgCodes) UseOTP(userID, number, purpose string, check func(code *models.OTP_Code) bool) error {
+	return pg_admin.UseOTP(userID, number, purpose, check)
+}
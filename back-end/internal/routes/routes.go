@@ -4,6 +4,8 @@ import (
 	"database/sql"
 
 	"github.com/Ahmeds-Library/Chat-App/internal/api/auth_handler"
+	"github.com/Ahmeds-Library/Chat-App/internal/api/group_handler"
+	"github.com/Ahmeds-Library/Chat-App/internal/api/user_handler"
 	message_handler "github.com/Ahmeds-Library/Chat-App/internal/api/mesage_handler"
 	mongo_db "github.com/Ahmeds-Library/Chat-App/internal/database/Mongo_DB"
 	"github.com/Ahmeds-Library/Chat-App/internal/middleware"
@@ -20,19 +22,54 @@ func RoutesHandler(r *gin.Engine) {
 				"192.168.49.2",
   				"http://chat.local", 
 				},
-		AllowMethods:     []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-		AllowHeaders:     []string{"Origin", "Authorization", "Content-Type"},
-		ExposeHeaders:    []string{"Content-Length"},
+		AllowMethods:     []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
+		AllowHeaders:     []string{"Origin", "Authorization", "Content-Type", "Upload-Offset"},
+		ExposeHeaders:    []string{"Content-Length", "Upload-Offset"},
 		AllowCredentials: true,
 	}))
 
 	r.POST("/signup", auth_handler.Signup)
 	r.POST("/login", auth_handler.Login)
+	r.POST("/send_verification_code", auth_handler.SendVerificationCode)
+	r.POST("/verify_number", auth_handler.VerifyNumber)
+	r.POST("/forgot_password", auth_handler.ForgotPassword)
+	r.POST("/reset_password", auth_handler.ResetPassword)
 	r.POST("/refresh_key", auth_handler.Refresh_Key)
+	r.GET("/.well-known/jwks.json", auth_handler.JWKS)
+	r.POST("/logout", middleware.AuthMiddleware(), auth_handler.Logout)
+	r.POST("/logout_all", middleware.AuthMiddleware(), auth_handler.LogoutAll)
+	r.POST("/change_password", middleware.AuthMiddleware(), auth_handler.ChangePassword)
+	r.GET("/sessions", middleware.AuthMiddleware(), auth_handler.ListSessions)
+	r.DELETE("/sessions/:id", middleware.AuthMiddleware(), auth_handler.RevokeSession)
 	r.POST("/get_message", middleware.AuthMiddleware(), message_handler.Get_Message)
 	r.GET("/chat_list", middleware.AuthMiddleware(), message_handler.GetChatListHandler(mongo_db.MongoClient, &sql.DB{}))
 	r.POST("/message", middleware.AuthMiddleware(), message_handler.SendMessageHandler(mongo_db.MongoClient))
 	r.POST("/update_message", middleware.AuthMiddleware(), func(c *gin.Context) {
 		message_handler.UpdateMessageHandler(c)
 	})
+	r.GET("/messages/:id/history", middleware.AuthMiddleware(), message_handler.MessageHistoryHandler)
+	r.GET("/messages/:id/thread", middleware.AuthMiddleware(), message_handler.GetThreadHandler)
+	r.POST("/messages/:id/reactions", middleware.AuthMiddleware(), message_handler.AddReactionHandler)
+	r.DELETE("/messages/:id/reactions", middleware.AuthMiddleware(), message_handler.RemoveReactionHandler)
+	r.POST("/delete_message", middleware.AuthMiddleware(), message_handler.DeleteMessageHandler)
+	r.POST("/attachments", middleware.AuthMiddleware(), message_handler.UploadAttachmentHandler)
+	r.GET("/attachments/:id", middleware.AuthMiddleware(), message_handler.DownloadAttachmentHandler)
+	r.POST("/uploads", middleware.AuthMiddleware(), message_handler.StartUploadHandler)
+	r.GET("/uploads/:id", middleware.AuthMiddleware(), message_handler.GetUploadHandler)
+	r.PATCH("/uploads/:id", middleware.AuthMiddleware(), message_handler.UploadChunkHandler)
+	r.GET("/search", middleware.AuthMiddleware(), message_handler.SearchMessagesHandler)
+	r.POST("/mark_read", middleware.AuthMiddleware(), message_handler.MarkReadHandler)
+
+	r.POST("/groups", middleware.AuthMiddleware(), group_handler.CreateGroup)
+	r.GET("/groups", middleware.AuthMiddleware(), group_handler.ListGroups)
+	r.GET("/groups/:id", middleware.AuthMiddleware(), group_handler.GetGroup)
+	r.PUT("/groups/:id", middleware.AuthMiddleware(), group_handler.RenameGroup)
+	r.POST("/groups/:id/members", middleware.AuthMiddleware(), group_handler.AddMembers)
+	r.PUT("/groups/:id/members/:user_id", middleware.AuthMiddleware(), group_handler.SetMemberRole)
+	r.DELETE("/groups/:id/members/:user_id", middleware.AuthMiddleware(), group_handler.RemoveMember)
+
+	r.GET("/blocks", middleware.AuthMiddleware(), user_handler.ListBlockedUsersHandler)
+	r.POST("/blocks", middleware.AuthMiddleware(), user_handler.BlockUserHandler)
+	r.DELETE("/blocks/:user_id", middleware.AuthMiddleware(), user_handler.UnblockUserHandler)
+	r.POST("/reports", middleware.AuthMiddleware(), user_handler.ReportUserHandler)
 }
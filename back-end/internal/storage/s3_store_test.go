@@ -0,0 +1,116 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/Ahmeds-Library/Chat-App/internal/storage/s3test"
+)
+
+func newTestS3Store(t *testing.T) (*S3Store, *s3test.Server) {
+	t.Helper()
+
+	server := s3test.NewServer()
+	t.Cleanup(server.Close)
+
+	store, err := NewS3Store(context.Background(), S3Config{
+		Endpoint:  server.Endpoint(),
+		Bucket:    "attachments",
+		AccessKey: "test",
+		SecretKey: "test-secret",
+		Region:    "us-east-1",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return store, server
+}
+
+func TestS3StoreRoundTrip(t *testing.T) {
+	store, server := newTestS3Store(t)
+	ctx := context.Background()
+	data := []byte("hello, attachment")
+
+	if err := store.Put(ctx, "attachments/a", bytes.NewReader(data), int64(len(data)), "text/plain"); err != nil {
+		t.Fatal(err)
+	}
+	if stored, _ := server.Object("attachments", "attachments/a"); !bytes.Equal(stored, data) {
+		t.Fatalf("stored %q, want %q", stored, data)
+	}
+
+	blob, err := store.Get(ctx, "attachments/a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := io.ReadAll(blob)
+	blob.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, data) {
+		t.Fatalf("read %q, want %q", got, data)
+	}
+
+	if err := store.Delete(ctx, "attachments/a"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.Get(ctx, "attachments/a"); !errors.Is(err, ErrBlobNotFound) {
+		t.Fatalf("Get after Delete: %v, want ErrBlobNotFound", err)
+	}
+	if err := store.Delete(ctx, "attachments/a"); err != nil {
+		t.Fatalf("deleting a missing blob: %v", err)
+	}
+}
+
+func TestS3StoreMissingBlob(t *testing.T) {
+	store, _ := newTestS3Store(t)
+
+	if _, err := store.Get(context.Background(), "attachments/missing"); !errors.Is(err, ErrBlobNotFound) {
+		t.Fatalf("Get: %v, want ErrBlobNotFound", err)
+	}
+}
+
+// Blobs past the client's part size go up as a multipart upload, which is
+// how a full size attachment is stored.
+func TestS3StoreLargeBlob(t *testing.T) {
+	store, server := newTestS3Store(t)
+	ctx := context.Background()
+
+	data := bytes.Repeat([]byte("0123456789abcdef"), (20<<20)/16)
+	// Hide the ReaderAt so the blob streams through as it would from a
+	// request body.
+	r := struct{ io.Reader }{bytes.NewReader(data)}
+	if err := store.Put(ctx, "attachments/large", r, int64(len(data)), "application/zip"); err != nil {
+		t.Fatal(err)
+	}
+
+	if stored, _ := server.Object("attachments", "attachments/large"); !bytes.Equal(stored, data) {
+		t.Fatalf("stored %d bytes, want %d", len(stored), len(data))
+	}
+	if n := server.PendingUploads(); n != 0 {
+		t.Fatalf("%d multipart uploads left open", n)
+	}
+}
+
+func TestS3StoreReusesBucket(t *testing.T) {
+	store, server := newTestS3Store(t)
+	data := []byte("kept")
+	if err := store.Put(context.Background(), "k", bytes.NewReader(data), int64(len(data)), "text/plain"); err != nil {
+		t.Fatal(err)
+	}
+
+	// A second replica starting up finds the bucket already there.
+	if _, err := NewS3Store(context.Background(), S3Config{
+		Endpoint: server.Endpoint(),
+		Bucket:   "attachments",
+		Region:   "us-east-1",
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if keys := server.Keys("attachments"); len(keys) != 1 || keys[0] != "k" {
+		t.Fatalf("keys %v, want [k]", keys)
+	}
+}
@@ -0,0 +1,351 @@
+// Package s3test runs an in-memory stand-in for an S3 compatible service, so
+// code that talks to S3Store can be tested without a MinIO server. It speaks
+// just enough of the API for the calls S3Store makes, and does not check
+// request signatures.
+package s3test
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/md5"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+type object struct {
+	data        []byte
+	contentType string
+	etag        string
+	modified    time.Time
+}
+
+// Server is an S3 stand-in listening on a local port.
+type Server struct {
+	server *httptest.Server
+
+	mu      sync.Mutex
+	buckets map[string]map[string]*object
+	uploads map[string]map[int][]byte
+	nextID  int
+}
+
+// NewServer starts a server with no buckets. Close it when done.
+func NewServer() *Server {
+	s := &Server{
+		buckets: make(map[string]map[string]*object),
+		uploads: make(map[string]map[int][]byte),
+	}
+	s.server = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+	return s
+}
+
+// Endpoint is the host and port to pass as S3Config.Endpoint, without SSL.
+func (s *Server) Endpoint() string {
+	return strings.TrimPrefix(s.server.URL, "http://")
+}
+
+func (s *Server) Close() {
+	s.server.Close()
+}
+
+// Object returns the contents stored under key, and whether there are any.
+func (s *Server) Object(bucket, key string) ([]byte, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	obj, ok := s.buckets[bucket][key]
+	if !ok {
+		return nil, false
+	}
+	return obj.data, true
+}
+
+// Keys lists the keys stored in bucket, in order.
+func (s *Server) Keys(bucket string) []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	keys := make([]string, 0, len(s.buckets[bucket]))
+	for key := range s.buckets[bucket] {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// PendingUploads counts multipart uploads that were neither completed nor
+// aborted.
+func (s *Server) PendingUploads() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.uploads)
+}
+
+func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	bucket, key, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/"), "/")
+	query := r.URL.Query()
+
+	if key == "" {
+		s.serveBucket(w, r, bucket, query)
+		return
+	}
+
+	switch {
+	case r.Method == http.MethodPost && query.Has("uploads"):
+		s.startUpload(w, bucket, key)
+	case r.Method == http.MethodPut && query.Has("uploadId"):
+		s.putPart(w, r, query)
+	case r.Method == http.MethodPost && query.Has("uploadId"):
+		s.completeUpload(w, r, bucket, key, query.Get("uploadId"))
+	case r.Method == http.MethodDelete && query.Has("uploadId"):
+		s.mu.Lock()
+		delete(s.uploads, query.Get("uploadId"))
+		s.mu.Unlock()
+		w.WriteHeader(http.StatusNoContent)
+	case r.Method == http.MethodPut:
+		s.putObject(w, r, bucket, key)
+	case r.Method == http.MethodGet || r.Method == http.MethodHead:
+		s.getObject(w, r, bucket, key)
+	case r.Method == http.MethodDelete:
+		s.mu.Lock()
+		delete(s.buckets[bucket], key)
+		s.mu.Unlock()
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		writeError(w, r, http.StatusNotImplemented, "NotImplemented")
+	}
+}
+
+func (s *Server) serveBucket(w http.ResponseWriter, r *http.Request, bucket string, query url.Values) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, exists := s.buckets[bucket]
+	switch {
+	case r.Method == http.MethodGet && query.Has("location"):
+		if !exists {
+			writeError(w, r, http.StatusNotFound, "NoSuchBucket")
+			return
+		}
+		writeXML(w, struct {
+			XMLName xml.Name `xml:"LocationConstraint"`
+		}{})
+	case r.Method == http.MethodHead:
+		if !exists {
+			w.WriteHeader(http.StatusNotFound)
+		}
+	case r.Method == http.MethodPut:
+		if exists {
+			writeError(w, r, http.StatusConflict, "BucketAlreadyOwnedByYou")
+			return
+		}
+		s.buckets[bucket] = make(map[string]*object)
+	default:
+		writeError(w, r, http.StatusNotImplemented, "NotImplemented")
+	}
+}
+
+func (s *Server) putObject(w http.ResponseWriter, r *http.Request, bucket, key string) {
+	data, err := readBody(r)
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, "IncompleteBody")
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	objects, ok := s.buckets[bucket]
+	if !ok {
+		writeError(w, r, http.StatusNotFound, "NoSuchBucket")
+		return
+	}
+	obj := newObject(data, r.Header.Get("Content-Type"))
+	objects[key] = obj
+	w.Header().Set("ETag", obj.etag)
+}
+
+func (s *Server) getObject(w http.ResponseWriter, r *http.Request, bucket, key string) {
+	s.mu.Lock()
+	obj, ok := s.buckets[bucket][key]
+	s.mu.Unlock()
+	if !ok {
+		writeError(w, r, http.StatusNotFound, "NoSuchKey")
+		return
+	}
+
+	w.Header().Set("ETag", obj.etag)
+	w.Header().Set("Content-Type", obj.contentType)
+	http.ServeContent(w, r, key, obj.modified, bytes.NewReader(obj.data))
+}
+
+func (s *Server) startUpload(w http.ResponseWriter, bucket, key string) {
+	s.mu.Lock()
+	s.nextID++
+	uploadID := strconv.Itoa(s.nextID)
+	s.uploads[uploadID] = make(map[int][]byte)
+	s.mu.Unlock()
+
+	writeXML(w, struct {
+		XMLName  xml.Name `xml:"InitiateMultipartUploadResult"`
+		Bucket   string
+		Key      string
+		UploadId string
+	}{Bucket: bucket, Key: key, UploadId: uploadID})
+}
+
+func (s *Server) putPart(w http.ResponseWriter, r *http.Request, query url.Values) {
+	number, err := strconv.Atoi(query.Get("partNumber"))
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, "InvalidArgument")
+		return
+	}
+	data, err := readBody(r)
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, "IncompleteBody")
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	parts, ok := s.uploads[query.Get("uploadId")]
+	if !ok {
+		writeError(w, r, http.StatusNotFound, "NoSuchUpload")
+		return
+	}
+	parts[number] = data
+	w.Header().Set("ETag", etag(data))
+}
+
+func (s *Server) completeUpload(w http.ResponseWriter, r *http.Request, bucket, key, uploadID string) {
+	var req struct {
+		Parts []struct {
+			PartNumber int
+		} `xml:"Part"`
+	}
+	if err := xml.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, r, http.StatusBadRequest, "MalformedXML")
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	parts, ok := s.uploads[uploadID]
+	objects, bucketOK := s.buckets[bucket]
+	if !ok || !bucketOK {
+		writeError(w, r, http.StatusNotFound, "NoSuchUpload")
+		return
+	}
+
+	var data []byte
+	for _, part := range req.Parts {
+		chunk, ok := parts[part.PartNumber]
+		if !ok {
+			writeError(w, r, http.StatusBadRequest, "InvalidPart")
+			return
+		}
+		data = append(data, chunk...)
+	}
+	delete(s.uploads, uploadID)
+
+	obj := newObject(data, "application/octet-stream")
+	objects[key] = obj
+	writeXML(w, struct {
+		XMLName xml.Name `xml:"CompleteMultipartUploadResult"`
+		Bucket  string
+		Key     string
+		ETag    string
+	}{Bucket: bucket, Key: key, ETag: obj.etag})
+}
+
+// readBody reads a request body, undoing the aws-chunked encoding clients
+// use to sign a payload as it streams.
+func readBody(r *http.Request) ([]byte, error) {
+	if !strings.HasPrefix(r.Header.Get("X-Amz-Content-Sha256"), "STREAMING-") {
+		return io.ReadAll(r.Body)
+	}
+
+	var data []byte
+	body := bufio.NewReader(r.Body)
+	for {
+		line, err := body.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		sizeHex, _, _ := strings.Cut(strings.TrimSpace(line), ";")
+		size, err := strconv.ParseInt(sizeHex, 16, 64)
+		if err != nil {
+			return nil, fmt.Errorf("bad chunk header %q", line)
+		}
+		if size == 0 {
+			// Trailing headers, if any, follow; nothing here needs them.
+			break
+		}
+		chunk := make([]byte, size+2)
+		if _, err := io.ReadFull(body, chunk); err != nil {
+			return nil, err
+		}
+		if !bytes.HasSuffix(chunk, []byte("\r\n")) {
+			return nil, errors.New("chunk not terminated")
+		}
+		data = append(data, chunk[:size]...)
+	}
+
+	if decoded := r.Header.Get("X-Amz-Decoded-Content-Length"); decoded != "" && decoded != strconv.Itoa(len(data)) {
+		return nil, fmt.Errorf("decoded %d bytes, expected %s", len(data), decoded)
+	}
+	return data, nil
+}
+
+func newObject(data []byte, contentType string) *object {
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	return &object{
+		data:        data,
+		contentType: contentType,
+		etag:        etag(data),
+		modified:    time.Now().UTC().Truncate(time.Second),
+	}
+}
+
+func etag(data []byte) string {
+	sum := md5.Sum(data)
+	return `"` + hex.EncodeToString(sum[:]) + `"`
+}
+
+func writeXML(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/xml")
+	io.WriteString(w, xml.Header)
+	xml.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, r *http.Request, status int, code string) {
+	// Clients read the code of a failed HEAD from the status alone.
+	if r.Method == http.MethodHead {
+		w.WriteHeader(status)
+		return
+	}
+	w.Header().Set("Content-Type", "application/xml")
+	w.WriteHeader(status)
+	io.WriteString(w, xml.Header)
+	xml.NewEncoder(w).Encode(struct {
+		XMLName  xml.Name `xml:"Error"`
+		Code     string
+		Message  string
+		Resource string
+	}{Code: code, Message: code, Resource: r.URL.Path})
+}
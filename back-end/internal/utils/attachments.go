@@ -0,0 +1,104 @@
+package utils
+
+import (
+	"log"
+	"mime"
+	"net/http"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/Ahmeds-Library/Chat-App/internal/models"
+)
+
+const (
+	DefaultMaxAttachmentSize = 25 << 20
+	// MaxChunkSize bounds a single chunk of a resumable upload.
+	MaxChunkSize = 8 << 20
+)
+
+// DefaultAttachmentTypes are the MIME types accepted unless
+// ATTACHMENT_ALLOWED_TYPES says otherwise.
+var DefaultAttachmentTypes = []string{
+	"image/jpeg", "image/png", "image/gif", "image/webp",
+	"audio/mpeg", "audio/ogg", "audio/mp4", "audio/webm", "audio/wave",
+	"video/mp4", "video/webm",
+	"application/pdf", "application/zip", "text/plain",
+}
+
+// MaxAttachmentSize reads ATTACHMENT_MAX_SIZE, in bytes.
+func MaxAttachmentSize() int64 {
+	value := os.Getenv("ATTACHMENT_MAX_SIZE")
+	if value == "" {
+		return DefaultMaxAttachmentSize
+	}
+	size, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || size <= 0 {
+		log.Printf("Invalid ATTACHMENT_MAX_SIZE %q, using %d", value, DefaultMaxAttachmentSize)
+		return DefaultMaxAttachmentSize
+	}
+	return size
+}
+
+// AttachmentTypeAllowed checks mimeType against ATTACHMENT_ALLOWED_TYPES, a
+// comma separated list, or DefaultAttachmentTypes.
+func AttachmentTypeAllowed(mimeType string) bool {
+	allowed := DefaultAttachmentTypes
+	if value := os.Getenv("ATTACHMENT_ALLOWED_TYPES"); value != "" {
+		allowed = strings.Split(value, ",")
+		for i := range allowed {
+			allowed[i] = strings.TrimSpace(allowed[i])
+		}
+	}
+	return slices.Contains(allowed, mimeType)
+}
+
+// DetectMimeType sniffs the type of a file from its first bytes. The type the
+// client declared is only trusted where sniffing cannot tell: audio in a
+// video or Ogg container, and formats Go does not recognise. Images are
+// always sniffed.
+func DetectMimeType(declared string, head []byte) string {
+	sniffed := baseMimeType(http.DetectContentType(head))
+	declared = baseMimeType(declared)
+
+	switch sniffed {
+	case "video/mp4", "video/webm", "application/ogg":
+		if strings.HasPrefix(declared, "audio/") {
+			return declared
+		}
+		if sniffed == "application/ogg" {
+			return "audio/ogg"
+		}
+	case "application/octet-stream":
+		if declared != "" && !strings.HasPrefix(declared, "image/") && !strings.HasPrefix(declared, "text/") {
+			return declared
+		}
+	}
+	return sniffed
+}
+
+// AttachmentKind groups a MIME type into one of the attachment kinds.
+func AttachmentKind(mimeType string) string {
+	switch {
+	case strings.HasPrefix(mimeType, "image/"):
+		return models.AttachmentImage
+	case strings.HasPrefix(mimeType, "audio/"):
+		return models.AttachmentVoice
+	case strings.HasPrefix(mimeType, "video/"):
+		return models.AttachmentVideo
+	default:
+		return models.AttachmentFile
+	}
+}
+
+func baseMimeType(value string) string {
+	if value == "" {
+		return ""
+	}
+	mediaType, _, err := mime.ParseMediaType(value)
+	if err != nil {
+		return ""
+	}
+	return mediaType
+}
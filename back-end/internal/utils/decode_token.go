@@ -1,19 +1,12 @@
 package utils
 
 import (
-	"errors"
-
 	"github.com/Ahmeds-Library/Chat-App/internal/middleware"
-	"github.com/golang-jwt/jwt"
+	"github.com/golang-jwt/jwt/v5"
 )
 
 func DecodeToken(tokenString string) (jwt.MapClaims, error) {
-	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, errors.New("unexpected signing method")
-		}
-		return middleware.SecretKey, nil
-	})
+	token, err := jwt.Parse(tokenString, middleware.Keys.Keyfunc)
 
 	if err != nil {
 		return nil, err
@@ -2,44 +2,184 @@ package utils
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
+	"time"
 
 	"github.com/Ahmeds-Library/Chat-App/internal/models"
 	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
-func Message_Fetcher(mongoClient *mongo.Client, senderID, receiverID string) ([]models.Save_Message, error) {
+const (
+	DefaultPageSize = 50
+	MaxPageSize     = 200
+)
+
+// ErrInvalidPage is returned for malformed or conflicting cursors.
+var ErrInvalidPage = errors.New("invalid page request")
+
+// Message_Fetcher returns one page of a conversation as userID sees it.
+// Messages are ordered by ID, which follows creation order, so pages stay
+// stable while new messages arrive. Messages the user deleted for themselves
+// are left out; messages deleted for everyone come back as tombstones. Thread
+// replies are only returned by Thread_Fetcher; their roots carry a reply
+// count instead.
+func Message_Fetcher(mongoClient *mongo.Client, userID, conversationID string, req models.Get_Message) (*models.Message_Page, error) {
+	collection := mongoClient.Database("chat-app").Collection("messages")
+
+	page, err := fetchPage(collection, bson.M{
+		"conversation_id": conversationID,
+		"thread_id":       bson.M{"$exists": false},
+		"deleted_for":     bson.M{"$ne": userID},
+	}, req)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := countReplies(collection, page.Messages); err != nil {
+		return nil, err
+	}
+	return page, nil
+}
+
+// Thread_Fetcher returns one page of the replies to rootID, paged the same way
+// as Message_Fetcher.
+func Thread_Fetcher(mongoClient *mongo.Client, userID string, rootID primitive.ObjectID, req models.Get_Message) (*models.Message_Page, error) {
 	collection := mongoClient.Database("chat-app").Collection("messages")
 
-	filter := bson.M{
-		"$or": []bson.M{
-			{"sender_id": senderID, "receiver_id": receiverID},
-			{"sender_id": receiverID, "receiver_id": senderID},
-		},
+	return fetchPage(collection, bson.M{
+		"thread_id":   rootID,
+		"deleted_for": bson.M{"$ne": userID},
+	}, req)
+}
+
+func fetchPage(collection *mongo.Collection, filter bson.M, req models.Get_Message) (*models.Message_Page, error) {
+	if req.Before != "" && req.After != "" {
+		return nil, fmt.Errorf("%w: before and after cannot be used together", ErrInvalidPage)
+	}
+
+	limit := req.Limit
+	if limit <= 0 {
+		limit = DefaultPageSize
+	}
+	if limit > MaxPageSize {
+		limit = MaxPageSize
+	}
+
+	// Without an after cursor we walk backwards from the newest (or the
+	// before cursor) and reverse the page afterwards.
+	forward := req.After != ""
+	sortOrder := -1
+	if forward {
+		sortOrder = 1
+		after, err := parseMessageCursor(req.After)
+		if err != nil {
+			return nil, err
+		}
+		filter["_id"] = bson.M{"$gt": after}
+	} else if req.Before != "" {
+		before, err := parseMessageCursor(req.Before)
+		if err != nil {
+			return nil, err
+		}
+		filter["_id"] = bson.M{"$lt": before}
 	}
 
-	cursor, err := collection.Find(context.Background(), filter)
+	findOptions := options.Find().
+		SetProjection(bson.M{"revisions": 0}).
+		SetSort(bson.M{"_id": sortOrder}).
+		SetLimit(limit + 1)
+
+	cursor, err := collection.Find(context.Background(), filter, findOptions)
 	if err != nil {
 		log.Println("Error finding messages:", err)
 		return nil, err
 	}
 	defer cursor.Close(context.Background())
 
-	var messages []models.Save_Message
+	messages := []models.Save_Message{}
 	for cursor.Next(context.Background()) {
 		var msg models.Save_Message
 		if err := cursor.Decode(&msg); err != nil {
 			log.Println("Error decoding message:", err)
 			return nil, err
 		}
+		msg.ReactionSummary = models.SummarizeReactions(msg.Reactions)
 		messages = append(messages, msg)
 	}
+	if err := cursor.Err(); err != nil {
+		return nil, err
+	}
+
+	page := &models.Message_Page{}
+	if int64(len(messages)) > limit {
+		messages = messages[:limit]
+		page.Next_Cursor = messages[len(messages)-1].ID.Hex()
+	}
+
+	if !forward {
+		for i, j := 0, len(messages)-1; i < j; i, j = i+1, j-1 {
+			messages[i], messages[j] = messages[j], messages[i]
+		}
+	}
+
+	page.Messages = messages
+	return page, nil
+}
 
+// countReplies sets ReplyCount on every message in the page that has thread
+// replies. Replies deleted for everyone are not counted.
+func countReplies(collection *mongo.Collection, messages []models.Save_Message) error {
 	if len(messages) == 0 {
-		return nil, fmt.Errorf("no messages found")
+		return nil
+	}
+
+	ids := make([]primitive.ObjectID, len(messages))
+	for i, msg := range messages {
+		ids[i] = msg.ID
 	}
 
-	return messages, nil
+	cursor, err := collection.Aggregate(context.Background(), mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{
+			"thread_id": bson.M{"$in": ids},
+			"deleted":   bson.M{"$ne": true},
+		}}},
+		{{Key: "$group", Value: bson.M{"_id": "$thread_id", "count": bson.M{"$sum": 1}}}},
+	})
+	if err != nil {
+		return err
+	}
+
+	var counts []struct {
+		ID    primitive.ObjectID `bson:"_id"`
+		Count int64              `bson:"count"`
+	}
+	if err := cursor.All(context.Background(), &counts); err != nil {
+		return err
+	}
+
+	byID := make(map[primitive.ObjectID]int64, len(counts))
+	for _, count := range counts {
+		byID[count.ID] = count.Count
+	}
+	for i := range messages {
+		messages[i].ReplyCount = byID[messages[i].ID]
+	}
+	return nil
+}
+
+// parseMessageCursor accepts a message ID or an RFC 3339 timestamp. A
+// timestamp is turned into the smallest ID for that second.
+func parseMessageCursor(cursor string) (primitive.ObjectID, error) {
+	if id, err := primitive.ObjectIDFromHex(cursor); err == nil {
+		return id, nil
+	}
+	if t, err := time.Parse(time.RFC3339, cursor); err == nil {
+		return primitive.NewObjectIDFromTimestamp(t), nil
+	}
+	return primitive.NilObjectID, fmt.Errorf("%w: invalid cursor %q", ErrInvalidPage, cursor)
 }
@@ -0,0 +1,36 @@
+package utils
+
+import (
+	"log"
+	"os"
+	"time"
+)
+
+// DefaultDeleteWindow is how long after sending a message its sender may
+// delete it for everyone.
+const DefaultDeleteWindow = 48 * time.Hour
+
+// DeleteWindow reads DELETE_FOR_EVERYONE_WINDOW, a duration such as "1h".
+// Zero removes the limit.
+func DeleteWindow() time.Duration {
+	return windowFromEnv("DELETE_FOR_EVERYONE_WINDOW", DefaultDeleteWindow)
+}
+
+// EditWindow reads MESSAGE_EDIT_WINDOW, how long after sending a message its
+// sender may edit it. Edits are unlimited unless it is set.
+func EditWindow() time.Duration {
+	return windowFromEnv("MESSAGE_EDIT_WINDOW", 0)
+}
+
+func windowFromEnv(key string, fallback time.Duration) time.Duration {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil || d < 0 {
+		log.Printf("Invalid %s %q, using %s", key, value, fallback)
+		return fallback
+	}
+	return d
+}
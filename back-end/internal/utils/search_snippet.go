@@ -0,0 +1,109 @@
+package utils
+
+import (
+	"strings"
+	"unicode"
+
+	"github.com/Ahmeds-Library/Chat-App/internal/models"
+)
+
+const (
+	// SnippetLength is the most characters of a message a snippet shows.
+	SnippetLength = 160
+	// snippetLead is how much text is kept before the first match.
+	snippetLead = 40
+)
+
+// SearchTerms returns the words and phrases of a text search query that a
+// matching message contains, lower-cased. Excluded words are left out.
+func SearchTerms(query string) []string {
+	var terms []string
+	for i, part := range strings.Split(query, `"`) {
+		// Odd parts were inside quotes.
+		if i%2 == 1 {
+			if phrase := strings.TrimSpace(part); phrase != "" {
+				terms = append(terms, strings.ToLower(phrase))
+			}
+			continue
+		}
+		for _, word := range strings.Fields(part) {
+			if strings.HasPrefix(word, "-") {
+				continue
+			}
+			if word = strings.TrimFunc(word, isWordBreak); word != "" {
+				terms = append(terms, strings.ToLower(word))
+			}
+		}
+	}
+	return terms
+}
+
+// Snippet cuts the part of text around the first match of terms and splits
+// it into matching and non-matching parts. Terms only match whole words,
+// ignoring case, as the text index does.
+func Snippet(text string, terms []string) []models.Snippet_Part {
+	runes := []rune(text)
+	lower := []rune(strings.ToLower(text))
+	// Lower-casing can change the length of some runes; fall back to the
+	// original text rather than misplace the matches.
+	if len(lower) != len(runes) {
+		lower = runes
+	}
+
+	matched := make([]bool, len(runes))
+	first := -1
+	for _, term := range terms {
+		termRunes := []rune(term)
+		for i := 0; i+len(termRunes) <= len(lower); i++ {
+			end := i + len(termRunes)
+			if string(lower[i:end]) != term {
+				continue
+			}
+			if (i > 0 && !isWordBreak(lower[i-1])) || (end < len(lower) && !isWordBreak(lower[end])) {
+				continue
+			}
+			for j := i; j < end; j++ {
+				matched[j] = true
+			}
+			if first == -1 || i < first {
+				first = i
+			}
+		}
+	}
+
+	start, end := 0, len(runes)
+	if len(runes) > SnippetLength {
+		start = max(0, first-snippetLead)
+		end = min(len(runes), start+SnippetLength)
+		start = max(0, end-SnippetLength)
+	}
+
+	parts := []models.Snippet_Part{}
+	if start > 0 {
+		parts = append(parts, models.Snippet_Part{Text: "…"})
+	}
+	for i := start; i < end; {
+		j := i
+		for j < end && matched[j] == matched[i] {
+			j++
+		}
+		parts = appendSnippetPart(parts, models.Snippet_Part{Text: string(runes[i:j]), Match: matched[i]})
+		i = j
+	}
+	if end < len(runes) {
+		parts = appendSnippetPart(parts, models.Snippet_Part{Text: "…"})
+	}
+	return parts
+}
+
+func appendSnippetPart(parts []models.Snippet_Part, part models.Snippet_Part) []models.Snippet_Part {
+	if last := len(parts) - 1; last >= 0 && !parts[last].Match && !part.Match {
+		parts[last].Text += part.Text
+		return parts
+	}
+	return append(parts, part)
+}
+
+func isWordBreak(r rune) bool {
+	return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+}
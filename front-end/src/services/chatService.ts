@@ -4,11 +4,20 @@ import MessageService from './messageService';
 import { Message, User } from '@/types/chat';
 
 interface ChatListItem {
-  partner_id: string;
-  partner_name: string;
-  partner_number: string;
+  conversation_id: string;
+  is_group: boolean;
+  group_name?: string;
+  partner_id?: string;
+  partner_name?: string;
+  partner_number?: string;
   last_message: string;
   last_message_at: string;
+  unread_count: number;
+}
+
+interface ChatListPage {
+  chats: ChatListItem[];
+  next_cursor?: string;
 }
 
 class ChatService {
@@ -35,31 +44,34 @@ class ChatService {
 
     try {
       console.log('📋 Fetching chat list...');
-      const response = await this.apiClient.getAxiosInstance().get('/chat_list');
-      
-      console.log('📋 Chat list response received:', response.data);
-      
-      const chatList = Array.isArray(response.data) ? response.data : [];
-      
-      // Remove duplicates by partner_id and keep only the latest message
-      const uniqueChats = new Map<string, ChatListItem>();
-      
-      chatList.forEach((item: ChatListItem) => {
-        const existingChat = uniqueChats.get(item.partner_id);
-        if (!existingChat || new Date(item.last_message_at) > new Date(existingChat.last_message_at)) {
-          uniqueChats.set(item.partner_id, item);
-        }
-      });
-      
-      const users: User[] = Array.from(uniqueChats.values()).map((item: ChatListItem) => ({
-        id: item.partner_id,
-        username: item.partner_name,
-        number: item.partner_number,
-        lastMessage: item.last_message,
-        lastMessageTime: new Date(item.last_message_at).toISOString(),
-        isOnline: false,
-        unreadCount: 0
-      }));
+      const chatList: ChatListItem[] = [];
+      let before: string | undefined;
+
+      // The list is paged, newest conversation first; follow next_cursor
+      // until the last page.
+      do {
+        const response: AxiosResponse<ChatListPage> = await this.apiClient
+          .getAxiosInstance()
+          .get('/chat_list', { params: before ? { before } : undefined });
+
+        console.log('📋 Chat list page received:', response.data);
+
+        chatList.push(...(response.data?.chats ?? []));
+        before = response.data?.next_cursor;
+      } while (before);
+
+      // Groups are not shown in this list yet.
+      const users: User[] = chatList
+        .filter((item) => !item.is_group && item.partner_id)
+        .map((item) => ({
+          id: item.partner_id as string,
+          username: item.partner_name ?? '',
+          number: item.partner_number ?? '',
+          lastMessage: item.last_message,
+          lastMessageTime: new Date(item.last_message_at).toISOString(),
+          isOnline: false,
+          unreadCount: item.unread_count ?? 0
+        }));
       
       console.log('✅ Chat list processed:', users.length, 'unique conversations');
       return users;
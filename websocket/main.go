@@ -1,9 +1,12 @@
 package main
 
 import (
+	"context"
+	"expvar"
 	"log"
 	"os"
 
+	"github.com/Ahmeds-Library/Chat-App/websocket_broker"
 	websocket_mongo "github.com/Ahmeds-Library/Chat-App/websocket_database/mongo"
 	websocket_postgres "github.com/Ahmeds-Library/Chat-App/websocket_database/postgres"
 	websocket "github.com/Ahmeds-Library/Chat-App/websocket_functions"
@@ -18,13 +21,44 @@ func main() {
 	if err := websocket_mongo.ConnectMongoDatabase(); err != nil {
 		log.Fatal("Mongo Init Error:", err)
 	}
+	if err := websocket_mongo.EnsureDeliveryCursorIndexes(context.Background()); err != nil {
+		log.Fatal("Mongo Index Error:", err)
+	}
 
 	websocket_postgres.ConnectPgAdminDatabase()
+	if err := websocket_postgres.EnsureLastSeenColumn(); err != nil {
+		log.Fatal("Postgres Schema Error:", err)
+	}
+
+	keys, err := websocket_utils.NewKeySetFromEnv()
+	if err != nil {
+		log.Fatal("JWT Key Error:", err)
+	}
+	websocket_utils.Keys = keys
+
+	broker, err := websocket_broker.NewFromEnv()
+	if err != nil {
+		log.Fatal("Broker Init Error:", err)
+	}
+	defer broker.Close()
+
+	config, err := websocket.ConfigFromEnv()
+	if err != nil {
+		log.Fatal("Config Error:", err)
+	}
+
+	hub, err := websocket.NewHub(websocket_utils.NodeID(), broker, config)
+	if err != nil {
+		log.Fatal("Hub Init Error:", err)
+	}
+	go hub.RelayMessageChanges(context.Background(), websocket_utils.RelayCheckpoint())
+	go hub.DisconnectRevokedSessions(context.Background())
 
-	hub := websocket.NewHub()
 	r := gin.Default()
 
 	r.GET("/ws", websocket_middleware.WebSocket_Middleware(), websocket.WebSocketHandler(hub))
+	r.GET("/debug/vars", gin.WrapH(expvar.Handler()))
+	r.GET("/presence", websocket_middleware.WebSocket_Middleware(), websocket.PresenceHandler(hub))
 
 	port := os.Getenv("WS_PORT")
 	if port == "" {
@@ -0,0 +1,44 @@
+package websocket_broker
+
+import (
+	"context"
+	"fmt"
+	"os"
+)
+
+// DeliverFunc hands a frame addressed to userID to the local node.
+type DeliverFunc func(userID string, frame []byte)
+
+// Broker routes encoded frames between websocket nodes so a frame reaches a
+// user whichever node holds their connections.
+type Broker interface {
+	// Subscribe starts delivering frames routed to nodeID.
+	Subscribe(ctx context.Context, nodeID string, deliver DeliverFunc) error
+	// Register records that nodeID holds at least one connection for userID.
+	Register(ctx context.Context, userID, nodeID string) error
+	// Unregister records that nodeID no longer holds any connection for userID.
+	Unregister(ctx context.Context, userID, nodeID string) error
+	// Publish sends frame to every node other than fromNode that holds a
+	// connection for userID.
+	Publish(ctx context.Context, userID, fromNode string, frame []byte) error
+	// Online reports which of userIDs have a connection on any node.
+	Online(ctx context.Context, userIDs []string) (map[string]bool, error)
+	Close() error
+}
+
+// NewFromEnv builds the broker selected by BROKER: "memory" (the default)
+// for a single node, or "redis" using REDIS_ADDR and REDIS_PASSWORD.
+func NewFromEnv() (Broker, error) {
+	switch kind := os.Getenv("BROKER"); kind {
+	case "", "memory":
+		return NewMemoryBroker(), nil
+	case "redis":
+		addr := os.Getenv("REDIS_ADDR")
+		if addr == "" {
+			return nil, fmt.Errorf("REDIS_ADDR is missing in .env")
+		}
+		return NewRedisBroker(addr, os.Getenv("REDIS_PASSWORD")), nil
+	default:
+		return nil, fmt.Errorf("unknown broker %q", kind)
+	}
+}
@@ -0,0 +1,91 @@
+package websocket_broker
+
+import (
+	"context"
+	"sync"
+)
+
+// MemoryBroker routes frames between hubs in the same process. It is the
+// default for a single node and lets several hubs share one broker locally.
+type MemoryBroker struct {
+	mu    sync.RWMutex
+	nodes map[string]DeliverFunc
+	users map[string]map[string]struct{}
+}
+
+func NewMemoryBroker() *MemoryBroker {
+	return &MemoryBroker{
+		nodes: make(map[string]DeliverFunc),
+		users: make(map[string]map[string]struct{}),
+	}
+}
+
+func (b *MemoryBroker) Subscribe(ctx context.Context, nodeID string, deliver DeliverFunc) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.nodes[nodeID] = deliver
+	return nil
+}
+
+func (b *MemoryBroker) Register(ctx context.Context, userID, nodeID string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	nodes, ok := b.users[userID]
+	if !ok {
+		nodes = make(map[string]struct{})
+		b.users[userID] = nodes
+	}
+	nodes[nodeID] = struct{}{}
+	return nil
+}
+
+func (b *MemoryBroker) Unregister(ctx context.Context, userID, nodeID string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	nodes, ok := b.users[userID]
+	if !ok {
+		return nil
+	}
+	delete(nodes, nodeID)
+	if len(nodes) == 0 {
+		delete(b.users, userID)
+	}
+	return nil
+}
+
+func (b *MemoryBroker) Publish(ctx context.Context, userID, fromNode string, frame []byte) error {
+	var targets []DeliverFunc
+
+	b.mu.RLock()
+	for nodeID := range b.users[userID] {
+		if nodeID == fromNode {
+			continue
+		}
+		if deliver, ok := b.nodes[nodeID]; ok {
+			targets = append(targets, deliver)
+		}
+	}
+	b.mu.RUnlock()
+
+	for _, deliver := range targets {
+		deliver(userID, frame)
+	}
+	return nil
+}
+
+func (b *MemoryBroker) Online(ctx context.Context, userIDs []string) (map[string]bool, error) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	online := make(map[string]bool, len(userIDs))
+	for _, userID := range userIDs {
+		online[userID] = len(b.users[userID]) > 0
+	}
+	return online, nil
+}
+
+func (b *MemoryBroker) Close() error {
+	return nil
+}
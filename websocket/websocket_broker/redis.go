@@ -0,0 +1,260 @@
+package websocket_broker
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	// RegistrationTTL is how long a registration outlives the node that
+	// made it, so a node that dies without cleaning up (or comes back under
+	// a new name) stops counting its users as online.
+	RegistrationTTL = time.Minute
+	// heartbeatInterval is how often a node renews its registrations.
+	heartbeatInterval = RegistrationTTL / 3
+)
+
+// RedisBroker routes frames between nodes with Redis pub/sub. Each node
+// listens on its own channel and a sorted set per user records which nodes
+// hold that user's connections, scored by when the registration expires.
+// Nodes renew their registrations while they are running.
+type RedisBroker struct {
+	client *redis.Client
+
+	mu     sync.Mutex
+	nodeID string
+	pubsub *redis.PubSub
+	users  map[string]struct{}
+	stop   chan struct{}
+}
+
+type redisEnvelope struct {
+	UserID string `json:"user_id"`
+	Frame  []byte `json:"frame"`
+}
+
+func NewRedisBroker(addr, password string) *RedisBroker {
+	return &RedisBroker{
+		client: redis.NewClient(&redis.Options{
+			Addr:     addr,
+			Password: password,
+		}),
+		users: make(map[string]struct{}),
+		stop:  make(chan struct{}),
+	}
+}
+
+func userNodesKey(userID string) string {
+	return "ws:user:" + userID + ":leases"
+}
+
+func nodeUsersKey(nodeID string) string {
+	return "ws:node:" + nodeID + ":users"
+}
+
+func nodeChannel(nodeID string) string {
+	return "ws:node:" + nodeID
+}
+
+func expiresAt(now time.Time) float64 {
+	return float64(now.Add(RegistrationTTL).UnixMilli())
+}
+
+func liveSince(now time.Time) string {
+	return strconv.FormatInt(now.UnixMilli(), 10)
+}
+
+// Subscribe clears any registrations left behind by a previous run of the
+// same node before it starts listening, and keeps this node's registrations
+// alive until Close.
+func (b *RedisBroker) Subscribe(ctx context.Context, nodeID string, deliver DeliverFunc) error {
+	if err := b.client.Ping(ctx).Err(); err != nil {
+		return err
+	}
+	if err := b.clearNode(ctx, nodeID); err != nil {
+		return err
+	}
+
+	pubsub := b.client.Subscribe(ctx, nodeChannel(nodeID))
+	if _, err := pubsub.Receive(ctx); err != nil {
+		pubsub.Close()
+		return err
+	}
+
+	b.mu.Lock()
+	b.nodeID = nodeID
+	b.pubsub = pubsub
+	b.mu.Unlock()
+
+	go b.heartbeat(nodeID)
+
+	go func() {
+		for msg := range pubsub.Channel() {
+			var env redisEnvelope
+			if err := json.Unmarshal([]byte(msg.Payload), &env); err != nil {
+				log.Println("Broker decode error:", err)
+				continue
+			}
+			deliver(env.UserID, env.Frame)
+		}
+	}()
+	return nil
+}
+
+func (b *RedisBroker) Register(ctx context.Context, userID, nodeID string) error {
+	b.mu.Lock()
+	b.users[userID] = struct{}{}
+	b.mu.Unlock()
+
+	_, err := b.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		b.renew(ctx, pipe, userID, nodeID, time.Now())
+		return nil
+	})
+	return err
+}
+
+func (b *RedisBroker) Unregister(ctx context.Context, userID, nodeID string) error {
+	b.mu.Lock()
+	delete(b.users, userID)
+	b.mu.Unlock()
+
+	_, err := b.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.ZRem(ctx, userNodesKey(userID), nodeID)
+		pipe.SRem(ctx, nodeUsersKey(nodeID), userID)
+		return nil
+	})
+	return err
+}
+
+func (b *RedisBroker) Publish(ctx context.Context, userID, fromNode string, frame []byte) error {
+	nodes, err := b.client.ZRangeByScore(ctx, userNodesKey(userID), &redis.ZRangeBy{
+		Min: liveSince(time.Now()),
+		Max: "+inf",
+	}).Result()
+	if err != nil {
+		return err
+	}
+
+	var payload []byte
+	for _, nodeID := range nodes {
+		if nodeID == fromNode {
+			continue
+		}
+		if payload == nil {
+			payload, err = json.Marshal(redisEnvelope{UserID: userID, Frame: frame})
+			if err != nil {
+				return err
+			}
+		}
+		if err := b.client.Publish(ctx, nodeChannel(nodeID), payload).Err(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (b *RedisBroker) Online(ctx context.Context, userIDs []string) (map[string]bool, error) {
+	since := liveSince(time.Now())
+	counts := make([]*redis.IntCmd, len(userIDs))
+	_, err := b.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for i, userID := range userIDs {
+			counts[i] = pipe.ZCount(ctx, userNodesKey(userID), since, "+inf")
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	online := make(map[string]bool, len(userIDs))
+	for i, userID := range userIDs {
+		online[userID] = counts[i].Val() > 0
+	}
+	return online, nil
+}
+
+// renew queues the commands that (re)register userID on nodeID for another
+// RegistrationTTL, dropping registrations of other nodes that have expired.
+func (b *RedisBroker) renew(ctx context.Context, pipe redis.Pipeliner, userID, nodeID string, now time.Time) {
+	key := userNodesKey(userID)
+	pipe.ZAdd(ctx, key, redis.Z{Score: expiresAt(now), Member: nodeID})
+	pipe.ZRemRangeByScore(ctx, key, "-inf", "("+liveSince(now))
+	pipe.Expire(ctx, key, RegistrationTTL)
+	pipe.SAdd(ctx, nodeUsersKey(nodeID), userID)
+	pipe.Expire(ctx, nodeUsersKey(nodeID), RegistrationTTL)
+}
+
+// heartbeat renews every registration of this node until Close. A renewal
+// racing with Unregister can leave one stale entry, which expires on its own.
+func (b *RedisBroker) heartbeat(nodeID string) {
+	ticker := time.NewTicker(heartbeatInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-b.stop:
+			return
+		case <-ticker.C:
+		}
+
+		b.mu.Lock()
+		users := make([]string, 0, len(b.users))
+		for userID := range b.users {
+			users = append(users, userID)
+		}
+		b.mu.Unlock()
+		if len(users) == 0 {
+			continue
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), heartbeatInterval)
+		now := time.Now()
+		_, err := b.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+			for _, userID := range users {
+				b.renew(ctx, pipe, userID, nodeID, now)
+			}
+			return nil
+		})
+		cancel()
+		if err != nil {
+			log.Println("Broker heartbeat error:", err)
+		}
+	}
+}
+
+func (b *RedisBroker) Close() error {
+	b.mu.Lock()
+	nodeID, pubsub := b.nodeID, b.pubsub
+	b.mu.Unlock()
+
+	if pubsub != nil {
+		close(b.stop)
+		pubsub.Close()
+		if err := b.clearNode(context.Background(), nodeID); err != nil {
+			log.Println("Broker cleanup error:", err)
+		}
+	}
+	return b.client.Close()
+}
+
+func (b *RedisBroker) clearNode(ctx context.Context, nodeID string) error {
+	users, err := b.client.SMembers(ctx, nodeUsersKey(nodeID)).Result()
+	if err != nil {
+		return err
+	}
+
+	_, err = b.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		for _, userID := range users {
+			pipe.ZRem(ctx, userNodesKey(userID), nodeID)
+		}
+		pipe.Del(ctx, nodeUsersKey(nodeID))
+		return nil
+	})
+	return err
+}
@@ -0,0 +1,77 @@
+package websocket_broker
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+func TestRedisRegistrationsExpire(t *testing.T) {
+	server := miniredis.RunT(t)
+	broker := NewRedisBroker(server.Addr(), "")
+	defer broker.Close()
+	ctx := context.Background()
+
+	if err := broker.Register(ctx, "1", "live"); err != nil {
+		t.Fatal(err)
+	}
+	// A node that died under another name left a registration it will never
+	// renew or remove.
+	if _, err := server.ZAdd(userNodesKey("2"), float64(time.Now().Add(-time.Second).UnixMilli()), "gone"); err != nil {
+		t.Fatal(err)
+	}
+
+	online, err := broker.Online(ctx, []string{"1", "2"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !online["1"] || online["2"] {
+		t.Fatalf("online = %v, want only 1", online)
+	}
+
+	listener := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	defer listener.Close()
+	pubsub := listener.Subscribe(ctx, nodeChannel("gone"))
+	defer pubsub.Close()
+	if _, err := pubsub.Receive(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := broker.Publish(ctx, "2", "live", []byte("{}")); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case msg := <-pubsub.Channel():
+		t.Fatalf("frame routed to an expired node: %s", msg.Payload)
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	ttl := server.TTL(userNodesKey("1"))
+	if ttl <= 0 || ttl > RegistrationTTL {
+		t.Fatalf("registration TTL = %s, want up to %s", ttl, RegistrationTTL)
+	}
+}
+
+func TestRedisUnregisterRemovesNode(t *testing.T) {
+	server := miniredis.RunT(t)
+	broker := NewRedisBroker(server.Addr(), "")
+	defer broker.Close()
+	ctx := context.Background()
+
+	broker.Register(ctx, "1", "a")
+	broker.Register(ctx, "1", "b")
+	if err := broker.Unregister(ctx, "1", "a"); err != nil {
+		t.Fatal(err)
+	}
+
+	members, err := server.ZMembers(userNodesKey("1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(members) != 1 || members[0] != "b" {
+		t.Fatalf("nodes = %v, want [b]", members)
+	}
+}
@@ -0,0 +1,86 @@
+package websocket_mongo
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/Ahmeds-Library/Chat-App/websocket_models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var ErrAttachmentNotFound = errors.New("attachment not found")
+
+// ClaimAttachment attaches a complete, unused upload to messageID. Only the
+// uploader can attach it, and only in the conversation it was uploaded to.
+func ClaimAttachment(attachmentID, uploaderID, conversationID string, messageID primitive.ObjectID) (*websocket_models.Message_Attachment, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	objID, err := primitive.ObjectIDFromHex(attachmentID)
+	if err != nil {
+		return nil, ErrAttachmentNotFound
+	}
+
+	var attachment struct {
+		ID       primitive.ObjectID `bson:"_id"`
+		FileName string             `bson:"file_name"`
+		MimeType string             `bson:"mime_type"`
+		Kind     string             `bson:"kind"`
+		Size     int64              `bson:"size"`
+		Checksum string             `bson:"checksum"`
+		Width    int                `bson:"width"`
+		Height   int                `bson:"height"`
+		Duration float64            `bson:"duration"`
+		Blurhash string             `bson:"blurhash"`
+		// Only whether there is one matters here.
+		Thumbnail *struct{} `bson:"thumbnail"`
+	}
+	err = AttachmentCollection.FindOneAndUpdate(ctx,
+		bson.M{
+			"_id":             objID,
+			"uploader_id":     uploaderID,
+			"conversation_id": conversationID,
+			"status":          "complete",
+			"message_id":      bson.M{"$exists": false},
+		},
+		bson.M{"$set": bson.M{"message_id": messageID}},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&attachment)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrAttachmentNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &websocket_models.Message_Attachment{
+		ID:           attachment.ID,
+		FileName:     attachment.FileName,
+		MimeType:     attachment.MimeType,
+		Kind:         attachment.Kind,
+		Size:         attachment.Size,
+		Checksum:     attachment.Checksum,
+		Width:        attachment.Width,
+		Height:       attachment.Height,
+		Duration:     attachment.Duration,
+		Blurhash:     attachment.Blurhash,
+		HasThumbnail: attachment.Thumbnail != nil,
+	}, nil
+}
+
+// ReleaseAttachment undoes ClaimAttachment when the message could not be
+// saved.
+func ReleaseAttachment(attachmentID primitive.ObjectID) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	_, err := AttachmentCollection.UpdateOne(ctx,
+		bson.M{"_id": attachmentID},
+		bson.M{"$unset": bson.M{"message_id": ""}},
+	)
+	return err
+}
@@ -0,0 +1,333 @@
+package websocket_mongo
+
+import (
+	"context"
+	"errors"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/Ahmeds-Library/Chat-App/websocket_models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	// Returned by standalone servers, which do not support change streams.
+	changeStreamUnsupportedCode = 40573
+	// Returned when a stored resume token has fallen off the oplog.
+	changeStreamHistoryLostCode = 286
+
+	pollInterval  = time.Second
+	pollBatchSize = 100
+	retryInterval = 5 * time.Second
+
+	// The change stream checkpoint is written after this many events or
+	// this long, whichever comes first. A crash replays at most the
+	// unsaved events; none are skipped.
+	checkpointBatchSize = 100
+	checkpointInterval  = 5 * time.Second
+)
+
+// LagWindow is how far behind the newest stamp or creation time seen a write
+// may still appear, covering clock differences between writers and slow
+// commits. Polling and replays read this far back again.
+const LagWindow = 30 * time.Second
+
+// MessageChangeHandler receives every message the back-end has created or
+// modified. The message's Change field says what happened.
+type MessageChangeHandler func(msg websocket_models.Save_Message)
+
+type streamCheckpoint struct {
+	ID          string   `bson:"_id"`
+	ResumeToken bson.Raw `bson:"resume_token,omitempty"`
+	// LastChangeAt is the newest change stamp the poller has passed on.
+	LastChangeAt time.Time `bson:"last_change_at,omitempty"`
+	UpdatedAt    time.Time `bson:"updated_at"`
+}
+
+// WatchMessages follows changes stamped on the messages collection by the
+// back-end and passes them to handle until ctx is cancelled. It uses a change
+// stream when MongoDB runs as a replica set and falls back to polling the
+// change stamps on a standalone server (or when MESSAGE_STREAM_MODE=poll).
+// Progress is checkpointed under name so a restart resumes where it stopped.
+func WatchMessages(ctx context.Context, name string, handle MessageChangeHandler) {
+	usePolling := os.Getenv("MESSAGE_STREAM_MODE") == "poll"
+
+	for ctx.Err() == nil {
+		var err error
+		if usePolling {
+			err = pollMessageChanges(ctx, name, handle)
+		} else {
+			err = watchChangeStream(ctx, name, handle)
+			if isCommandError(err, changeStreamUnsupportedCode) {
+				log.Println("Change streams unavailable, polling messages instead")
+				usePolling = true
+				continue
+			}
+		}
+
+		if err != nil && ctx.Err() == nil {
+			log.Println("Message stream error:", err)
+			select {
+			case <-ctx.Done():
+			case <-time.After(retryInterval):
+			}
+		}
+	}
+}
+
+func watchChangeStream(ctx context.Context, name string, handle MessageChangeHandler) error {
+	checkpoint, err := loadCheckpoint(ctx, name)
+	if err != nil {
+		return err
+	}
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"operationType": bson.M{"$in": bson.A{"insert", "update", "replace"}}}}},
+	}
+	opts := options.ChangeStream().SetFullDocument(options.UpdateLookup)
+	if checkpoint.ResumeToken != nil {
+		opts.SetStartAfter(checkpoint.ResumeToken)
+	}
+
+	stream, err := MessageCollection.Watch(ctx, pipeline, opts)
+	if isCommandError(err, changeStreamHistoryLostCode) {
+		log.Println("Message stream resume token expired, starting from now")
+		checkpoint.ResumeToken = nil
+		if err := saveCheckpoint(ctx, checkpoint); err != nil {
+			return err
+		}
+		stream, err = MessageCollection.Watch(ctx, pipeline, options.ChangeStream().SetFullDocument(options.UpdateLookup))
+	}
+	if err != nil {
+		return err
+	}
+	defer stream.Close(context.Background())
+
+	pending := 0
+	savedAt := time.Now()
+	// ctx may already be cancelled on the way out, so the final save
+	// uses its own.
+	defer func() {
+		if pending > 0 {
+			saveCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			if err := saveCheckpoint(saveCtx, checkpoint); err != nil {
+				log.Println("Message stream checkpoint error:", err)
+			}
+		}
+	}()
+
+	for stream.Next(ctx) {
+		var event struct {
+			OperationType     string                         `bson:"operationType"`
+			FullDocument      *websocket_models.Save_Message `bson:"fullDocument"`
+			UpdateDescription struct {
+				UpdatedFields bson.M `bson:"updatedFields"`
+			} `bson:"updateDescription"`
+		}
+		if err := stream.Decode(&event); err != nil {
+			return err
+		}
+
+		if event.FullDocument != nil && event.FullDocument.Change != nil && touchesChange(event.OperationType, event.UpdateDescription.UpdatedFields) {
+			handle(*event.FullDocument)
+		}
+
+		checkpoint.ResumeToken = stream.ResumeToken()
+		pending++
+		if pending >= checkpointBatchSize || time.Since(savedAt) >= checkpointInterval {
+			if err := saveCheckpoint(ctx, checkpoint); err != nil {
+				return err
+			}
+			pending = 0
+			savedAt = time.Now()
+		}
+	}
+	return stream.Err()
+}
+
+// touchesChange reports whether an event carries a new change stamp, which
+// filters out writes made by the websocket service itself.
+func touchesChange(operationType string, updatedFields bson.M) bool {
+	if operationType != "update" {
+		return true
+	}
+	for field := range updatedFields {
+		if field == "change" || strings.HasPrefix(field, "change.") {
+			return true
+		}
+	}
+	return false
+}
+
+func pollMessageChanges(ctx context.Context, name string, handle MessageChangeHandler) error {
+	_, err := MessageCollection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "change.at", Value: 1}, {Key: "change.id", Value: 1}, {Key: "_id", Value: 1}},
+	})
+	if err != nil {
+		return err
+	}
+
+	checkpoint, err := loadCheckpoint(ctx, name)
+	if err != nil {
+		return err
+	}
+	if checkpoint.LastChangeAt.IsZero() {
+		checkpoint.LastChangeAt = time.Now()
+	}
+
+	poller := newChangePoller(findChanges, handle, checkpoint.LastChangeAt)
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+	for {
+		moved, err := poller.poll(ctx)
+		if err != nil {
+			return err
+		}
+		if moved {
+			checkpoint.LastChangeAt = poller.newest
+			if err := saveCheckpoint(ctx, checkpoint); err != nil {
+				return err
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// changePosition orders change stamps: by time, then by change ID, then by
+// message, since one bulk update stamps many messages with the same change.
+type changePosition struct {
+	At        time.Time
+	ChangeID  primitive.ObjectID
+	MessageID primitive.ObjectID
+}
+
+// findChanges returns up to limit messages whose change stamp comes after
+// the position, in stamp order.
+func findChanges(ctx context.Context, after changePosition, limit int64) ([]websocket_models.Save_Message, error) {
+	filter := bson.M{
+		"$or": bson.A{
+			bson.M{"change.at": bson.M{"$gt": after.At}},
+			bson.M{"change.at": after.At, "change.id": bson.M{"$gt": after.ChangeID}},
+			bson.M{"change.at": after.At, "change.id": after.ChangeID, "_id": bson.M{"$gt": after.MessageID}},
+		},
+	}
+	findOptions := options.Find().
+		SetSort(bson.D{{Key: "change.at", Value: 1}, {Key: "change.id", Value: 1}, {Key: "_id", Value: 1}}).
+		SetLimit(limit)
+
+	cursor, err := MessageCollection.Find(ctx, filter, findOptions)
+	if err != nil {
+		return nil, err
+	}
+
+	var messages []websocket_models.Save_Message
+	if err := cursor.All(ctx, &messages); err != nil {
+		return nil, err
+	}
+	return messages, nil
+}
+
+type changeKey struct {
+	messageID primitive.ObjectID
+	changeID  primitive.ObjectID
+}
+
+// changePoller follows change stamps without a change stream. Stamps come
+// from each writer's clock and are taken before the write commits, so a
+// change can turn up stamped earlier than one already seen. Every poll
+// therefore reads again from LagWindow before the newest stamp, and
+// changes already passed on are skipped by message and change ID.
+type changePoller struct {
+	fetch  func(ctx context.Context, after changePosition, limit int64) ([]websocket_models.Save_Message, error)
+	handle MessageChangeHandler
+
+	// newest is the latest stamp passed on, which is what gets saved.
+	newest time.Time
+	// handled holds the changes passed on that are still inside the window.
+	handled map[changeKey]time.Time
+}
+
+func newChangePoller(fetch func(context.Context, changePosition, int64) ([]websocket_models.Save_Message, error), handle MessageChangeHandler, from time.Time) *changePoller {
+	return &changePoller{
+		fetch:   fetch,
+		handle:  handle,
+		newest:  from,
+		handled: make(map[changeKey]time.Time),
+	}
+}
+
+// poll passes on every change in the window it has not passed on yet, and
+// reports whether newest moved. After a restart the handled set is empty, so
+// up to LagWindow of changes are passed on again; nothing is skipped.
+func (p *changePoller) poll(ctx context.Context) (bool, error) {
+	start := p.newest
+	after := changePosition{At: p.newest.Add(-LagWindow)}
+
+	for {
+		messages, err := p.fetch(ctx, after, pollBatchSize)
+		if err != nil {
+			return false, err
+		}
+
+		for _, msg := range messages {
+			after = changePosition{At: msg.Change.At, ChangeID: msg.Change.ID, MessageID: msg.ID}
+			key := changeKey{messageID: msg.ID, changeID: msg.Change.ID}
+			if _, ok := p.handled[key]; ok {
+				continue
+			}
+			p.handle(msg)
+			p.handled[key] = msg.Change.At
+			if msg.Change.At.After(p.newest) {
+				p.newest = msg.Change.At
+			}
+		}
+
+		if len(messages) < pollBatchSize {
+			break
+		}
+	}
+
+	cutoff := p.newest.Add(-LagWindow)
+	for key, at := range p.handled {
+		if at.Before(cutoff) {
+			delete(p.handled, key)
+		}
+	}
+	return !p.newest.Equal(start), nil
+}
+
+func loadCheckpoint(ctx context.Context, name string) (*streamCheckpoint, error) {
+	checkpoint := &streamCheckpoint{ID: name}
+	err := CheckpointCollection.FindOne(ctx, bson.M{"_id": name}).Decode(checkpoint)
+	if err != nil && !errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, err
+	}
+	return checkpoint, nil
+}
+
+func saveCheckpoint(ctx context.Context, checkpoint *streamCheckpoint) error {
+	checkpoint.UpdatedAt = time.Now()
+	_, err := CheckpointCollection.ReplaceOne(ctx,
+		bson.M{"_id": checkpoint.ID},
+		checkpoint,
+		options.Replace().SetUpsert(true),
+	)
+	return err
+}
+
+func isCommandError(err error, code int32) bool {
+	var cmdErr mongo.CommandError
+	return errors.As(err, &cmdErr) && cmdErr.Code == code
+}
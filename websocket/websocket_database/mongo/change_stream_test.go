@@ -0,0 +1,116 @@
+package websocket_mongo
+
+import (
+	"context"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/Ahmeds-Library/Chat-App/websocket_models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// memoryChanges stands in for the messages collection, answering
+// findChanges queries from a slice.
+type memoryChanges struct {
+	messages []websocket_models.Save_Message
+}
+
+func (m *memoryChanges) stamp(at time.Time) primitive.ObjectID {
+	id := primitive.NewObjectID()
+	m.messages = append(m.messages, websocket_models.Save_Message{
+		ID:     id,
+		Change: &websocket_models.Message_Change{Kind: websocket_models.ChangeCreated, ID: primitive.NewObjectID(), At: at},
+	})
+	return id
+}
+
+func (m *memoryChanges) fetch(_ context.Context, after changePosition, limit int64) ([]websocket_models.Save_Message, error) {
+	position := func(msg websocket_models.Save_Message) changePosition {
+		return changePosition{At: msg.Change.At, ChangeID: msg.Change.ID, MessageID: msg.ID}
+	}
+	less := func(a, b changePosition) bool {
+		if !a.At.Equal(b.At) {
+			return a.At.Before(b.At)
+		}
+		if a.ChangeID != b.ChangeID {
+			return a.ChangeID.Hex() < b.ChangeID.Hex()
+		}
+		return a.MessageID.Hex() < b.MessageID.Hex()
+	}
+
+	var found []websocket_models.Save_Message
+	for _, msg := range m.messages {
+		if less(after, position(msg)) {
+			found = append(found, msg)
+		}
+	}
+	sort.Slice(found, func(i, j int) bool { return less(position(found[i]), position(found[j])) })
+	if int64(len(found)) > limit {
+		found = found[:limit]
+	}
+	return found, nil
+}
+
+func TestChangePollerPassesOnLateStamps(t *testing.T) {
+	start := time.Now()
+	changes := &memoryChanges{}
+	var handled []primitive.ObjectID
+	poller := newChangePoller(changes.fetch, func(msg websocket_models.Save_Message) {
+		handled = append(handled, msg.ID)
+	}, start)
+
+	first := changes.stamp(start.Add(10 * time.Second))
+	if moved, err := poller.poll(context.Background()); err != nil || !moved {
+		t.Fatalf("poll: moved %v, %v", moved, err)
+	}
+
+	// A writer with a slow clock commits a change stamped before the
+	// checkpoint.
+	late := changes.stamp(start.Add(5 * time.Second))
+	if moved, err := poller.poll(context.Background()); err != nil || moved {
+		t.Fatalf("poll: moved %v, %v", moved, err)
+	}
+	if !poller.newest.Equal(start.Add(10 * time.Second)) {
+		t.Fatalf("checkpoint moved back to %v", poller.newest)
+	}
+
+	if len(handled) != 2 || handled[0] != first || handled[1] != late {
+		t.Fatalf("handled %v, want %v then %v once each", handled, first, late)
+	}
+}
+
+func TestChangePollerPagesThroughWindow(t *testing.T) {
+	start := time.Now()
+	changes := &memoryChanges{}
+	count := 0
+	poller := newChangePoller(changes.fetch, func(websocket_models.Save_Message) { count++ }, start)
+
+	total := pollBatchSize*2 + 5
+	for i := 0; i < total; i++ {
+		changes.stamp(start.Add(time.Duration(i) * time.Millisecond))
+	}
+	for i := 0; i < 3; i++ {
+		if _, err := poller.poll(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if count != total {
+		t.Fatalf("handled %d changes, want %d", count, total)
+	}
+}
+
+func TestChangePollerForgetsChangesOutsideWindow(t *testing.T) {
+	start := time.Now()
+	changes := &memoryChanges{}
+	poller := newChangePoller(changes.fetch, func(websocket_models.Save_Message) {}, start)
+
+	changes.stamp(start.Add(time.Second))
+	changes.stamp(start.Add(time.Second + 2*LagWindow))
+	if _, err := poller.poll(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if len(poller.handled) != 1 {
+		t.Fatalf("kept %d handled changes, want only the one inside the window", len(poller.handled))
+	}
+}
@@ -0,0 +1,66 @@
+package websocket_mongo
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// GetContactIDs returns every user the given user has exchanged a message
+// with.
+func GetContactIDs(userID string) ([]string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	received, err := MessageCollection.Distinct(ctx, "receiver_id", bson.M{"sender_id": userID})
+	if err != nil {
+		return nil, err
+	}
+	sent, err := MessageCollection.Distinct(ctx, "sender_id", bson.M{"receiver_id": userID})
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[string]bool)
+	var contacts []string
+	for _, value := range append(received, sent...) {
+		id, ok := value.(string)
+		if !ok || id == userID || seen[id] {
+			continue
+		}
+		seen[id] = true
+		contacts = append(contacts, id)
+	}
+	return contacts, nil
+}
+
+// FilterContactIDs returns those of candidateIDs the given user has exchanged
+// a message with.
+func FilterContactIDs(userID string, candidateIDs []string) ([]string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	received, err := MessageCollection.Distinct(ctx, "receiver_id", bson.M{
+		"sender_id":   userID,
+		"receiver_id": bson.M{"$in": candidateIDs},
+	})
+	if err != nil {
+		return nil, err
+	}
+	sent, err := MessageCollection.Distinct(ctx, "sender_id", bson.M{
+		"receiver_id": userID,
+		"sender_id":   bson.M{"$in": candidateIDs},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var contacts []string
+	for _, value := range append(received, sent...) {
+		if id, ok := value.(string); ok {
+			contacts = append(contacts, id)
+		}
+	}
+	return contacts, nil
+}
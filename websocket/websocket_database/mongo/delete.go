@@ -0,0 +1,62 @@
+package websocket_mongo
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/Ahmeds-Library/Chat-App/websocket_models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+var ErrMessageNotFound = errors.New("message not found")
+
+func GetMessage(messageID primitive.ObjectID) (websocket_models.Save_Message, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var msg websocket_models.Save_Message
+	err := MessageCollection.FindOne(ctx, bson.M{"_id": messageID}).Decode(&msg)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return msg, ErrMessageNotFound
+	}
+	return msg, err
+}
+
+// HideMessage deletes a message for userID only.
+func HideMessage(messageID primitive.ObjectID, userID string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	_, err := MessageCollection.UpdateOne(ctx,
+		bson.M{"_id": messageID},
+		bson.M{"$addToSet": bson.M{"deleted_for": userID}},
+	)
+	return err
+}
+
+// TombstoneMessage deletes a message for everyone by clearing its text, edit
+// history, reactions and attachment. It reports whether the message was
+// changed, so callers only announce the first delete.
+func TombstoneMessage(messageID primitive.ObjectID, at time.Time) (bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	result, err := MessageCollection.UpdateOne(ctx,
+		bson.M{"_id": messageID, "deleted": bson.M{"$ne": true}},
+		bson.M{
+			"$set": bson.M{
+				"message":    "",
+				"deleted":    true,
+				"deleted_at": at,
+			},
+			"$unset": bson.M{"revisions": "", "reactions": "", "attachment": ""},
+		},
+	)
+	if err != nil {
+		return false, err
+	}
+	return result.ModifiedCount > 0, nil
+}
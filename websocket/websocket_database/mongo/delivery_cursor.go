@@ -0,0 +1,122 @@
+package websocket_mongo
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/Ahmeds-Library/Chat-App/websocket_models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// DeliveryCursorLifetime is how long the cursor of a session that stopped
+// acknowledging messages is kept.
+const DeliveryCursorLifetime = 90 * 24 * time.Hour
+
+func deliveryCursorID(userID, sessionID string) string {
+	return userID + ":" + sessionID
+}
+
+// EnsureDeliveryCursorIndexes expires cursors of sessions that are no longer
+// used.
+func EnsureDeliveryCursorIndexes(ctx context.Context) error {
+	_, err := CursorCollection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "updated_at", Value: 1}},
+		Options: options.Index().SetExpireAfterSeconds(int32(DeliveryCursorLifetime.Seconds())),
+	})
+	return err
+}
+
+// GetDeliveryCursor returns the creation time of the newest message the
+// session has acknowledged. A session without a cursor of its own starts
+// from the user's cursor from before cursors were kept per session, if any,
+// and otherwise gets the zero time.
+func GetDeliveryCursor(userID, sessionID string) (time.Time, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var cursor websocket_models.Delivery_Cursor
+	err := CursorCollection.FindOne(ctx, bson.M{"_id": deliveryCursorID(userID, sessionID)}).Decode(&cursor)
+	if err == nil {
+		return cursor.AckedAt, nil
+	}
+	if !errors.Is(err, mongo.ErrNoDocuments) {
+		return time.Time{}, err
+	}
+
+	err = CursorCollection.FindOne(ctx, bson.M{"_id": userID}).Decode(&cursor)
+	if errors.Is(err, mongo.ErrNoDocuments) || (err == nil && cursor.LastAckedID.IsZero()) {
+		return time.Time{}, nil
+	}
+	if err != nil {
+		return time.Time{}, err
+	}
+	return cursor.LastAckedID.Timestamp(), nil
+}
+
+// AdvanceDeliveryCursor moves the session's cursor forward to ackedAt. Acks
+// for older messages leave the cursor where it is.
+func AdvanceDeliveryCursor(userID, sessionID string, ackedAt time.Time) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	_, err := CursorCollection.UpdateOne(ctx,
+		bson.M{"_id": deliveryCursorID(userID, sessionID)},
+		bson.M{
+			"$max": bson.M{"acked_at": ackedAt},
+			"$set": bson.M{"user_id": userID, "session_id": sessionID, "updated_at": time.Now()},
+		},
+		options.Update().SetUpsert(true),
+	)
+	return err
+}
+
+// ReplayPosition is where a replay has got to, in creation order.
+type ReplayPosition struct {
+	CreatedAt time.Time
+	ID        primitive.ObjectID
+}
+
+// GetUndeliveredMessages returns up to limit messages addressed to the user,
+// directly or through one of conversationIDs, created after the position,
+// oldest first. The user's own group messages and messages they deleted for
+// themselves are left out.
+func GetUndeliveredMessages(userID string, conversationIDs []string, after ReplayPosition, limit int64) ([]websocket_models.Save_Message, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	addressed := bson.A{bson.M{"receiver_id": userID}}
+	if len(conversationIDs) > 0 {
+		addressed = append(addressed, bson.M{
+			"conversation_id": bson.M{"$in": conversationIDs},
+			"sender_id":       bson.M{"$ne": userID},
+		})
+	}
+	filter := bson.M{
+		"$and": bson.A{
+			bson.M{"$or": addressed},
+			bson.M{"$or": bson.A{
+				bson.M{"created_at": bson.M{"$gt": after.CreatedAt}},
+				bson.M{"created_at": after.CreatedAt, "_id": bson.M{"$gt": after.ID}},
+			}},
+		},
+		"deleted_for": bson.M{"$ne": userID},
+	}
+	findOptions := options.Find().
+		SetSort(bson.D{{Key: "created_at", Value: 1}, {Key: "_id", Value: 1}}).
+		SetLimit(limit)
+
+	cursor, err := MessageCollection.Find(ctx, filter, findOptions)
+	if err != nil {
+		return nil, err
+	}
+
+	var messages []websocket_models.Save_Message
+	if err := cursor.All(ctx, &messages); err != nil {
+		return nil, err
+	}
+	return messages, nil
+}
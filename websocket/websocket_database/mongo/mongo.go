@@ -16,6 +16,10 @@ import (
 
 var MongoClient *mongo.Client
 var MessageCollection *mongo.Collection
+var CheckpointCollection *mongo.Collection
+var CursorCollection *mongo.Collection
+var ReadCursorCollection *mongo.Collection
+var AttachmentCollection *mongo.Collection
 
 func ConnectMongoDatabase() error {
 	websocket_utils.LoadEnv()
@@ -55,11 +59,19 @@ func ConnectMongoDatabase() error {
 
 	MongoClient = client
 	MessageCollection = client.Database(MONGO_DB).Collection("messages")
+	CheckpointCollection = client.Database(MONGO_DB).Collection("stream_checkpoints")
+	CursorCollection = client.Database(MONGO_DB).Collection("delivery_cursors")
+	ReadCursorCollection = client.Database(MONGO_DB).Collection("read_cursors")
+	AttachmentCollection = client.Database(MONGO_DB).Collection("attachments")
 	return nil
 }
 
+// SaveMessage stores a new message, keeping its ID if the caller already
+// picked one.
 func SaveMessage(msg *websocket_models.Save_Message) error {
-	msg.ID = primitive.NewObjectID()
+	if msg.ID.IsZero() {
+		msg.ID = primitive.NewObjectID()
+	}
 	msg.CreatedAt = time.Now()
 
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
@@ -0,0 +1,59 @@
+package websocket_mongo
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/Ahmeds-Library/Chat-App/websocket_models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// AddReaction adds userID's emoji to a message unless they already reacted
+// with it. It returns the updated message and whether anything changed.
+func AddReaction(messageID primitive.ObjectID, userID, emoji string) (websocket_models.Save_Message, bool, error) {
+	return updateReactions(
+		bson.M{
+			"_id":       messageID,
+			"deleted":   bson.M{"$ne": true},
+			"reactions": bson.M{"$not": bson.M{"$elemMatch": bson.M{"user_id": userID, "emoji": emoji}}},
+		},
+		bson.M{"$push": bson.M{"reactions": websocket_models.Message_Reaction{UserID: userID, Emoji: emoji, At: time.Now()}}},
+		messageID,
+	)
+}
+
+// RemoveReaction removes userID's emoji from a message. It returns the
+// updated message and whether anything changed.
+func RemoveReaction(messageID primitive.ObjectID, userID, emoji string) (websocket_models.Save_Message, bool, error) {
+	return updateReactions(
+		bson.M{
+			"_id":       messageID,
+			"reactions": bson.M{"$elemMatch": bson.M{"user_id": userID, "emoji": emoji}},
+		},
+		bson.M{"$pull": bson.M{"reactions": bson.M{"user_id": userID, "emoji": emoji}}},
+		messageID,
+	)
+}
+
+func updateReactions(filter, update bson.M, messageID primitive.ObjectID) (websocket_models.Save_Message, bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var msg websocket_models.Save_Message
+	err := MessageCollection.FindOneAndUpdate(ctx, filter, update,
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&msg)
+	if err == nil {
+		return msg, true, nil
+	}
+	if !errors.Is(err, mongo.ErrNoDocuments) {
+		return msg, false, err
+	}
+
+	msg, err = GetMessage(messageID)
+	return msg, false, err
+}
@@ -0,0 +1,85 @@
+package websocket_mongo
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/Ahmeds-Library/Chat-App/websocket_models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+func readCursorID(conversationID, userID string) string {
+	return conversationID + ":" + userID
+}
+
+// MarkGroupDelivered moves the member's delivered cursor in the group forward
+// to upTo and returns the other members' messages it passed over.
+func MarkGroupDelivered(receiverID, conversationID string, upTo primitive.ObjectID) ([]websocket_models.Save_Message, error) {
+	previous, err := advanceReadCursor(receiverID, conversationID, bson.M{"delivered_up_to": upTo})
+	if err != nil {
+		return nil, err
+	}
+	return groupMessagesBetween(receiverID, conversationID, previous.DeliveredUpTo, upTo)
+}
+
+// MarkGroupRead moves the member's read cursor in the group forward to upTo
+// and returns the other members' messages it passed over. The delivered
+// cursor is moved along with it.
+func MarkGroupRead(readerID, conversationID string, upTo primitive.ObjectID) ([]websocket_models.Save_Message, error) {
+	previous, err := advanceReadCursor(readerID, conversationID, bson.M{"delivered_up_to": upTo, "read_up_to": upTo})
+	if err != nil {
+		return nil, err
+	}
+	return groupMessagesBetween(readerID, conversationID, previous.ReadUpTo, upTo)
+}
+
+// advanceReadCursor raises the given cursor fields and returns the cursor as
+// it was before. Cursors never move back.
+func advanceReadCursor(userID, conversationID string, upTo bson.M) (websocket_models.Read_Cursor, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var previous websocket_models.Read_Cursor
+	err := ReadCursorCollection.FindOneAndUpdate(ctx,
+		bson.M{"_id": readCursorID(conversationID, userID)},
+		bson.M{
+			"$max": upTo,
+			"$set": bson.M{"conversation_id": conversationID, "user_id": userID, "updated_at": time.Now()},
+		},
+		options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.Before),
+	).Decode(&previous)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return websocket_models.Read_Cursor{}, nil
+	}
+	return previous, err
+}
+
+// groupMessagesBetween returns the messages other members sent to the group
+// after from, up to and including upTo.
+func groupMessagesBetween(userID, conversationID string, from, upTo primitive.ObjectID) ([]websocket_models.Save_Message, error) {
+	if upTo.Hex() <= from.Hex() {
+		return nil, nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	cursor, err := MessageCollection.Find(ctx, bson.M{
+		"conversation_id": conversationID,
+		"sender_id":       bson.M{"$ne": userID},
+		"_id":             bson.M{"$gt": from, "$lte": upTo},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var messages []websocket_models.Save_Message
+	if err := cursor.All(ctx, &messages); err != nil {
+		return nil, err
+	}
+	return messages, nil
+}
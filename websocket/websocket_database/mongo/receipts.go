@@ -0,0 +1,71 @@
+package websocket_mongo
+
+import (
+	"context"
+	"time"
+
+	"github.com/Ahmeds-Library/Chat-App/websocket_models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// MarkDelivered marks every direct message to receiverID created up to and
+// including upTo as delivered, and returns the messages it changed.
+func MarkDelivered(receiverID string, upTo time.Time, at time.Time) ([]websocket_models.Save_Message, error) {
+	return markMessages(bson.M{
+		"receiver_id":  receiverID,
+		"created_at":   bson.M{"$lte": upTo},
+		"delivered_at": nil,
+	}, bson.M{"delivered_at": at})
+}
+
+// MarkRead marks every message addressed to readerID in the direct
+// conversation up to and including upTo as read, and returns the messages it
+// changed. Messages that were never marked delivered are marked delivered at
+// the same time. Groups use MarkGroupRead.
+func MarkRead(readerID, conversationID string, upTo primitive.ObjectID, at time.Time) ([]websocket_models.Save_Message, error) {
+	if _, err := markMessages(bson.M{
+		"conversation_id": conversationID,
+		"receiver_id":     readerID,
+		"_id":             bson.M{"$lte": upTo},
+		"delivered_at":    nil,
+	}, bson.M{"delivered_at": at}); err != nil {
+		return nil, err
+	}
+
+	return markMessages(bson.M{
+		"conversation_id": conversationID,
+		"receiver_id":     readerID,
+		"_id":             bson.M{"$lte": upTo},
+		"read_at":         nil,
+	}, bson.M{"read_at": at})
+}
+
+func markMessages(filter, set bson.M) ([]websocket_models.Save_Message, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	cursor, err := MessageCollection.Find(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	var messages []websocket_models.Save_Message
+	if err := cursor.All(ctx, &messages); err != nil {
+		return nil, err
+	}
+	if len(messages) == 0 {
+		return nil, nil
+	}
+
+	ids := make([]primitive.ObjectID, len(messages))
+	for i, msg := range messages {
+		ids[i] = msg.ID
+	}
+
+	_, err = MessageCollection.UpdateMany(ctx, bson.M{"_id": bson.M{"$in": ids}}, bson.M{"$set": set})
+	if err != nil {
+		return nil, err
+	}
+	return messages, nil
+}
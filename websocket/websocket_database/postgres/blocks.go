@@ -0,0 +1,36 @@
+package websocket_postgres
+
+// IsBlocked reports whether either user has blocked the other.
+func IsBlocked(userID, otherID string) (bool, error) {
+	var blocked bool
+	err := Db.QueryRow(`
+		SELECT EXISTS (
+			SELECT 1 FROM user_blocks
+			WHERE (blocker_id = $1 AND blocked_id = $2) OR (blocker_id = $2 AND blocked_id = $1)
+		)`, userID, otherID,
+	).Scan(&blocked)
+	return blocked, err
+}
+
+// GetBlockedUserIDs returns everyone the user has blocked or been blocked
+// by. Blocks are managed by the back-end.
+func GetBlockedUserIDs(userID string) (map[string]bool, error) {
+	rows, err := Db.Query(`
+		SELECT blocked_id FROM user_blocks WHERE blocker_id = $1
+		UNION
+		SELECT blocker_id FROM user_blocks WHERE blocked_id = $1`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	blocked := make(map[string]bool)
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		blocked[id] = true
+	}
+	return blocked, rows.Err()
+}
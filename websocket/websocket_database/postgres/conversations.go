@@ -0,0 +1,114 @@
+package websocket_postgres
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/lib/pq"
+)
+
+// ErrConversationNotFound is returned for a group that does not exist.
+var ErrConversationNotFound = errors.New("conversation not found")
+
+// GetConversationMemberIDs returns the IDs of every member of a group, none
+// if there is no such group, or ErrConversationNotFound if the ID cannot be
+// one.
+func GetConversationMemberIDs(conversationID string) ([]string, error) {
+	if _, err := strconv.Atoi(conversationID); err != nil {
+		return nil, ErrConversationNotFound
+	}
+
+	rows, err := Db.Query("SELECT user_id FROM conversation_members WHERE conversation_id = $1", conversationID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var members []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		members = append(members, id)
+	}
+	return members, rows.Err()
+}
+
+// GetUserConversationIDs returns the IDs of every group the user is in.
+func GetUserConversationIDs(userID string) ([]string, error) {
+	rows, err := Db.Query("SELECT conversation_id FROM conversation_members WHERE user_id = $1", userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var conversations []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		conversations = append(conversations, id)
+	}
+	return conversations, rows.Err()
+}
+
+// GetGroupPeerIDs returns the IDs of everyone who shares a group with the
+// user, not counting the user.
+func GetGroupPeerIDs(userID string) ([]string, error) {
+	rows, err := Db.Query(`
+		SELECT DISTINCT peer.user_id
+		FROM conversation_members self
+		JOIN conversation_members peer ON peer.conversation_id = self.conversation_id
+		WHERE self.user_id = $1 AND peer.user_id <> self.user_id`,
+		userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var peers []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		peers = append(peers, id)
+	}
+	return peers, rows.Err()
+}
+
+// FilterGroupPeerIDs returns those of candidateIDs that share a group with the
+// user.
+func FilterGroupPeerIDs(userID string, candidateIDs []string) ([]string, error) {
+	ids := make([]int64, 0, len(candidateIDs))
+	for _, candidateID := range candidateIDs {
+		id, err := strconv.ParseInt(candidateID, 10, 64)
+		if err != nil {
+			return nil, errors.New("invalid userID format: " + candidateID)
+		}
+		ids = append(ids, id)
+	}
+
+	rows, err := Db.Query(`
+		SELECT DISTINCT peer.user_id
+		FROM conversation_members self
+		JOIN conversation_members peer ON peer.conversation_id = self.conversation_id
+		WHERE self.user_id = $1 AND peer.user_id = ANY($2)`,
+		userID, pq.Array(ids))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var peers []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		peers = append(peers, id)
+	}
+	return peers, rows.Err()
+}
@@ -3,6 +3,7 @@ package websocket_postgres
 import (
 	"database/sql"
 	"errors"
+	"strconv"
 
 	"github.com/Ahmeds-Library/Chat-App/websocket_models"
 )
@@ -16,9 +17,11 @@ func InitDB(dataSourceName string) error {
 	return Db.Ping()
 }
 
+// GetUserByPhone returns the account that has confirmed the number. Signups
+// still waiting to confirm it cannot be messaged.
 func GetUserByPhone(phone string) (*websocket_models.User, error) {
 	var user websocket_models.User
-	row := Db.QueryRow("SELECT id, username, number FROM users WHERE number=$1", phone)
+	row := Db.QueryRow("SELECT id, username, number FROM users WHERE number=$1 AND phone_verified", phone)
 	if err := row.Scan(&user.ID, &user.Username, &user.Number); err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return nil, errors.New("user not found")
@@ -27,3 +30,22 @@ func GetUserByPhone(phone string) (*websocket_models.User, error) {
 	}
 	return &user, nil
 }
+
+// ErrUserNotFound is returned by GetUserID for an ID no user has.
+var ErrUserNotFound = errors.New("user not found")
+
+// GetUserID returns the ID of the user userID refers to as Postgres stores
+// it, so "01" and "+1" both come back as "1".
+func GetUserID(userID string) (string, error) {
+	idInt, err := strconv.Atoi(userID)
+	if err != nil {
+		return "", ErrUserNotFound
+	}
+
+	var id string
+	err = Db.QueryRow("SELECT id FROM users WHERE id = $1", idInt).Scan(&id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", ErrUserNotFound
+	}
+	return id, err
+}
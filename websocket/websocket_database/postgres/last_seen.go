@@ -0,0 +1,52 @@
+package websocket_postgres
+
+import (
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// EnsureLastSeenColumn adds users.last_seen if the back-end has not created it
+// yet. The back-end owns the rest of the schema; this is the only column the
+// websocket service writes.
+func EnsureLastSeenColumn() error {
+	_, err := Db.Exec("ALTER TABLE users ADD COLUMN IF NOT EXISTS last_seen TIMESTAMPTZ")
+	return err
+}
+
+func UpdateLastSeen(userID string, lastSeen time.Time) error {
+	_, err := Db.Exec("UPDATE users SET last_seen = $1 WHERE id = $2", lastSeen, userID)
+	return err
+}
+
+// GetLastSeen returns the stored last seen time for each of userIDs. Users who
+// have never disconnected are left out.
+func GetLastSeen(userIDs []string) (map[string]time.Time, error) {
+	ids := make([]int64, 0, len(userIDs))
+	for _, userID := range userIDs {
+		id, err := strconv.ParseInt(userID, 10, 64)
+		if err != nil {
+			return nil, errors.New("invalid userID format: " + userID)
+		}
+		ids = append(ids, id)
+	}
+
+	rows, err := Db.Query("SELECT id, last_seen FROM users WHERE id = ANY($1) AND last_seen IS NOT NULL", pq.Array(ids))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	lastSeen := make(map[string]time.Time)
+	for rows.Next() {
+		var id int64
+		var seen time.Time
+		if err := rows.Scan(&id, &seen); err != nil {
+			return nil, err
+		}
+		lastSeen[strconv.FormatInt(id, 10)] = seen
+	}
+	return lastSeen, rows.Err()
+}
@@ -12,12 +12,18 @@ import (
 
 var Db *sql.DB
 
+// connInfo is kept for connections that cannot come from the pool, such as
+// LISTEN.
+var connInfo string
+
 func ConnectPgAdminDatabase() {
 	websocket_utils.LoadEnv()
 
 	envdata := fmt.Sprintf("host=%s port=%s user=%s dbname=%s password=%s sslmode=disable",
 		os.Getenv("HOST"), os.Getenv("PORT"), os.Getenv("DB_USER"), os.Getenv("DB_NAME"), os.Getenv("PASSWORD"))
 
+	connInfo = envdata
+
 	var err error
 	Db, err = sql.Open("postgres", envdata)
 	if err != nil {
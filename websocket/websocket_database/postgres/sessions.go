@@ -0,0 +1,121 @@
+package websocket_postgres
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// sessionRevokedChannel must match the channel the back-end notifies on.
+const sessionRevokedChannel = "session_revoked"
+
+// The session listener waits between listenMinBackoff and listenMaxBackoff
+// before connecting again.
+const (
+	listenMinBackoff = time.Second
+	listenMaxBackoff = time.Minute
+)
+
+// SessionActive reports whether a session exists and has not been revoked.
+func SessionActive(sessionID string) (bool, error) {
+	var active bool
+	err := Db.QueryRow("SELECT revoked_at IS NULL FROM sessions WHERE id = $1", sessionID).Scan(&active)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	return active, err
+}
+
+// ActiveSessions reports which of sessionIDs exist and have not been
+// revoked.
+func ActiveSessions(sessionIDs []string) (map[string]bool, error) {
+	rows, err := Db.Query("SELECT id FROM sessions WHERE id = ANY($1) AND revoked_at IS NULL", pq.Array(sessionIDs))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	active := make(map[string]bool, len(sessionIDs))
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		active[id] = true
+	}
+	return active, rows.Err()
+}
+
+// ListenSessionRevocations calls fn for every session the back-end revokes
+// until ctx is cancelled. An empty sessionID means all of the user's
+// sessions. Notifications sent while not listening are lost, so resync is
+// called each time listening starts or resumes, to catch up on them. If
+// listening fails it starts over, waiting longer after each failure in a
+// row.
+func ListenSessionRevocations(ctx context.Context, fn func(userID, sessionID string), resync func()) {
+	backoff := listenMinBackoff
+	for {
+		listened, err := listenSessionRevocations(ctx, fn, resync)
+		if ctx.Err() != nil {
+			return
+		}
+		if listened {
+			backoff = listenMinBackoff
+		}
+		log.Println("Session listener stopped, retrying in", backoff, "-", err)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+		backoff = min(backoff*2, listenMaxBackoff)
+	}
+}
+
+// listenSessionRevocations listens until ctx is cancelled or the listener
+// fails. listened reports whether it got as far as listening.
+func listenSessionRevocations(ctx context.Context, fn func(userID, sessionID string), resync func()) (listened bool, err error) {
+	listener := pq.NewListener(connInfo, listenMinBackoff, listenMaxBackoff, func(event pq.ListenerEventType, err error) {
+		if err != nil {
+			log.Println("Session listener error:", err)
+		}
+	})
+	defer listener.Close()
+
+	if err := listener.Listen(sessionRevokedChannel); err != nil {
+		return false, err
+	}
+	resync()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return true, ctx.Err()
+		case notification, ok := <-listener.Notify:
+			if !ok {
+				return true, errors.New("listener closed")
+			}
+			// A nil notification follows a reconnect.
+			if notification == nil {
+				resync()
+				continue
+			}
+
+			var revoked struct {
+				UserID    string `json:"user_id"`
+				SessionID string `json:"session_id"`
+			}
+			if err := json.Unmarshal([]byte(notification.Extra), &revoked); err != nil {
+				log.Println("Session notification decode error:", err)
+				continue
+			}
+			fn(revoked.UserID, revoked.SessionID)
+		}
+	}
+}
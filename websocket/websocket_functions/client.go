@@ -2,70 +2,162 @@ package websocket
 
 import (
 	"log"
+	"sync"
 	"time"
 
-	websocket_database "github.com/Ahmeds-Library/Chat-App/websocket_database/mongo"
-	websocket_postgres "github.com/Ahmeds-Library/Chat-App/websocket_database/postgres"
-	"github.com/Ahmeds-Library/Chat-App/websocket_models"
 	"github.com/gorilla/websocket"
 )
 
+// CloseSessionRevoked is the close code sent to connections whose login
+// session was ended. Clients should not reconnect without logging in again.
+const CloseSessionRevoked = 4001
+
 type Client struct {
-	conn   *websocket.Conn
-	userID string
-	send   chan interface{}
+	id        string
+	conn      *websocket.Conn
+	userID    string
+	sessionID string
+	config    Config
+	send      chan []byte
+
+	// done is closed when the connection is shut down, so senders never block
+	// on a client that is no longer being written to.
+	done      chan struct{}
+	closeOnce sync.Once
+	// closeMessage is the close frame WritePump sends, set before done is
+	// closed.
+	closeMessage []byte
+}
+
+func newClient(conn *websocket.Conn, userID, sessionID string, config Config) *Client {
+	return &Client{
+		id:           newConnectionID(),
+		conn:         conn,
+		userID:       userID,
+		sessionID:    sessionID,
+		config:       config,
+		send:         make(chan []byte, config.SendBuffer),
+		done:         make(chan struct{}),
+		closeMessage: []byte{},
+	}
+}
+
+// close asks WritePump to send a close frame and drop the connection, which
+// in turn ends ReadPump and unregisters the client. It is safe to call more
+// than once.
+func (c *Client) close() {
+	c.closeOnce.Do(func() {
+		close(c.done)
+	})
+}
+
+// closeWithReason is close with a close code and reason for the client.
+func (c *Client) closeWithReason(code int, reason string) {
+	c.closeOnce.Do(func() {
+		c.closeMessage = websocket.FormatCloseMessage(code, reason)
+		close(c.done)
+	})
+}
+
+// enqueue queues a frame without blocking. When the send buffer is full the
+// frame is dropped and, under the disconnect policy, the client is evicted.
+func (c *Client) enqueue(data []byte) bool {
+	select {
+	case <-c.done:
+		return false
+	case c.send <- data:
+		return true
+	default:
+	}
+
+	droppedFrames.Add(1)
+	if c.config.SlowConsumerPolicy == SlowConsumerDisconnect {
+		log.Println("Evicting slow client:", c.userID, "connection:", c.id)
+		evictedClients.Add(1)
+		c.close()
+	}
+	return false
+}
+
+// enqueueWait queues a frame the client itself asked for, waiting for room in
+// the buffer until the connection closes.
+func (c *Client) enqueueWait(data []byte) bool {
+	select {
+	case <-c.done:
+		return false
+	case c.send <- data:
+		return true
+	}
 }
 
 func (c *Client) ReadPump(h *Hub) {
 	defer func() {
-		h.RemoveClient(c.userID)
-		c.conn.Close()
+		if h.RemoveClient(c) {
+			h.userDisconnected(c.userID)
+		}
+		c.close()
 	}()
 
-	for {
-		var input struct {
-			ReceiverNumber string `json:"receiver_number"`
-			Message        string `json:"message"`
-		}
+	c.conn.SetReadLimit(c.config.MaxMessageSize)
+	c.conn.SetReadDeadline(time.Now().Add(c.config.PongWait))
+	c.conn.SetPongHandler(func(string) error {
+		return c.conn.SetReadDeadline(time.Now().Add(c.config.PongWait))
+	})
 
-		if err := c.conn.ReadJSON(&input); err != nil {
-			log.Println("Read error:", err)
+	for {
+		_, data, err := c.conn.ReadMessage()
+		if err != nil {
+			if websocket.IsUnexpectedCloseError(err, websocket.CloseGoingAway, websocket.CloseNormalClosure) {
+				log.Println("Read error:", err)
+			}
 			break
 		}
+		c.conn.SetReadDeadline(time.Now().Add(c.config.PongWait))
 
-		receiverUser, err := websocket_postgres.GetUserByPhone(input.ReceiverNumber)
+		frame, err := decodeFrame(data)
 		if err != nil {
-			log.Println(" Receiver not found:", err)
+			c.sendError(frame.ID, err)
 			continue
 		}
 
-		if c.userID == receiverUser.ID {
-			log.Println(" Sender and receiver cannot be the same")
+		handler, ok := h.handler(frame.Type)
+		if !ok {
+			c.sendError(frame.ID, newFrameError(ErrCodeUnsupportedType, "unsupported frame type %q", frame.Type))
 			continue
 		}
 
-		msg := &websocket_models.Save_Message{
-			SenderID:   c.userID,
-			ReceiverID: receiverUser.ID,
-			Message:    input.Message,
-			CreatedAt:  time.Now(),
-		}
-
-		if err := websocket_database.SaveMessage(msg); err != nil {
-			log.Println("Mongo Save Error:", err)
-			continue
+		if err := handler(h, c, frame); err != nil {
+			c.sendError(frame.ID, err)
 		}
-
-		h.SendToUser(receiverUser.ID, msg)
 	}
 }
 
 func (c *Client) WritePump() {
-	for msg := range c.send {
-		if err := c.conn.WriteJSON(msg); err != nil {
-			log.Println("Write error:", err)
-			break
+	ticker := time.NewTicker(c.config.PingInterval)
+	defer func() {
+		ticker.Stop()
+		c.close()
+		c.conn.Close()
+	}()
+
+	for {
+		select {
+		case <-c.done:
+			c.conn.SetWriteDeadline(time.Now().Add(c.config.WriteWait))
+			c.conn.WriteMessage(websocket.CloseMessage, c.closeMessage)
+			return
+		case msg := <-c.send:
+			c.conn.SetWriteDeadline(time.Now().Add(c.config.WriteWait))
+			if err := c.conn.WriteMessage(websocket.TextMessage, msg); err != nil {
+				log.Println("Write error:", err)
+				return
+			}
+		case <-ticker.C:
+			c.conn.SetWriteDeadline(time.Now().Add(c.config.WriteWait))
+			if err := c.conn.WriteMessage(websocket.PingMessage, nil); err != nil {
+				log.Println("Ping error:", err)
+				return
+			}
 		}
 	}
-	c.conn.Close()
 }
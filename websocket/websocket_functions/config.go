@@ -0,0 +1,92 @@
+package websocket
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+)
+
+// Policies for a client whose send buffer is full.
+const (
+	SlowConsumerDrop       = "drop"
+	SlowConsumerDisconnect = "disconnect"
+)
+
+// Config holds the connection keepalive and buffering settings.
+type Config struct {
+	// PongWait is how long a connection may stay silent before it is closed.
+	PongWait time.Duration
+	// PingInterval must be shorter than PongWait so a healthy client always
+	// has a pong in flight.
+	PingInterval time.Duration
+	// WriteWait bounds every write to the connection.
+	WriteWait time.Duration
+	// MaxMessageSize is the largest frame accepted from a client, in bytes.
+	MaxMessageSize int64
+	// SendBuffer is the number of frames queued per connection.
+	SendBuffer int
+	// SlowConsumerPolicy is SlowConsumerDrop or SlowConsumerDisconnect.
+	SlowConsumerPolicy string
+}
+
+func DefaultConfig() Config {
+	return Config{
+		PongWait:           60 * time.Second,
+		PingInterval:       54 * time.Second,
+		WriteWait:          10 * time.Second,
+		MaxMessageSize:     64 * 1024,
+		SendBuffer:         256,
+		SlowConsumerPolicy: SlowConsumerDisconnect,
+	}
+}
+
+// ConfigFromEnv overrides the defaults with WS_PONG_WAIT, WS_PING_INTERVAL,
+// WS_WRITE_WAIT (durations such as "30s"), WS_MAX_MESSAGE_SIZE,
+// WS_SEND_BUFFER and WS_SLOW_CONSUMER_POLICY.
+func ConfigFromEnv() (Config, error) {
+	cfg := DefaultConfig()
+
+	durations := map[string]*time.Duration{
+		"WS_PONG_WAIT":     &cfg.PongWait,
+		"WS_PING_INTERVAL": &cfg.PingInterval,
+		"WS_WRITE_WAIT":    &cfg.WriteWait,
+	}
+	for key, target := range durations {
+		if value := os.Getenv(key); value != "" {
+			d, err := time.ParseDuration(value)
+			if err != nil || d <= 0 {
+				return cfg, fmt.Errorf("invalid %s: %q", key, value)
+			}
+			*target = d
+		}
+	}
+
+	if value := os.Getenv("WS_MAX_MESSAGE_SIZE"); value != "" {
+		size, err := strconv.ParseInt(value, 10, 64)
+		if err != nil || size <= 0 {
+			return cfg, fmt.Errorf("invalid WS_MAX_MESSAGE_SIZE: %q", value)
+		}
+		cfg.MaxMessageSize = size
+	}
+
+	if value := os.Getenv("WS_SEND_BUFFER"); value != "" {
+		size, err := strconv.Atoi(value)
+		if err != nil || size <= 0 {
+			return cfg, fmt.Errorf("invalid WS_SEND_BUFFER: %q", value)
+		}
+		cfg.SendBuffer = size
+	}
+
+	if value := os.Getenv("WS_SLOW_CONSUMER_POLICY"); value != "" {
+		if value != SlowConsumerDrop && value != SlowConsumerDisconnect {
+			return cfg, fmt.Errorf("invalid WS_SLOW_CONSUMER_POLICY: %q", value)
+		}
+		cfg.SlowConsumerPolicy = value
+	}
+
+	if cfg.PingInterval >= cfg.PongWait {
+		return cfg, fmt.Errorf("WS_PING_INTERVAL (%s) must be shorter than WS_PONG_WAIT (%s)", cfg.PingInterval, cfg.PongWait)
+	}
+	return cfg, nil
+}
@@ -0,0 +1,157 @@
+package websocket
+
+import (
+	"errors"
+	"slices"
+
+	websocket_postgres "github.com/Ahmeds-Library/Chat-App/websocket_database/postgres"
+	"github.com/Ahmeds-Library/Chat-App/websocket_models"
+	"github.com/Ahmeds-Library/Chat-App/websocket_utils"
+)
+
+// lookupUserID returns a user's ID as stored, see websocket_postgres.GetUserID.
+var lookupUserID = websocket_postgres.GetUserID
+
+// conversationMembers returns a group's members, see
+// websocket_postgres.GetConversationMemberIDs.
+var conversationMembers = websocket_postgres.GetConversationMemberIDs
+
+// conversationTarget is the conversation a client frame addresses.
+type conversationTarget struct {
+	ID string
+	// ReceiverID is the partner in a direct conversation and empty for groups.
+	ReceiverID string
+	// Recipients is every participant other than the sender.
+	Recipients []string
+}
+
+// resolveConversation works out which conversation a frame addresses:
+// conversationID when it is set, otherwise the direct conversation with
+// partnerNumber.
+func resolveConversation(userID, partnerNumber, conversationID string) (*conversationTarget, error) {
+	if conversationID != "" {
+		if websocket_utils.IsDirectConversation(conversationID) {
+			partnerID, ok := websocket_utils.DirectConversationPartner(conversationID, userID)
+			if !ok {
+				return nil, newFrameError(ErrCodeNotMember, "not a participant in this conversation")
+			}
+
+			partnerID, err := lookupUserID(partnerID)
+			if errors.Is(err, websocket_postgres.ErrUserNotFound) {
+				return nil, newFrameError(ErrCodeReceiverNotFound, "receiver not found")
+			}
+			if err != nil {
+				return nil, err
+			}
+			// Postgres parses the ID, so "01" finds user 1: only accept the ID
+			// the partner's real ID produces, and never a chat with oneself.
+			canonicalID := websocket_utils.DirectConversationID(userID, partnerID)
+			if canonicalID != conversationID || partnerID == userID {
+				return nil, newFrameError(ErrCodeNotMember, "not a participant in this conversation")
+			}
+			return &conversationTarget{
+				ID:         canonicalID,
+				ReceiverID: partnerID,
+				Recipients: []string{partnerID},
+			}, nil
+		}
+
+		recipients, err := groupRecipients(conversationID, userID)
+		if err != nil {
+			return nil, err
+		}
+		return &conversationTarget{ID: conversationID, Recipients: recipients}, nil
+	}
+
+	partner, err := websocket_postgres.GetUserByPhone(partnerNumber)
+	if err != nil {
+		return nil, newFrameError(ErrCodeReceiverNotFound, "receiver not found")
+	}
+	if userID == partner.ID {
+		return nil, newFrameError(ErrCodeInvalidReceiver, "sender and receiver cannot be the same")
+	}
+	return &conversationTarget{
+		ID:         websocket_utils.DirectConversationID(userID, partner.ID),
+		ReceiverID: partner.ID,
+		Recipients: []string{partner.ID},
+	}, nil
+}
+
+// requireNotBlocked returns a blocked error when either user has blocked the
+// other.
+func requireNotBlocked(userID, partnerID string) error {
+	blocked, err := websocket_postgres.IsBlocked(userID, partnerID)
+	if err != nil {
+		return err
+	}
+	if blocked {
+		return newFrameError(ErrCodeBlocked, "you cannot message this user")
+	}
+	return nil
+}
+
+// withoutBlocked drops the users who have blocked userID, or been blocked by
+// them, from userIDs.
+func withoutBlocked(userID string, userIDs []string) ([]string, error) {
+	blocked, err := websocket_postgres.GetBlockedUserIDs(userID)
+	if err != nil || len(blocked) == 0 {
+		return userIDs, err
+	}
+
+	kept := make([]string, 0, len(userIDs))
+	for _, id := range userIDs {
+		if !blocked[id] {
+			kept = append(kept, id)
+		}
+	}
+	return kept, nil
+}
+
+// groupRecipients returns every member of the group other than senderID, or
+// a not_member error if senderID is not in it or there is no such group.
+// Lookup failures are returned as they are, so they reach the client as
+// internal errors.
+func groupRecipients(conversationID, senderID string) ([]string, error) {
+	members, err := conversationMembers(conversationID)
+	if errors.Is(err, websocket_postgres.ErrConversationNotFound) {
+		return nil, newFrameError(ErrCodeNotMember, "not a member of this conversation")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	recipients := without(members, senderID)
+	if len(recipients) == len(members) {
+		return nil, newFrameError(ErrCodeNotMember, "not a member of this conversation")
+	}
+	return recipients, nil
+}
+
+// addressedTo reports whether msg was sent to userID: as the receiver of a
+// direct message, or as a member of the group it was sent to by someone
+// else.
+func addressedTo(msg websocket_models.Save_Message, userID string) (bool, error) {
+	if msg.SenderID == userID {
+		return false, nil
+	}
+	if msg.ReceiverID != "" {
+		return msg.ReceiverID == userID, nil
+	}
+	members, err := conversationMembers(msg.ConversationID)
+	if errors.Is(err, websocket_postgres.ErrConversationNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return slices.Contains(members, userID), nil
+}
+
+// participants returns everyone who can see msg: both users of a direct
+// message, or every member of a group.
+func participants(msg websocket_models.Save_Message) ([]string, error) {
+	if msg.ReceiverID != "" {
+		return []string{msg.SenderID, msg.ReceiverID}, nil
+	}
+	return websocket_postgres.GetConversationMemberIDs(msg.ConversationID)
+}
@@ -0,0 +1,148 @@
+package websocket
+
+import (
+	"errors"
+	"strconv"
+	"testing"
+
+	websocket_postgres "github.com/Ahmeds-Library/Chat-App/websocket_database/postgres"
+	"github.com/Ahmeds-Library/Chat-App/websocket_models"
+)
+
+// useUsers makes lookupUserID find the given users, parsing IDs the way
+// Postgres casts them to integers.
+func useUsers(t *testing.T, ids ...string) {
+	t.Helper()
+	users := make(map[int64]bool)
+	for _, id := range ids {
+		n, _ := strconv.ParseInt(id, 10, 64)
+		users[n] = true
+	}
+
+	old := lookupUserID
+	lookupUserID = func(userID string) (string, error) {
+		n, err := strconv.ParseInt(userID, 10, 64)
+		if err != nil || !users[n] {
+			return "", websocket_postgres.ErrUserNotFound
+		}
+		return strconv.FormatInt(n, 10), nil
+	}
+	t.Cleanup(func() { lookupUserID = old })
+}
+
+func TestResolveDirectConversation(t *testing.T) {
+	useUsers(t, "1", "2")
+
+	target, err := resolveConversation("1", "", "dm:1:2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if target.ID != "dm:1:2" || target.ReceiverID != "2" || len(target.Recipients) != 1 || target.Recipients[0] != "2" {
+		t.Fatalf("got %+v", target)
+	}
+}
+
+func TestResolveDirectConversationRejectsNonCanonicalIDs(t *testing.T) {
+	useUsers(t, "1", "2")
+
+	for _, id := range []string{
+		"dm:1:01", // would become the self-chat dm:1:1
+		"dm:1:02",
+		"dm:1:+2",
+		"dm:+2:1",
+		"dm:2:1",
+		"dm:1:1",
+	} {
+		_, err := resolveConversation("1", "", id)
+		var frameErr *FrameError
+		if !errors.As(err, &frameErr) || frameErr.Code != ErrCodeNotMember {
+			t.Errorf("%s: got %v, want a not_member error", id, err)
+		}
+	}
+}
+
+func TestResolveDirectConversationUnknownPartner(t *testing.T) {
+	useUsers(t, "1")
+
+	_, err := resolveConversation("1", "", "dm:1:2")
+	var frameErr *FrameError
+	if !errors.As(err, &frameErr) || frameErr.Code != ErrCodeReceiverNotFound {
+		t.Fatalf("got %v, want a receiver_not_found error", err)
+	}
+}
+
+var lookupErr = errors.New("connection refused")
+
+// useGroups makes group 7 hold users 1, 2 and 3, makes the lookup for
+// group 8 fail and treats "x" as an ID no group can have.
+func useGroups(t *testing.T) {
+	old := conversationMembers
+	conversationMembers = func(conversationID string) ([]string, error) {
+		switch conversationID {
+		case "7":
+			return []string{"1", "2", "3"}, nil
+		case "8":
+			return nil, lookupErr
+		case "x":
+			return nil, websocket_postgres.ErrConversationNotFound
+		}
+		return nil, nil
+	}
+	t.Cleanup(func() { conversationMembers = old })
+}
+
+func TestGroupRecipients(t *testing.T) {
+	useGroups(t)
+
+	recipients, err := groupRecipients("7", "1")
+	if err != nil || len(recipients) != 2 {
+		t.Fatalf("member: %v, %v", recipients, err)
+	}
+
+	for _, tc := range []struct{ conversationID, senderID string }{
+		{"7", "4"}, // not in the group
+		{"9", "1"}, // no such group
+		{"x", "1"}, // not a group ID
+	} {
+		_, err := groupRecipients(tc.conversationID, tc.senderID)
+		var frameErr *FrameError
+		if !errors.As(err, &frameErr) || frameErr.Code != ErrCodeNotMember {
+			t.Errorf("%+v: got %v, want a not_member error", tc, err)
+		}
+	}
+
+	// A failed lookup says nothing about membership.
+	_, err = groupRecipients("8", "1")
+	var frameErr *FrameError
+	if !errors.Is(err, lookupErr) || errors.As(err, &frameErr) {
+		t.Fatalf("failed lookup: got %v, want the lookup error", err)
+	}
+}
+
+func TestAddressedTo(t *testing.T) {
+	useGroups(t)
+
+	for _, tc := range []struct {
+		msg    websocket_models.Save_Message
+		userID string
+		want   bool
+	}{
+		{websocket_models.Save_Message{SenderID: "1", ReceiverID: "2"}, "2", true},
+		{websocket_models.Save_Message{SenderID: "1", ReceiverID: "2"}, "3", false},
+		{websocket_models.Save_Message{SenderID: "1", ReceiverID: "2"}, "1", false},
+		{websocket_models.Save_Message{SenderID: "1", ConversationID: "7"}, "2", true},
+		{websocket_models.Save_Message{SenderID: "1", ConversationID: "7"}, "1", false},
+		{websocket_models.Save_Message{SenderID: "1", ConversationID: "7"}, "4", false},
+		{websocket_models.Save_Message{SenderID: "1", ConversationID: "x"}, "2", false},
+	} {
+		got, err := addressedTo(tc.msg, tc.userID)
+		if err != nil || got != tc.want {
+			t.Errorf("%+v for %s: got %v, %v, want %v", tc.msg, tc.userID, got, err, tc.want)
+		}
+	}
+
+	msg := websocket_models.Save_Message{SenderID: "1", ConversationID: "8"}
+	if _, err := addressedTo(msg, "2"); !errors.Is(err, lookupErr) {
+		t.Fatalf("failed lookup: got %v, want the lookup error", err)
+	}
+}
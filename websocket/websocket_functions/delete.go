@@ -0,0 +1,94 @@
+package websocket
+
+import (
+	"errors"
+	"slices"
+	"time"
+
+	websocket_mongo "github.com/Ahmeds-Library/Chat-App/websocket_database/mongo"
+	"github.com/Ahmeds-Library/Chat-App/websocket_models"
+	"github.com/Ahmeds-Library/Chat-App/websocket_utils"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// handleDeleteFrame deletes a message for the client's user only, or, when
+// they sent it and the delete window has not passed, for everyone. A delete
+// for me is echoed to the user's other connections; a delete for everyone
+// goes to every participant.
+func handleDeleteFrame(h *Hub, c *Client, frame websocket_models.Envelope) error {
+	var input websocket_models.Delete_Payload
+	if err := decodePayload(frame, &input); err != nil {
+		return err
+	}
+
+	if input.Scope != websocket_models.DeleteForMe && input.Scope != websocket_models.DeleteForEveryone {
+		return newFrameError(ErrCodeBadRequest, "scope must be %q or %q", websocket_models.DeleteForMe, websocket_models.DeleteForEveryone)
+	}
+
+	messageID, err := primitive.ObjectIDFromHex(input.MessageID)
+	if err != nil {
+		return newFrameError(ErrCodeBadRequest, "invalid message ID")
+	}
+
+	msg, err := websocket_mongo.GetMessage(messageID)
+	if errors.Is(err, websocket_mongo.ErrMessageNotFound) {
+		return newFrameError(ErrCodeNotFound, "message not found")
+	}
+	if err != nil {
+		return err
+	}
+
+	users, err := participants(msg)
+	if err != nil {
+		return err
+	}
+	if !slices.Contains(users, c.userID) {
+		return newFrameError(ErrCodeNotMember, "not a participant in this conversation")
+	}
+
+	now := time.Now()
+	if input.Scope == websocket_models.DeleteForMe {
+		if err := websocket_mongo.HideMessage(messageID, c.userID); err != nil {
+			return err
+		}
+		c.sendAck(frame.ID, websocket_models.Ack_Payload{MessageID: input.MessageID})
+		h.SendFrameToUser(c.userID, websocket_models.FrameDeleted, input.MessageID, websocket_models.Deleted_Payload{
+			MessageID:      input.MessageID,
+			ConversationID: msg.ConversationID,
+			Scope:          websocket_models.DeleteForMe,
+			DeletedAt:      now,
+		})
+		return nil
+	}
+
+	if msg.SenderID != c.userID {
+		return newFrameError(ErrCodeForbidden, "only the sender can delete a message for everyone")
+	}
+	if window := websocket_utils.DeleteWindow(); window > 0 && now.Sub(msg.CreatedAt) > window {
+		return newFrameError(ErrCodeForbidden, "messages can be deleted for everyone up to %s after sending", window)
+	}
+
+	changed, err := websocket_mongo.TombstoneMessage(messageID, now)
+	if err != nil {
+		return err
+	}
+	c.sendAck(frame.ID, websocket_models.Ack_Payload{MessageID: input.MessageID})
+	if changed {
+		msg.DeletedAt = &now
+		h.SendFrameToUsers(users, websocket_models.FrameDeleted, input.MessageID, deletedPayload(msg))
+	}
+	return nil
+}
+
+// deletedPayload describes a message deleted for everyone.
+func deletedPayload(msg websocket_models.Save_Message) websocket_models.Deleted_Payload {
+	payload := websocket_models.Deleted_Payload{
+		MessageID:      msg.ID.Hex(),
+		ConversationID: msg.ConversationID,
+		Scope:          websocket_models.DeleteForEveryone,
+	}
+	if msg.DeletedAt != nil {
+		payload.DeletedAt = *msg.DeletedAt
+	}
+	return payload
+}
@@ -0,0 +1,133 @@
+package websocket
+
+import (
+	"errors"
+	"log"
+	"strings"
+	"time"
+
+	websocket_database "github.com/Ahmeds-Library/Chat-App/websocket_database/mongo"
+	"github.com/Ahmeds-Library/Chat-App/websocket_models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func registerDefaultHandlers(h *Hub) {
+	h.Handle(websocket_models.FrameMessage, handleMessageFrame)
+	h.Handle(websocket_models.FrameAck, handleAckFrame)
+	h.Handle(websocket_models.FrameRead, handleReadFrame)
+	h.Handle(websocket_models.FrameTypingStart, handleTypingFrame)
+	h.Handle(websocket_models.FrameTypingStop, handleTypingFrame)
+	h.Handle(websocket_models.FrameDelete, handleDeleteFrame)
+	h.Handle(websocket_models.FrameReactionAdd, handleReactionFrame)
+	h.Handle(websocket_models.FrameReactionRemove, handleReactionFrame)
+}
+
+func handleMessageFrame(h *Hub, c *Client, frame websocket_models.Envelope) error {
+	var input websocket_models.Send_Message_Payload
+	if err := decodePayload(frame, &input); err != nil {
+		return err
+	}
+	if strings.TrimSpace(input.Message) == "" && input.AttachmentID == "" {
+		return newFrameError(ErrCodeBadRequest, "message cannot be empty")
+	}
+
+	msg := &websocket_models.Save_Message{
+		SenderID: c.userID,
+		Message:  input.Message,
+	}
+
+	target, err := resolveConversation(c.userID, input.ReceiverNumber, input.ConversationID)
+	if err != nil {
+		return err
+	}
+	msg.ConversationID = target.ID
+	msg.ReceiverID = target.ReceiverID
+	if target.ReceiverID != "" {
+		if err := requireNotBlocked(c.userID, target.ReceiverID); err != nil {
+			return err
+		}
+	}
+
+	if err := resolveReply(c.userID, input, msg); err != nil {
+		return err
+	}
+
+	if input.AttachmentID != "" {
+		msg.ID = primitive.NewObjectID()
+		attachment, err := websocket_database.ClaimAttachment(input.AttachmentID, c.userID, msg.ConversationID, msg.ID)
+		if errors.Is(err, websocket_database.ErrAttachmentNotFound) {
+			return newFrameError(ErrCodeNotFound, "attachment not found")
+		}
+		if err != nil {
+			return err
+		}
+		msg.Attachment = attachment
+	}
+
+	if err := websocket_database.SaveMessage(msg); err != nil {
+		if msg.Attachment != nil {
+			if err := websocket_database.ReleaseAttachment(msg.Attachment.ID); err != nil {
+				log.Println("Failed to release attachment:", err)
+			}
+		}
+		return err
+	}
+
+	c.sendAck(frame.ID, websocket_models.Ack_Payload{
+		MessageID: msg.ID.Hex(),
+		CreatedAt: msg.CreatedAt,
+	})
+	h.SendFrameToUsers(target.Recipients, websocket_models.FrameMessage, msg.ID.Hex(), msg)
+	return nil
+}
+
+// handleAckFrame advances the session's delivery cursor to the acknowledged
+// message so it is not replayed to this device on the next connect, and
+// sends delivery receipts for everything up to it: the user's direct
+// messages, and for a group message, the group up to that message. Only
+// messages addressed to the user move the cursor.
+func handleAckFrame(h *Hub, c *Client, frame websocket_models.Envelope) error {
+	var input websocket_models.Ack_Payload
+	if err := decodePayload(frame, &input); err != nil {
+		return err
+	}
+
+	messageID, err := primitive.ObjectIDFromHex(input.MessageID)
+	if err != nil {
+		return newFrameError(ErrCodeBadRequest, "invalid message ID")
+	}
+
+	msg, err := websocket_database.GetMessage(messageID)
+	if errors.Is(err, websocket_database.ErrMessageNotFound) {
+		return newFrameError(ErrCodeNotFound, "message not found")
+	}
+	if err != nil {
+		return err
+	}
+	addressed, err := addressedTo(msg, c.userID)
+	if err != nil {
+		return err
+	}
+	if !addressed {
+		return newFrameError(ErrCodeNotFound, "message not found")
+	}
+
+	if err := websocket_database.AdvanceDeliveryCursor(c.userID, c.sessionID, msg.CreatedAt); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	delivered, err := websocket_database.MarkDelivered(c.userID, msg.CreatedAt, now)
+	if err != nil {
+		return err
+	}
+	if msg.ReceiverID == "" {
+		inGroup, err := websocket_database.MarkGroupDelivered(c.userID, msg.ConversationID, msg.ID)
+		if err != nil {
+			return err
+		}
+		delivered = append(delivered, inGroup...)
+	}
+	h.sendReceipts(websocket_models.ReceiptDelivered, c.userID, delivered, now)
+	return nil
+}
@@ -1,35 +1,228 @@
 package websocket
 
-import "sync"
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"hash/fnv"
+	"log"
+	"sync"
 
+	"github.com/Ahmeds-Library/Chat-App/websocket_broker"
+	"github.com/Ahmeds-Library/Chat-App/websocket_models"
+)
+
+// Hub tracks every live connection on this node, keyed by user ID and then by
+// connection ID, so one user can be connected from several devices at once.
+// Frames for users connected to other nodes are routed through the broker.
 type Hub struct {
-	clients map[string]*Client
-	mu      sync.RWMutex
+	nodeID   string
+	config   Config
+	broker   websocket_broker.Broker
+	clients  map[string]map[string]*Client
+	handlers map[string]FrameHandler
+	mu       sync.RWMutex
+	// registering serialises broker registration per user, striped by user
+	// ID, so a quick reconnect cannot leave the broker out of step.
+	registering [64]sync.Mutex
 }
 
-func NewHub() *Hub {
-	return &Hub{
-		clients: make(map[string]*Client),
+func NewHub(nodeID string, broker websocket_broker.Broker, config Config) (*Hub, error) {
+	h := &Hub{
+		nodeID:   nodeID,
+		config:   config,
+		broker:   broker,
+		clients:  make(map[string]map[string]*Client),
+		handlers: make(map[string]FrameHandler),
+	}
+	registerDefaultHandlers(h)
+
+	if err := broker.Subscribe(context.Background(), nodeID, h.deliverLocal); err != nil {
+		return nil, err
 	}
+	return h, nil
 }
 
-func (h *Hub) AddClient(userID string, client *Client) {
+// Handle registers the handler for a client frame type, replacing any
+// existing one.
+func (h *Hub) Handle(frameType string, handler FrameHandler) {
 	h.mu.Lock()
 	defer h.mu.Unlock()
-	h.clients[userID] = client
+	h.handlers[frameType] = handler
 }
 
-func (h *Hub) RemoveClient(userID string) {
+func (h *Hub) handler(frameType string) (FrameHandler, bool) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	handler, ok := h.handlers[frameType]
+	return handler, ok
+}
+
+// AddClient registers a connection and reports whether it is the user's first
+// connection on this node.
+func (h *Hub) AddClient(client *Client) bool {
 	h.mu.Lock()
-	defer h.mu.Unlock()
-	delete(h.clients, userID)
+	conns, ok := h.clients[client.userID]
+	if !ok {
+		conns = make(map[string]*Client)
+		h.clients[client.userID] = conns
+	}
+	conns[client.id] = client
+	h.mu.Unlock()
+	activeClients.Add(1)
+
+	if !ok {
+		h.syncRegistration(client.userID)
+	}
+	return !ok
 }
 
-func (h *Hub) SendToUser(userID string, message interface{}) {
+// RemoveClient drops only the given connection, leaving the user's other
+// connections untouched. It reports whether that was the user's last
+// connection on this node.
+func (h *Hub) RemoveClient(client *Client) bool {
+	h.mu.Lock()
+	conns, ok := h.clients[client.userID]
+	if !ok {
+		h.mu.Unlock()
+		return false
+	}
+	if current, ok := conns[client.id]; ok && current == client {
+		delete(conns, client.id)
+		activeClients.Add(-1)
+	}
+	last := len(conns) == 0
+	if last {
+		delete(h.clients, client.userID)
+	}
+	h.mu.Unlock()
+
+	if last {
+		h.syncRegistration(client.userID)
+	}
+	return last
+}
+
+// syncRegistration tells the broker whether this node holds the user's
+// connections. It reads the current state rather than trusting the caller,
+// so whichever call runs last leaves the broker matching the hub.
+func (h *Hub) syncRegistration(userID string) {
+	lock := h.registrationLock(userID)
+	lock.Lock()
+	defer lock.Unlock()
+
 	h.mu.RLock()
-	client, ok := h.clients[userID]
+	connected := len(h.clients[userID]) > 0
 	h.mu.RUnlock()
-	if ok {
-		client.send <- message
+
+	if connected {
+		if err := h.broker.Register(context.Background(), userID, h.nodeID); err != nil {
+			log.Println("Broker register error:", err)
+		}
+		return
+	}
+	if err := h.broker.Unregister(context.Background(), userID, h.nodeID); err != nil {
+		log.Println("Broker unregister error:", err)
+	}
+}
+
+func (h *Hub) registrationLock(userID string) *sync.Mutex {
+	hash := fnv.New32a()
+	hash.Write([]byte(userID))
+	return &h.registering[hash.Sum32()%uint32(len(h.registering))]
+}
+
+// SendToUser delivers the message to every connection the user has open, on
+// this node and on any other node the broker knows about.
+func (h *Hub) SendToUser(userID string, message interface{}) {
+	data, err := json.Marshal(message)
+	if err != nil {
+		log.Println("Frame encode error:", err)
+		return
+	}
+
+	h.deliverLocal(userID, data)
+	if err := h.broker.Publish(context.Background(), userID, h.nodeID, data); err != nil {
+		log.Println("Broker publish error:", err)
+	}
+}
+
+// SendFrameToUser wraps the payload in a protocol envelope and delivers it to
+// every connection the user has open.
+func (h *Hub) SendFrameToUser(userID, frameType, id string, payload interface{}) {
+	frame, err := websocket_models.NewEnvelope(frameType, id, payload)
+	if err != nil {
+		log.Println("Frame encode error:", err)
+		return
+	}
+	h.SendToUser(userID, frame)
+}
+
+// SendFrameToUsers encodes the frame once and delivers it to every listed
+// user.
+func (h *Hub) SendFrameToUsers(userIDs []string, frameType, id string, payload interface{}) {
+	data, err := encodeFrame(frameType, id, payload)
+	if err != nil {
+		log.Println("Frame encode error:", err)
+		return
+	}
+
+	for _, userID := range userIDs {
+		h.deliverLocal(userID, data)
+		if err := h.broker.Publish(context.Background(), userID, h.nodeID, data); err != nil {
+			log.Println("Broker publish error:", err)
+		}
+	}
+}
+
+// sendLocalFrameToUser is SendFrameToUser limited to this node's connections,
+// for events every node observes on its own.
+func (h *Hub) sendLocalFrameToUser(userID, frameType, id string, payload interface{}) {
+	data, err := encodeFrame(frameType, id, payload)
+	if err != nil {
+		log.Println("Frame encode error:", err)
+		return
+	}
+	h.deliverLocal(userID, data)
+}
+
+// sendLocalFrameToUsers is sendLocalFrameToUser for several users.
+func (h *Hub) sendLocalFrameToUsers(userIDs []string, frameType, id string, payload interface{}) {
+	data, err := encodeFrame(frameType, id, payload)
+	if err != nil {
+		log.Println("Frame encode error:", err)
+		return
+	}
+	for _, userID := range userIDs {
+		h.deliverLocal(userID, data)
+	}
+}
+
+// deliverLocal never blocks: a connection that cannot keep up is handled by
+// the slow consumer policy instead of stalling the sender.
+func (h *Hub) deliverLocal(userID string, data []byte) {
+	for _, client := range h.userClients(userID) {
+		client.enqueue(data)
+	}
+}
+
+func (h *Hub) userClients(userID string) []*Client {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	conns := h.clients[userID]
+	clients := make([]*Client, 0, len(conns))
+	for _, client := range conns {
+		clients = append(clients, client)
+	}
+	return clients
+}
+
+func newConnectionID() string {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
 	}
+	return hex.EncodeToString(b)
 }
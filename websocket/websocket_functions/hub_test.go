@@ -0,0 +1,150 @@
+package websocket
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/Ahmeds-Library/Chat-App/websocket_broker"
+	"github.com/Ahmeds-Library/Chat-App/websocket_models"
+	"github.com/alicebob/miniredis/v2"
+)
+
+func newTestHub(t *testing.T, nodeID string, broker websocket_broker.Broker) *Hub {
+	t.Helper()
+	h, err := NewHub(nodeID, broker, DefaultConfig())
+	if err != nil {
+		t.Fatalf("NewHub(%s): %v", nodeID, err)
+	}
+	return h
+}
+
+func connect(h *Hub, userID string) *Client {
+	c := newClient(nil, userID, "session-"+userID, h.config)
+	h.AddClient(c)
+	return c
+}
+
+func receive(t *testing.T, c *Client) websocket_models.Envelope {
+	t.Helper()
+	select {
+	case data := <-c.send:
+		var frame websocket_models.Envelope
+		if err := json.Unmarshal(data, &frame); err != nil {
+			t.Fatalf("decode frame: %v", err)
+		}
+		return frame
+	case <-time.After(2 * time.Second):
+		t.Fatalf("no frame for %s", c.userID)
+	}
+	return websocket_models.Envelope{}
+}
+
+func expectNothing(t *testing.T, c *Client) {
+	t.Helper()
+	select {
+	case data := <-c.send:
+		t.Fatalf("unexpected frame for %s: %s", c.userID, data)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func twoHubsWithMemoryBroker(t *testing.T) (*Hub, *Hub, websocket_broker.Broker) {
+	broker := websocket_broker.NewMemoryBroker()
+	return newTestHub(t, "node-a", broker), newTestHub(t, "node-b", broker), broker
+}
+
+func twoHubsWithRedisBroker(t *testing.T) (*Hub, *Hub, websocket_broker.Broker) {
+	server := miniredis.RunT(t)
+	brokerA := websocket_broker.NewRedisBroker(server.Addr(), "")
+	brokerB := websocket_broker.NewRedisBroker(server.Addr(), "")
+	t.Cleanup(func() {
+		brokerA.Close()
+		brokerB.Close()
+	})
+	return newTestHub(t, "node-a", brokerA), newTestHub(t, "node-b", brokerB), brokerA
+}
+
+var brokerSetups = map[string]func(*testing.T) (*Hub, *Hub, websocket_broker.Broker){
+	"memory": twoHubsWithMemoryBroker,
+	"redis":  twoHubsWithRedisBroker,
+}
+
+func TestFramesReachUsersOnOtherHubs(t *testing.T) {
+	for name, setup := range brokerSetups {
+		t.Run(name, func(t *testing.T) {
+			hubA, hubB, _ := setup(t)
+			alice := connect(hubA, "1")
+			bobPhone := connect(hubB, "2")
+			bobLaptop := connect(hubA, "2")
+
+			hubA.SendFrameToUser("2", websocket_models.FrameMessage, "m1", map[string]string{"message": "hi"})
+
+			for _, c := range []*Client{bobPhone, bobLaptop} {
+				if frame := receive(t, c); frame.Type != websocket_models.FrameMessage || frame.ID != "m1" {
+					t.Fatalf("got %s %q, want message m1", frame.Type, frame.ID)
+				}
+				expectNothing(t, c)
+			}
+			expectNothing(t, alice)
+		})
+	}
+}
+
+func TestFramesStopAfterDisconnect(t *testing.T) {
+	for name, setup := range brokerSetups {
+		t.Run(name, func(t *testing.T) {
+			hubA, hubB, broker := setup(t)
+			bob := connect(hubB, "2")
+			if !hubB.RemoveClient(bob) {
+				t.Fatal("RemoveClient did not report the last connection")
+			}
+
+			online, err := broker.Online(context.Background(), []string{"2"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if online["2"] {
+				t.Fatal("user still online after disconnecting")
+			}
+
+			hubA.SendFrameToUser("2", websocket_models.FrameMessage, "m1", nil)
+			expectNothing(t, bob)
+		})
+	}
+}
+
+// slowUnregister delays Unregister so it lands after a reconnect's Register
+// unless the hub orders them.
+type slowUnregister struct {
+	websocket_broker.Broker
+}
+
+func (b slowUnregister) Unregister(ctx context.Context, userID, nodeID string) error {
+	time.Sleep(100 * time.Millisecond)
+	return b.Broker.Unregister(ctx, userID, nodeID)
+}
+
+func TestQuickReconnectStaysRegistered(t *testing.T) {
+	broker := websocket_broker.NewMemoryBroker()
+	hub := newTestHub(t, "node-a", slowUnregister{broker})
+
+	first := connect(hub, "2")
+	removed := make(chan struct{})
+	go func() {
+		hub.RemoveClient(first)
+		close(removed)
+	}()
+	time.Sleep(20 * time.Millisecond)
+	connect(hub, "2")
+	<-removed
+
+	online, err := broker.Online(context.Background(), []string{"2"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !online["2"] {
+		t.Fatal("reconnected user is not registered with the broker")
+	}
+}
@@ -0,0 +1,10 @@
+package websocket
+
+import "expvar"
+
+// Counters published on /debug/vars.
+var (
+	droppedFrames  = expvar.NewInt("ws_dropped_frames")
+	evictedClients = expvar.NewInt("ws_evicted_clients")
+	activeClients  = expvar.NewInt("ws_active_connections")
+)
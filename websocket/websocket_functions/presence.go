@@ -0,0 +1,161 @@
+package websocket
+
+import (
+	"context"
+	"log"
+	"time"
+
+	websocket_mongo "github.com/Ahmeds-Library/Chat-App/websocket_database/mongo"
+	websocket_postgres "github.com/Ahmeds-Library/Chat-App/websocket_database/postgres"
+	"github.com/Ahmeds-Library/Chat-App/websocket_models"
+)
+
+// handleTypingFrame relays typing_start and typing_stop to the other
+// participants of the conversation, except those with a block either way.
+// Typing state is never stored.
+func handleTypingFrame(h *Hub, c *Client, frame websocket_models.Envelope) error {
+	var input websocket_models.Typing_Payload
+	if err := decodePayload(frame, &input); err != nil {
+		return err
+	}
+
+	target, err := resolveConversation(c.userID, input.PartnerNumber, input.ConversationID)
+	if err != nil {
+		return err
+	}
+
+	recipients, err := withoutBlocked(c.userID, target.Recipients)
+	if err != nil {
+		return err
+	}
+
+	h.SendFrameToUsers(recipients, frame.Type, "", websocket_models.Typing_Payload{
+		ConversationID: target.ID,
+		UserID:         c.userID,
+	})
+	return nil
+}
+
+// userConnected tells the user's contacts they came online.
+func (h *Hub) userConnected(userID string) {
+	h.broadcastPresence(websocket_models.Presence_Payload{UserID: userID, Online: true})
+}
+
+// userDisconnected records when the user was last seen and tells their
+// contacts they went offline, unless they are still connected to another
+// node.
+func (h *Hub) userDisconnected(userID string) {
+	online, err := h.broker.Online(context.Background(), []string{userID})
+	if err != nil {
+		log.Println("Presence lookup error:", err)
+		return
+	}
+	if online[userID] {
+		return
+	}
+
+	now := time.Now()
+	if err := websocket_postgres.UpdateLastSeen(userID, now); err != nil {
+		log.Println("Last seen update error:", err)
+	}
+	h.broadcastPresence(websocket_models.Presence_Payload{UserID: userID, Online: false, LastSeen: &now})
+}
+
+// broadcastPresence sends the presence to everyone Presence would show it
+// to: direct contacts and group peers without a block either way.
+func (h *Hub) broadcastPresence(presence websocket_models.Presence_Payload) {
+	audience, err := presenceAudience(presence.UserID)
+	if err != nil {
+		log.Println("Contact lookup error:", err)
+		return
+	}
+	audience, err = withoutBlocked(presence.UserID, audience)
+	if err != nil {
+		log.Println("Block lookup error:", err)
+		return
+	}
+
+	h.SendFrameToUsers(audience, websocket_models.FramePresence, "", presence)
+}
+
+// presenceAudience returns everyone who shares a direct conversation or a
+// group with the user, the counterpart of sharesConversation.
+func presenceAudience(userID string) ([]string, error) {
+	contacts, err := websocket_mongo.GetContactIDs(userID)
+	if err != nil {
+		return nil, err
+	}
+	peers, err := websocket_postgres.GetGroupPeerIDs(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	seen := map[string]bool{userID: true}
+	var audience []string
+	for _, id := range append(contacts, peers...) {
+		if !seen[id] {
+			seen[id] = true
+			audience = append(audience, id)
+		}
+	}
+	return audience, nil
+}
+
+// Presence returns the online status and last seen time of each user as
+// viewerID sees them. Users who share no conversation with the viewer, or
+// have a block with them either way, always look offline.
+func (h *Hub) Presence(viewerID string, userIDs []string) ([]websocket_models.Presence_Payload, error) {
+	visible, err := sharesConversation(viewerID, userIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	online, err := h.broker.Online(context.Background(), userIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	lastSeen, err := websocket_postgres.GetLastSeen(userIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	blocked, err := websocket_postgres.GetBlockedUserIDs(viewerID)
+	if err != nil {
+		return nil, err
+	}
+
+	presence := make([]websocket_models.Presence_Payload, 0, len(userIDs))
+	for _, userID := range userIDs {
+		p := websocket_models.Presence_Payload{UserID: userID}
+		if blocked[userID] || !visible[userID] {
+			presence = append(presence, p)
+			continue
+		}
+		p.Online = online[userID]
+		if seen, ok := lastSeen[userID]; ok {
+			p.LastSeen = &seen
+		}
+		presence = append(presence, p)
+	}
+	return presence, nil
+}
+
+// sharesConversation reports which of userIDs have a direct conversation or a
+// group with viewerID. Viewers can always see themselves.
+func sharesConversation(viewerID string, userIDs []string) (map[string]bool, error) {
+	contacts, err := websocket_mongo.FilterContactIDs(viewerID, userIDs)
+	if err != nil {
+		return nil, err
+	}
+	peers, err := websocket_postgres.FilterGroupPeerIDs(viewerID, userIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	visible := map[string]bool{viewerID: true}
+	for _, id := range append(contacts, peers...) {
+		visible[id] = true
+	}
+	return visible, nil
+}
@@ -0,0 +1,58 @@
+package websocket
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/Ahmeds-Library/Chat-App/websocket_middleware"
+	"github.com/gin-gonic/gin"
+)
+
+// MaxPresenceUsers caps how many users one presence request may ask about.
+const MaxPresenceUsers = 100
+
+// PresenceHandler answers GET /presence?user_ids=1,2,3 with the online status
+// and last seen time of each user, hiding those of users the caller shares no
+// conversation with or has a block with.
+func PresenceHandler(hub *Hub) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		claims, ok := websocket_middleware.ValidateToken_WebSocket(c)
+		if !ok {
+			return
+		}
+		viewerID, _ := claims["id"].(string)
+
+		var userIDs []string
+		seen := make(map[string]bool)
+		for _, id := range strings.Split(c.Query("user_ids"), ",") {
+			id = strings.TrimSpace(id)
+			if id == "" || seen[id] {
+				continue
+			}
+			seen[id] = true
+			if _, err := strconv.Atoi(id); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request", "details": "invalid user ID: " + id})
+				return
+			}
+			userIDs = append(userIDs, id)
+		}
+		if len(userIDs) == 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request", "details": "user_ids is required"})
+			return
+		}
+		if len(userIDs) > MaxPresenceUsers {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request", "details": fmt.Sprintf("at most %d user IDs per request", MaxPresenceUsers)})
+			return
+		}
+
+		presence, err := hub.Presence(viewerID, userIDs)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get presence", "details": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, presence)
+	}
+}
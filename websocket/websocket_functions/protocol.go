@@ -0,0 +1,107 @@
+package websocket
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+
+	"github.com/Ahmeds-Library/Chat-App/websocket_models"
+)
+
+// Error codes sent to clients in "error" frames.
+const (
+	ErrCodeBadRequest         = "bad_request"
+	ErrCodeUnsupportedVersion = "unsupported_version"
+	ErrCodeUnsupportedType    = "unsupported_type"
+	ErrCodeReceiverNotFound   = "receiver_not_found"
+	ErrCodeInvalidReceiver    = "invalid_receiver"
+	ErrCodeNotMember          = "not_member"
+	ErrCodeNotFound           = "not_found"
+	ErrCodeForbidden          = "forbidden"
+	ErrCodeBlocked            = "blocked"
+	ErrCodeInternal           = "internal_error"
+)
+
+// FrameError is returned by frame handlers to report a failure back to the
+// client that sent the frame.
+type FrameError struct {
+	Code    string
+	Message string
+}
+
+func (e *FrameError) Error() string {
+	return e.Code + ": " + e.Message
+}
+
+func newFrameError(code, format string, args ...interface{}) *FrameError {
+	return &FrameError{Code: code, Message: fmt.Sprintf(format, args...)}
+}
+
+// FrameHandler processes one client frame. Returning a *FrameError sends its
+// code to the client; any other error is reported as an internal error.
+type FrameHandler func(h *Hub, c *Client, frame websocket_models.Envelope) error
+
+// decodeFrame parses a raw client frame. Frames without a "type" are the
+// pre-envelope {receiver_number, message} shape and are wrapped as a
+// "message" frame.
+func decodeFrame(data []byte) (websocket_models.Envelope, error) {
+	var frame websocket_models.Envelope
+	if err := json.Unmarshal(data, &frame); err != nil {
+		return frame, newFrameError(ErrCodeBadRequest, "malformed frame: %v", err)
+	}
+
+	if frame.Type == "" {
+		frame.Type = websocket_models.FrameMessage
+		frame.Payload = data
+	}
+	if frame.Version == 0 {
+		frame.Version = websocket_models.ProtocolVersion
+	}
+	if frame.Version > websocket_models.ProtocolVersion {
+		return frame, newFrameError(ErrCodeUnsupportedVersion, "protocol version %d is not supported", frame.Version)
+	}
+	return frame, nil
+}
+
+func decodePayload(frame websocket_models.Envelope, v interface{}) error {
+	if len(frame.Payload) == 0 {
+		return newFrameError(ErrCodeBadRequest, "missing payload")
+	}
+	if err := json.Unmarshal(frame.Payload, v); err != nil {
+		return newFrameError(ErrCodeBadRequest, "invalid payload: %v", err)
+	}
+	return nil
+}
+
+func encodeFrame(frameType, id string, payload interface{}) ([]byte, error) {
+	frame, err := websocket_models.NewEnvelope(frameType, id, payload)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(frame)
+}
+
+func (c *Client) sendFrame(frameType, id string, payload interface{}) {
+	data, err := encodeFrame(frameType, id, payload)
+	if err != nil {
+		log.Println("Frame encode error:", err)
+		return
+	}
+	c.enqueueWait(data)
+}
+
+func (c *Client) sendAck(id string, payload websocket_models.Ack_Payload) {
+	c.sendFrame(websocket_models.FrameAck, id, payload)
+}
+
+func (c *Client) sendError(id string, err error) {
+	frameErr, ok := err.(*FrameError)
+	if !ok {
+		log.Println("Frame handler error:", err)
+		frameErr = newFrameError(ErrCodeInternal, "internal server error")
+	}
+	c.sendFrame(websocket_models.FrameError, id, websocket_models.Error_Payload{
+		Code:    frameErr.Code,
+		Message: frameErr.Message,
+	})
+}
@@ -0,0 +1,69 @@
+package websocket
+
+import (
+	"errors"
+	"slices"
+	"strings"
+
+	websocket_mongo "github.com/Ahmeds-Library/Chat-App/websocket_database/mongo"
+	"github.com/Ahmeds-Library/Chat-App/websocket_models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// handleReactionFrame adds or removes the client's reaction on a message and
+// sends the message's reactions to every participant.
+func handleReactionFrame(h *Hub, c *Client, frame websocket_models.Envelope) error {
+	var input websocket_models.Reaction_Payload
+	if err := decodePayload(frame, &input); err != nil {
+		return err
+	}
+
+	emoji := strings.TrimSpace(input.Emoji)
+	if emoji == "" || len(emoji) > 32 {
+		return newFrameError(ErrCodeBadRequest, "emoji must be between 1 and 32 bytes")
+	}
+
+	messageID, err := primitive.ObjectIDFromHex(input.MessageID)
+	if err != nil {
+		return newFrameError(ErrCodeBadRequest, "invalid message ID")
+	}
+
+	msg, err := websocket_mongo.GetMessage(messageID)
+	if errors.Is(err, websocket_mongo.ErrMessageNotFound) || (err == nil && (msg.Deleted || slices.Contains(msg.DeletedFor, c.userID))) {
+		return newFrameError(ErrCodeNotFound, "message not found")
+	}
+	if err != nil {
+		return err
+	}
+
+	users, err := participants(msg)
+	if err != nil {
+		return err
+	}
+	if !slices.Contains(users, c.userID) {
+		return newFrameError(ErrCodeNotMember, "not a participant in this conversation")
+	}
+
+	update := websocket_mongo.AddReaction
+	if frame.Type == websocket_models.FrameReactionRemove {
+		update = websocket_mongo.RemoveReaction
+	}
+	msg, changed, err := update(messageID, c.userID, emoji)
+	if err != nil {
+		return err
+	}
+
+	c.sendAck(frame.ID, websocket_models.Ack_Payload{MessageID: input.MessageID})
+	if changed {
+		h.SendFrameToUsers(users, websocket_models.FrameReactions, input.MessageID, reactionsPayload(msg))
+	}
+	return nil
+}
+
+func reactionsPayload(msg websocket_models.Save_Message) websocket_models.Reactions_Payload {
+	return websocket_models.Reactions_Payload{
+		MessageID:      msg.ID.Hex(),
+		ConversationID: msg.ConversationID,
+		Reactions:      websocket_models.SummarizeReactions(msg.Reactions),
+	}
+}
@@ -0,0 +1,62 @@
+package websocket
+
+import (
+	"time"
+
+	websocket_mongo "github.com/Ahmeds-Library/Chat-App/websocket_database/mongo"
+	"github.com/Ahmeds-Library/Chat-App/websocket_models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// handleReadFrame marks the conversation read up to a message and tells the
+// senders' connections. Groups keep the reader's place in a read cursor
+// rather than on the shared messages.
+func handleReadFrame(h *Hub, c *Client, frame websocket_models.Envelope) error {
+	var input websocket_models.Read_Payload
+	if err := decodePayload(frame, &input); err != nil {
+		return err
+	}
+
+	upTo, err := primitive.ObjectIDFromHex(input.MessageID)
+	if err != nil {
+		return newFrameError(ErrCodeBadRequest, "invalid message ID")
+	}
+
+	target, err := resolveConversation(c.userID, input.PartnerNumber, input.ConversationID)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	var messages []websocket_models.Save_Message
+	if target.ReceiverID == "" {
+		messages, err = websocket_mongo.MarkGroupRead(c.userID, target.ID, upTo)
+	} else {
+		messages, err = websocket_mongo.MarkRead(c.userID, target.ID, upTo, now)
+	}
+	if err != nil {
+		return err
+	}
+
+	h.sendReceipts(websocket_models.ReceiptRead, c.userID, messages, now)
+	c.sendAck(frame.ID, websocket_models.Ack_Payload{MessageID: input.MessageID})
+	return nil
+}
+
+// sendReceipts tells the senders of messages that userID received or read
+// them, with one receipt frame per sender.
+func (h *Hub) sendReceipts(status, userID string, messages []websocket_models.Save_Message, at time.Time) {
+	bySender := make(map[string][]string)
+	for _, msg := range messages {
+		bySender[msg.SenderID] = append(bySender[msg.SenderID], msg.ID.Hex())
+	}
+
+	for senderID, ids := range bySender {
+		h.SendFrameToUser(senderID, websocket_models.FrameReceipt, "", websocket_models.Receipt_Payload{
+			Status:     status,
+			UserID:     userID,
+			MessageIDs: ids,
+			At:         at,
+		})
+	}
+}
@@ -0,0 +1,70 @@
+package websocket
+
+import (
+	"context"
+	"log"
+
+	websocket_mongo "github.com/Ahmeds-Library/Chat-App/websocket_database/mongo"
+	"github.com/Ahmeds-Library/Chat-App/websocket_models"
+)
+
+// RelayMessageChanges pushes messages created, edited, read, deleted or
+// reacted to through the REST API to the connected participants until ctx is
+// cancelled. Every node watches the stream itself, so changes are only
+// delivered to local connections. Progress is saved under checkpoint.
+func (h *Hub) RelayMessageChanges(ctx context.Context, checkpoint string) {
+	websocket_mongo.WatchMessages(ctx, checkpoint, func(msg websocket_models.Save_Message) {
+		switch msg.Change.Kind {
+		case websocket_models.ChangeCreated:
+			users, err := participants(msg)
+			if err != nil {
+				log.Println("Relay participants error:", err)
+				return
+			}
+			// The sender's other devices need the message as well; the one
+			// that sent it already has the ID from the REST response.
+			h.sendLocalFrameToUsers(users, websocket_models.FrameMessage, msg.ID.Hex(), msg)
+		case websocket_models.ChangeEdited:
+			users, err := participants(msg)
+			if err != nil {
+				log.Println("Relay participants error:", err)
+				return
+			}
+			h.sendLocalFrameToUsers(users, websocket_models.FrameEdited, msg.ID.Hex(), msg)
+		case websocket_models.ChangeDeleted:
+			users, err := participants(msg)
+			if err != nil {
+				log.Println("Relay participants error:", err)
+				return
+			}
+			h.sendLocalFrameToUsers(users, websocket_models.FrameDeleted, msg.ID.Hex(), deletedPayload(msg))
+		case websocket_models.ChangeReacted:
+			users, err := participants(msg)
+			if err != nil {
+				log.Println("Relay participants error:", err)
+				return
+			}
+			h.sendLocalFrameToUsers(users, websocket_models.FrameReactions, msg.ID.Hex(), reactionsPayload(msg))
+		case websocket_models.ChangeRead:
+			if msg.ReadAt == nil {
+				return
+			}
+			h.sendLocalFrameToUser(msg.SenderID, websocket_models.FrameReceipt, "", websocket_models.Receipt_Payload{
+				Status:     websocket_models.ReceiptRead,
+				UserID:     msg.ReceiverID,
+				MessageIDs: []string{msg.ID.Hex()},
+				At:         *msg.ReadAt,
+			})
+		}
+	})
+}
+
+func without(userIDs []string, userID string) []string {
+	rest := make([]string, 0, len(userIDs))
+	for _, id := range userIDs {
+		if id != userID {
+			rest = append(rest, id)
+		}
+	}
+	return rest
+}
@@ -0,0 +1,85 @@
+package websocket
+
+import (
+	"log"
+	"time"
+
+	websocket_mongo "github.com/Ahmeds-Library/Chat-App/websocket_database/mongo"
+	websocket_postgres "github.com/Ahmeds-Library/Chat-App/websocket_database/postgres"
+	"github.com/Ahmeds-Library/Chat-App/websocket_models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	replayBatchSize   = 100
+	maxReplayMessages = 1000
+)
+
+// replayStart parses the client's "since" parameter, the ID of the newest
+// message it has. An empty value means resuming from the session's stored
+// delivery cursor.
+func replayStart(since string) (*primitive.ObjectID, error) {
+	if since == "" {
+		return nil, nil
+	}
+	id, err := primitive.ObjectIDFromHex(since)
+	if err != nil {
+		return nil, err
+	}
+	return &id, nil
+}
+
+// replayUndelivered sends every message addressed to the client created
+// after since, or after the session's delivery cursor when since is nil,
+// then a replay_done frame. Creation times come from the writers' clocks
+// and are taken before the write commits, so the replay starts LagWindow
+// earlier and may repeat messages the client already has; clients drop
+// those by ID. Replays stop after maxReplayMessages; a truncated replay
+// tells the client to fetch the rest over REST.
+func (c *Client) replayUndelivered(since *primitive.ObjectID) {
+	var from time.Time
+	if since != nil {
+		from = since.Timestamp()
+	} else {
+		cursor, err := websocket_mongo.GetDeliveryCursor(c.userID, c.sessionID)
+		if err != nil {
+			log.Println("Replay cursor error:", err)
+			return
+		}
+		from = cursor
+	}
+	var after websocket_mongo.ReplayPosition
+	if !from.IsZero() {
+		after.CreatedAt = from.Add(-websocket_mongo.LagWindow)
+	}
+
+	conversationIDs, err := websocket_postgres.GetUserConversationIDs(c.userID)
+	if err != nil {
+		log.Println("Replay conversations error:", err)
+		return
+	}
+
+	done := websocket_models.Replay_Done_Payload{}
+	for done.Count < maxReplayMessages {
+		messages, err := websocket_mongo.GetUndeliveredMessages(c.userID, conversationIDs, after, replayBatchSize)
+		if err != nil {
+			log.Println("Replay error:", err)
+			return
+		}
+
+		for _, msg := range messages {
+			c.sendFrame(websocket_models.FrameMessage, msg.ID.Hex(), msg)
+			after = websocket_mongo.ReplayPosition{CreatedAt: msg.CreatedAt, ID: msg.ID}
+			done.Count++
+			done.LastID = msg.ID.Hex()
+		}
+
+		if len(messages) < replayBatchSize {
+			c.sendFrame(websocket_models.FrameReplayDone, "", done)
+			return
+		}
+	}
+
+	done.Truncated = true
+	c.sendFrame(websocket_models.FrameReplayDone, "", done)
+}
@@ -0,0 +1,76 @@
+package websocket
+
+import (
+	"errors"
+	"slices"
+
+	websocket_mongo "github.com/Ahmeds-Library/Chat-App/websocket_database/mongo"
+	"github.com/Ahmeds-Library/Chat-App/websocket_models"
+	"github.com/Ahmeds-Library/Chat-App/websocket_utils"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// resolveReply fills in the quoted message and thread of a new message. Both
+// must belong to the message's conversation, and threads only exist in
+// groups.
+func resolveReply(userID string, input websocket_models.Send_Message_Payload, msg *websocket_models.Save_Message) error {
+	if input.ReplyTo != "" {
+		quoted, err := visibleMessage(input.ReplyTo, msg.ConversationID, userID)
+		if err != nil {
+			return err
+		}
+		if quoted == nil {
+			return newFrameError(ErrCodeNotFound, "quoted message not found")
+		}
+
+		msg.ReplyTo = &websocket_models.Message_Reply{
+			MessageID: quoted.ID,
+			SenderID:  quoted.SenderID,
+			Message:   quoted.Message,
+			CreatedAt: quoted.CreatedAt,
+		}
+	}
+
+	if input.ThreadID != "" {
+		if websocket_utils.IsDirectConversation(msg.ConversationID) {
+			return newFrameError(ErrCodeBadRequest, "threads are only available in groups")
+		}
+
+		root, err := visibleMessage(input.ThreadID, msg.ConversationID, userID)
+		if err != nil {
+			return err
+		}
+		if root == nil {
+			return newFrameError(ErrCodeNotFound, "thread not found")
+		}
+		if root.ThreadID != nil {
+			return newFrameError(ErrCodeBadRequest, "a thread reply cannot start a thread")
+		}
+
+		msg.ThreadID = &root.ID
+	}
+
+	return nil
+}
+
+// visibleMessage loads a message from conversationID that userID can still
+// see. It returns nil if there is no such message.
+func visibleMessage(messageID, conversationID, userID string) (*websocket_models.Save_Message, error) {
+	id, err := primitive.ObjectIDFromHex(messageID)
+	if err != nil {
+		return nil, nil
+	}
+
+	msg, err := websocket_mongo.GetMessage(id)
+	if errors.Is(err, websocket_mongo.ErrMessageNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if msg.ConversationID != conversationID || msg.Deleted || slices.Contains(msg.DeletedFor, userID) {
+		return nil, nil
+	}
+	return &msg, nil
+}
@@ -0,0 +1,65 @@
+package websocket
+
+import (
+	"context"
+	"log"
+
+	websocket_postgres "github.com/Ahmeds-Library/Chat-App/websocket_database/postgres"
+)
+
+// DisconnectRevokedSessions closes the connections of sessions the back-end
+// revokes, on logout or when a stolen refresh token is detected, until ctx is
+// cancelled. Every node listens itself and closes only its own connections.
+func (h *Hub) DisconnectRevokedSessions(ctx context.Context) {
+	websocket_postgres.ListenSessionRevocations(ctx, h.closeRevokedSessions, func() {
+		h.closeEndedSessions(websocket_postgres.ActiveSessions)
+	})
+}
+
+// closeRevokedSessions closes the connections of one session of a user, or
+// of all of them when sessionID is empty.
+func (h *Hub) closeRevokedSessions(userID, sessionID string) {
+	for _, client := range h.userClients(userID) {
+		if sessionID == "" || client.sessionID == sessionID {
+			log.Println("Closing revoked session:", userID, "connection:", client.id)
+			client.closeWithReason(CloseSessionRevoked, "session revoked")
+		}
+	}
+}
+
+// closeEndedSessions closes every connection whose session active no longer
+// reports, catching up on revocations missed while not listening.
+func (h *Hub) closeEndedSessions(active func(sessionIDs []string) (map[string]bool, error)) {
+	h.mu.RLock()
+	var clients []*Client
+	for _, conns := range h.clients {
+		for _, client := range conns {
+			clients = append(clients, client)
+		}
+	}
+	h.mu.RUnlock()
+	if len(clients) == 0 {
+		return
+	}
+
+	sessionIDs := make([]string, 0, len(clients))
+	seen := make(map[string]bool, len(clients))
+	for _, client := range clients {
+		if !seen[client.sessionID] {
+			seen[client.sessionID] = true
+			sessionIDs = append(sessionIDs, client.sessionID)
+		}
+	}
+
+	stillActive, err := active(sessionIDs)
+	if err != nil {
+		log.Println("Session check error:", err)
+		return
+	}
+	for _, client := range clients {
+		if !stillActive[client.sessionID] {
+			log.Println("Closing ended session:", client.userID, "connection:", client.id)
+			client.closeWithReason(CloseSessionRevoked, "session revoked")
+		}
+	}
+}
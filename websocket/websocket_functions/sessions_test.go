@@ -0,0 +1,140 @@
+package websocket
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Ahmeds-Library/Chat-App/websocket_broker"
+	"github.com/gorilla/websocket"
+)
+
+// dialSession opens a real socket to hub as userID in sessionID and returns
+// the client end.
+func dialSession(t *testing.T, hub *Hub, userID, sessionID string) *websocket.Conn {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		client := newClient(conn, userID, sessionID, hub.config)
+		hub.AddClient(client)
+		go client.WritePump()
+	}))
+	t.Cleanup(server.Close)
+
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	// AddClient runs after the handshake; wait until the hub has it.
+	deadline := time.Now().Add(2 * time.Second)
+	for !hasSession(hub, userID, sessionID) {
+		if time.Now().After(deadline) {
+			t.Fatalf("session %s never registered", sessionID)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	return conn
+}
+
+func hasSession(hub *Hub, userID, sessionID string) bool {
+	for _, client := range hub.userClients(userID) {
+		if client.sessionID == sessionID {
+			return true
+		}
+	}
+	return false
+}
+
+// expectClosed reads from conn until the server closes it, and checks the
+// close code.
+func expectClosed(t *testing.T, conn *websocket.Conn, code int) {
+	t.Helper()
+
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	for {
+		_, _, err := conn.ReadMessage()
+		if err == nil {
+			continue
+		}
+		var closeErr *websocket.CloseError
+		if !errors.As(err, &closeErr) || closeErr.Code != code {
+			t.Fatalf("connection ended with %v, want close code %d", err, code)
+		}
+		return
+	}
+}
+
+func expectOpen(t *testing.T, conn *websocket.Conn) {
+	t.Helper()
+
+	conn.SetReadDeadline(time.Now().Add(200 * time.Millisecond))
+	_, _, err := conn.ReadMessage()
+	var netErr interface{ Timeout() bool }
+	if !errors.As(err, &netErr) || !netErr.Timeout() {
+		t.Fatalf("connection should still be open, read returned %v", err)
+	}
+}
+
+func TestRevokingASessionClosesItsSocket(t *testing.T) {
+	hub := newTestHub(t, "node-a", websocket_broker.NewMemoryBroker())
+	phone := dialSession(t, hub, "1", "phone")
+	laptop := dialSession(t, hub, "1", "laptop")
+	other := dialSession(t, hub, "2", "phone")
+
+	hub.closeRevokedSessions("1", "phone")
+
+	expectClosed(t, phone, CloseSessionRevoked)
+	expectOpen(t, laptop)
+	expectOpen(t, other)
+}
+
+func TestRevokingAllSessionsClosesEverySocket(t *testing.T) {
+	hub := newTestHub(t, "node-a", websocket_broker.NewMemoryBroker())
+	phone := dialSession(t, hub, "1", "phone")
+	laptop := dialSession(t, hub, "1", "laptop")
+
+	hub.closeRevokedSessions("1", "")
+
+	expectClosed(t, phone, CloseSessionRevoked)
+	expectClosed(t, laptop, CloseSessionRevoked)
+}
+
+// After the listener reconnects, sessions revoked in the meantime are found
+// and closed.
+func TestResyncClosesSessionsEndedWhileNotListening(t *testing.T) {
+	hub := newTestHub(t, "node-a", websocket_broker.NewMemoryBroker())
+	phone := dialSession(t, hub, "1", "phone")
+	laptop := dialSession(t, hub, "1", "laptop")
+
+	var asked []string
+	hub.closeEndedSessions(func(sessionIDs []string) (map[string]bool, error) {
+		asked = sessionIDs
+		return map[string]bool{"laptop": true}, nil
+	})
+
+	if len(asked) != 2 {
+		t.Fatalf("checked sessions %v, want both", asked)
+	}
+	expectClosed(t, phone, CloseSessionRevoked)
+	expectOpen(t, laptop)
+}
+
+func TestResyncKeepsSocketsWhenTheCheckFails(t *testing.T) {
+	hub := newTestHub(t, "node-a", websocket_broker.NewMemoryBroker())
+	phone := dialSession(t, hub, "1", "phone")
+
+	hub.closeEndedSessions(func([]string) (map[string]bool, error) {
+		return nil, errors.New("database down")
+	})
+
+	expectOpen(t, phone)
+}
@@ -20,6 +20,13 @@ func WebSocketHandler(hub *Hub) gin.HandlerFunc {
 			return
 		}
 		userID := claims["id"].(string)
+		sessionID, _ := claims["sid"].(string)
+
+		since, err := replayStart(c.Query("since"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid since parameter", "details": err.Error()})
+			return
+		}
 
 		conn, err := upgrader.Upgrade(c.Writer, c.Request, nil)
 		if err != nil {
@@ -27,16 +34,15 @@ func WebSocketHandler(hub *Hub) gin.HandlerFunc {
 			return
 		}
 
-		client := &Client{
-			conn:   conn,
-			userID: userID,
-			send:   make(chan interface{}, 256),
-		}
+		client := newClient(conn, userID, sessionID, hub.config)
 
-		hub.AddClient(userID, client)
-		log.Println("Connected:", userID)
+		if hub.AddClient(client) {
+			go hub.userConnected(userID)
+		}
+		log.Println("Connected:", userID, "connection:", client.id)
 
 		go client.WritePump()
+		go client.replayUndelivered(since)
 		client.ReadPump(hub)
 	}
 }
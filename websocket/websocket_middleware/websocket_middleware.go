@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"net/http"
 
+	websocket_postgres "github.com/Ahmeds-Library/Chat-App/websocket_database/postgres"
 	"github.com/Ahmeds-Library/Chat-App/websocket_utils"
 	"github.com/gin-gonic/gin"
 )
@@ -39,5 +40,29 @@ func ValidateToken_WebSocket(C *gin.Context) (map[string]interface{}, bool) {
 		return nil, false
 	}
 
+	// A refresh token lives much longer and must only ever be sent to the
+	// back-end's refresh endpoint.
+	if tokenType, _ := claims["token_type"].(string); tokenType != "Access" {
+		C.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token type", "details": "Use a valid access token"})
+		return nil, false
+	}
+
+	// Revocations are only announced to connected clients, so a token whose
+	// session was revoked must be turned away here.
+	sessionID, _ := claims["sid"].(string)
+	if sessionID == "" {
+		C.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token", "details": "Log in again"})
+		return nil, false
+	}
+	active, err := websocket_postgres.SessionActive(sessionID)
+	if err != nil {
+		C.JSON(http.StatusInternalServerError, gin.H{"error": "Database error", "details": err.Error()})
+		return nil, false
+	}
+	if !active {
+		C.JSON(http.StatusUnauthorized, gin.H{"error": "Session ended", "details": "Log in again"})
+		return nil, false
+	}
+
 	return claims, true
 }
@@ -0,0 +1,50 @@
+package websocket_middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/Ahmeds-Library/Chat-App/websocket_utils"
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func TestValidateTokenRejectsRefreshTokens(t *testing.T) {
+	t.Setenv("JWKS_URL", "")
+	t.Setenv("JWT_PUBLIC_KEYS_DIR", "")
+	t.Setenv("JWT_SECRET", "test-secret")
+	keys, err := websocket_utils.NewKeySetFromEnv()
+	if err != nil {
+		t.Fatal(err)
+	}
+	previous := websocket_utils.Keys
+	websocket_utils.Keys = keys
+	t.Cleanup(func() { websocket_utils.Keys = previous })
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS512, jwt.MapClaims{
+		"id":         "user-1",
+		"sid":        "session-1",
+		"token_type": "refresh",
+		"exp":        time.Now().Add(time.Hour).Unix(),
+	})
+	token.Header["kid"] = "hs512"
+	signed, err := token.SignedString([]byte("test-secret"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	gin.SetMode(gin.TestMode)
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodGet, "/ws?token="+url.QueryEscape(signed), nil)
+
+	if _, ok := ValidateToken_WebSocket(c); ok {
+		t.Fatal("a refresh token was accepted")
+	}
+	if w.Code != http.StatusUnauthorized {
+		t.Fatalf("status %d, want 401", w.Code)
+	}
+}
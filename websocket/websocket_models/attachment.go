@@ -0,0 +1,20 @@
+package websocket_models
+
+import "go.mongodb.org/mongo-driver/bson/primitive"
+
+// Message_Attachment is the metadata of a file attached to a message. The
+// file itself is uploaded to, and downloaded from, the back-end, which also
+// fills in the media fields and serves the thumbnail.
+type Message_Attachment struct {
+	ID           primitive.ObjectID `bson:"id" json:"id"`
+	FileName     string             `bson:"file_name" json:"file_name"`
+	MimeType     string             `bson:"mime_type" json:"mime_type"`
+	Kind         string             `bson:"kind" json:"kind"`
+	Size         int64              `bson:"size" json:"size"`
+	Checksum     string             `bson:"checksum" json:"checksum"`
+	Width        int                `bson:"width,omitempty" json:"width,omitempty"`
+	Height       int                `bson:"height,omitempty" json:"height,omitempty"`
+	Duration     float64            `bson:"duration,omitempty" json:"duration,omitempty"`
+	Blurhash     string             `bson:"blurhash,omitempty" json:"blurhash,omitempty"`
+	HasThumbnail bool               `bson:"has_thumbnail,omitempty" json:"has_thumbnail"`
+}
@@ -0,0 +1,24 @@
+package websocket_models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Delivery_Cursor records how far one login session of a user has
+// acknowledged messages, so anything after it can be replayed when that
+// device reconnects. Each session keeps its own, so one device's acks do
+// not stop replays to another.
+type Delivery_Cursor struct {
+	ID        string `bson:"_id"`
+	UserID    string `bson:"user_id"`
+	SessionID string `bson:"session_id"`
+	// AckedAt is the creation time of the newest message acknowledged.
+	AckedAt   time.Time `bson:"acked_at"`
+	UpdatedAt time.Time `bson:"updated_at"`
+
+	// LastAckedID is set on cursors from before they were kept per session,
+	// which are stored under the user ID alone.
+	LastAckedID primitive.ObjectID `bson:"last_acked_id,omitempty"`
+}
@@ -0,0 +1,155 @@
+package websocket_models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// ProtocolVersion is the envelope version spoken by this server. Frames that
+// omit "v" are treated as the current version.
+const ProtocolVersion = 1
+
+// Frame types. "message" is used in both directions: clients send one to post
+// a chat message and the server pushes one to deliver it. "ack" is also used
+// in both directions: the server acknowledges client frames, and clients
+// acknowledge the messages they have received.
+const (
+	FrameMessage        = "message"
+	FrameEdited         = "edited"
+	FrameAck            = "ack"
+	FrameError          = "error"
+	FrameReplayDone     = "replay_done"
+	FrameRead           = "read"
+	FrameReceipt        = "receipt"
+	FrameTypingStart    = "typing_start"
+	FrameTypingStop     = "typing_stop"
+	FramePresence       = "presence"
+	FrameDelete         = "delete"
+	FrameDeleted        = "deleted"
+	FrameReactionAdd    = "reaction_add"
+	FrameReactionRemove = "reaction_remove"
+	FrameReactions      = "reactions"
+)
+
+// Delete scopes carried by "delete" and "deleted" frames.
+const (
+	DeleteForMe       = "me"
+	DeleteForEveryone = "everyone"
+)
+
+// Receipt statuses carried by "receipt" frames.
+const (
+	ReceiptDelivered = "delivered"
+	ReceiptRead      = "read"
+)
+
+type Envelope struct {
+	Version int             `json:"v"`
+	Type    string          `json:"type"`
+	ID      string          `json:"id,omitempty"`
+	Payload json.RawMessage `json:"payload,omitempty"`
+}
+
+func NewEnvelope(frameType, id string, payload interface{}) (Envelope, error) {
+	raw, err := json.Marshal(payload)
+	if err != nil {
+		return Envelope{}, err
+	}
+	return Envelope{
+		Version: ProtocolVersion,
+		Type:    frameType,
+		ID:      id,
+		Payload: raw,
+	}, nil
+}
+
+// Send_Message_Payload is addressed to ConversationID, a group or direct
+// conversation, or to ReceiverNumber for a direct message. ReplyTo quotes
+// another message in the conversation and ThreadID posts the message as a
+// thread reply to a group message. AttachmentID attaches a file uploaded to
+// the same conversation through the back-end, in which case Message may be
+// empty.
+type Send_Message_Payload struct {
+	ReceiverNumber string `json:"receiver_number"`
+	ConversationID string `json:"conversation_id,omitempty"`
+	Message        string `json:"message"`
+	ReplyTo        string `json:"reply_to,omitempty"`
+	ThreadID       string `json:"thread_id,omitempty"`
+	AttachmentID   string `json:"attachment_id,omitempty"`
+}
+
+type Ack_Payload struct {
+	MessageID string    `json:"message_id,omitempty"`
+	CreatedAt time.Time `json:"created_at,omitempty"`
+}
+
+// Read_Payload, like the other conversation frames, addresses
+// ConversationID or, when that is empty, the direct conversation with
+// PartnerNumber.
+type Read_Payload struct {
+	PartnerNumber  string `json:"partner_number,omitempty"`
+	ConversationID string `json:"conversation_id,omitempty"`
+	MessageID      string `json:"message_id"`
+}
+
+// Receipt_Payload tells a sender that UserID received or read their messages.
+type Receipt_Payload struct {
+	Status     string    `json:"status"`
+	UserID     string    `json:"user_id"`
+	MessageIDs []string  `json:"message_ids"`
+	At         time.Time `json:"at"`
+}
+
+// Typing_Payload carries PartnerNumber or ConversationID from the client,
+// and ConversationID with UserID, the typist, to the other participants.
+type Typing_Payload struct {
+	PartnerNumber  string `json:"partner_number,omitempty"`
+	ConversationID string `json:"conversation_id,omitempty"`
+	UserID         string `json:"user_id,omitempty"`
+}
+
+type Presence_Payload struct {
+	UserID   string     `json:"user_id"`
+	Online   bool       `json:"online"`
+	LastSeen *time.Time `json:"last_seen,omitempty"`
+}
+
+type Replay_Done_Payload struct {
+	Count     int    `json:"count"`
+	LastID    string `json:"last_id,omitempty"`
+	Truncated bool   `json:"truncated"`
+}
+
+type Error_Payload struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// Delete_Payload asks the server to delete MessageID for the sender of the
+// frame only, or for everyone.
+type Delete_Payload struct {
+	MessageID string `json:"message_id"`
+	Scope     string `json:"scope"`
+}
+
+// Deleted_Payload tells clients to hide a message. Scope "me" is only sent to
+// the deleting user's own connections.
+type Deleted_Payload struct {
+	MessageID      string    `json:"message_id"`
+	ConversationID string    `json:"conversation_id,omitempty"`
+	Scope          string    `json:"scope"`
+	DeletedAt      time.Time `json:"deleted_at"`
+}
+
+// Reaction_Payload adds or removes the sender's Emoji on MessageID.
+type Reaction_Payload struct {
+	MessageID string `json:"message_id"`
+	Emoji     string `json:"emoji"`
+}
+
+// Reactions_Payload carries every reaction on a message after one changed.
+type Reactions_Payload struct {
+	MessageID      string             `json:"message_id"`
+	ConversationID string             `json:"conversation_id,omitempty"`
+	Reactions      []Reaction_Summary `json:"reactions"`
+}
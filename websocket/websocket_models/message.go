@@ -7,9 +7,48 @@ import (
 )
 
 type Save_Message struct {
-	ID         primitive.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
-	SenderID   string             `bson:"sender_id" json:"sender_id"`
-	ReceiverID string             `bson:"receiver_id" json:"receiver_id"`
-	Message    string             `bson:"message" json:"message"`
-	CreatedAt  time.Time          `bson:"created_at" json:"created_at"`
+	ID             primitive.ObjectID  `bson:"_id,omitempty" json:"id,omitempty"`
+	SenderID       string              `bson:"sender_id" json:"sender_id"`
+	ReceiverID     string              `bson:"receiver_id,omitempty" json:"receiver_id,omitempty"`
+	ConversationID string              `bson:"conversation_id,omitempty" json:"conversation_id,omitempty"`
+	Message        string              `bson:"message" json:"message"`
+	Attachment     *Message_Attachment `bson:"attachment,omitempty" json:"attachment,omitempty"`
+	ReplyTo        *Message_Reply      `bson:"reply_to,omitempty" json:"reply_to,omitempty"`
+	ThreadID       *primitive.ObjectID `bson:"thread_id,omitempty" json:"thread_id,omitempty"`
+	Reactions      []Message_Reaction  `bson:"reactions,omitempty" json:"-"`
+	CreatedAt      time.Time           `bson:"created_at" json:"created_at"`
+	Edited         bool                `bson:"edited,omitempty" json:"edited,omitempty"`
+	UpdatedTime    *time.Time          `bson:"updated_time,omitempty" json:"updated_time,omitempty"`
+	DeliveredAt    *time.Time          `bson:"delivered_at,omitempty" json:"delivered_at,omitempty"`
+	ReadAt         *time.Time          `bson:"read_at,omitempty" json:"read_at,omitempty"`
+	Deleted        bool                `bson:"deleted,omitempty" json:"deleted,omitempty"`
+	DeletedAt      *time.Time          `bson:"deleted_at,omitempty" json:"deleted_at,omitempty"`
+	DeletedFor     []string            `bson:"deleted_for,omitempty" json:"-"`
+	Change         *Message_Change     `bson:"change,omitempty" json:"-"`
+}
+
+// Message_Reply is a snapshot of the quoted message taken when the reply is
+// sent.
+type Message_Reply struct {
+	MessageID primitive.ObjectID `bson:"message_id" json:"message_id"`
+	SenderID  string             `bson:"sender_id" json:"sender_id"`
+	Message   string             `bson:"message" json:"message"`
+	CreatedAt time.Time          `bson:"created_at" json:"created_at"`
+}
+
+// Kinds of changes the back-end stamps on messages it writes.
+const (
+	ChangeCreated = "created"
+	ChangeEdited  = "edited"
+	ChangeRead    = "read"
+	ChangeDeleted = "deleted"
+	ChangeReacted = "reacted"
+)
+
+// Message_Change is the stamp the back-end leaves on a message whenever it
+// creates or modifies it through the REST API.
+type Message_Change struct {
+	Kind string             `bson:"kind" json:"kind"`
+	ID   primitive.ObjectID `bson:"id" json:"id"`
+	At   time.Time          `bson:"at" json:"at"`
 }
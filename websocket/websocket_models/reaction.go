@@ -0,0 +1,35 @@
+package websocket_models
+
+import "time"
+
+// Message_Reaction is one user's emoji on a message.
+type Message_Reaction struct {
+	UserID string    `bson:"user_id" json:"user_id"`
+	Emoji  string    `bson:"emoji" json:"emoji"`
+	At     time.Time `bson:"at" json:"at"`
+}
+
+// Reaction_Summary is the aggregated count for one emoji on a message.
+type Reaction_Summary struct {
+	Emoji   string   `json:"emoji"`
+	Count   int      `json:"count"`
+	UserIDs []string `json:"user_ids"`
+}
+
+// SummarizeReactions groups reactions by emoji, in the order each emoji was
+// first used.
+func SummarizeReactions(reactions []Message_Reaction) []Reaction_Summary {
+	summaries := []Reaction_Summary{}
+	index := make(map[string]int)
+	for _, reaction := range reactions {
+		i, ok := index[reaction.Emoji]
+		if !ok {
+			i = len(summaries)
+			index[reaction.Emoji] = i
+			summaries = append(summaries, Reaction_Summary{Emoji: reaction.Emoji})
+		}
+		summaries[i].Count++
+		summaries[i].UserIDs = append(summaries[i].UserIDs, reaction.UserID)
+	}
+	return summaries
+}
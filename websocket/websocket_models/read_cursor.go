@@ -0,0 +1,19 @@
+package websocket_models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Read_Cursor records how far one member of a group has received and read
+// it. Group messages are shared by every member, so their delivered_at and
+// read_at cannot say who has seen them.
+type Read_Cursor struct {
+	ID             string             `bson:"_id"`
+	ConversationID string             `bson:"conversation_id"`
+	UserID         string             `bson:"user_id"`
+	DeliveredUpTo  primitive.ObjectID `bson:"delivered_up_to,omitempty"`
+	ReadUpTo       primitive.ObjectID `bson:"read_up_to,omitempty"`
+	UpdatedAt      time.Time          `bson:"updated_at"`
+}
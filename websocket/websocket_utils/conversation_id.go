@@ -0,0 +1,59 @@
+package websocket_utils
+
+import (
+	"strconv"
+	"strings"
+)
+
+const directConversationPrefix = "dm:"
+
+// DirectConversationID returns the conversation ID shared by two users. It is
+// the same whichever user is passed first. Group conversations use their
+// numeric Postgres ID instead.
+func DirectConversationID(userID, partnerID string) string {
+	if lessUserID(partnerID, userID) {
+		userID, partnerID = partnerID, userID
+	}
+	return directConversationPrefix + userID + ":" + partnerID
+}
+
+func IsDirectConversation(conversationID string) bool {
+	return strings.HasPrefix(conversationID, directConversationPrefix)
+}
+
+// DirectConversationUsers returns the two users of a direct conversation ID.
+func DirectConversationUsers(conversationID string) (string, string, bool) {
+	if !IsDirectConversation(conversationID) {
+		return "", "", false
+	}
+	parts := strings.Split(strings.TrimPrefix(conversationID, directConversationPrefix), ":")
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return "", "", false
+	}
+	return parts[0], parts[1], true
+}
+
+// DirectConversationPartner returns the user userID talks to in a direct
+// conversation, or false if userID is not one of its users.
+func DirectConversationPartner(conversationID, userID string) (string, bool) {
+	a, b, ok := DirectConversationUsers(conversationID)
+	switch {
+	case !ok:
+		return "", false
+	case a == userID:
+		return b, true
+	case b == userID:
+		return a, true
+	}
+	return "", false
+}
+
+// lessUserID orders numeric IDs by value so "9" sorts before "10".
+func lessUserID(a, b string) bool {
+	ai, errA := strconv.ParseInt(a, 10, 64)
+	bi, errB := strconv.ParseInt(b, 10, 64)
+	if errA == nil && errB == nil {
+		return ai < bi
+	}
+	return a < b
+}
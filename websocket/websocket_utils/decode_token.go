@@ -1,18 +1,9 @@
 package websocket_utils
 
-import (
-	"errors"
-
-	"github.com/golang-jwt/jwt/v5"
-)
+import "github.com/golang-jwt/jwt/v5"
 
 func DecodeToken(tokenString string) (jwt.MapClaims, error) {
-	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, errors.New("unexpected signing method")
-		}
-		return SecretKey, nil
-	})
+	token, err := jwt.Parse(tokenString, Keys.Keyfunc)
 
 	if err != nil {
 		return nil, err
@@ -0,0 +1,26 @@
+package websocket_utils
+
+import (
+	"log"
+	"os"
+	"time"
+)
+
+// DefaultDeleteWindow is how long after sending a message its sender may
+// delete it for everyone.
+const DefaultDeleteWindow = 48 * time.Hour
+
+// DeleteWindow reads DELETE_FOR_EVERYONE_WINDOW, a duration such as "1h".
+// Zero removes the limit.
+func DeleteWindow() time.Duration {
+	value := os.Getenv("DELETE_FOR_EVERYONE_WINDOW")
+	if value == "" {
+		return DefaultDeleteWindow
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil || d < 0 {
+		log.Println("Invalid DELETE_FOR_EVERYONE_WINDOW, using default:", value)
+		return DefaultDeleteWindow
+	}
+	return d
+}
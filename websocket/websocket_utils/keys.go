@@ -0,0 +1,217 @@
+package websocket_utils
+
+import (
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	// jwksMaxAge is how long fetched keys are trusted before fetching again,
+	// which is how retired keys stop being accepted.
+	jwksMaxAge = 10 * time.Minute
+	// jwksMinInterval stops tokens with made-up key IDs from hammering the
+	// back-end.
+	jwksMinInterval = 30 * time.Second
+)
+
+// Keys verifies the tokens clients connect with.
+var Keys *KeySet
+
+type verifyKey struct {
+	alg string
+	key any
+}
+
+// KeySet holds the keys tokens are accepted from. Keys from a JWKS URL are
+// refetched when a token names an unknown key and when they get old, so the
+// back-end can rotate keys without a restart here.
+type KeySet struct {
+	mu        sync.RWMutex
+	keys      map[string]verifyKey
+	jwksURL   string
+	client    *http.Client
+	fetchedAt time.Time
+	triedAt   time.Time
+}
+
+// NewKeySetFromEnv loads the verification keys from JWKS_URL, normally the
+// back-end's /.well-known/jwks.json; from JWT_PUBLIC_KEYS_DIR, a directory of
+// PEM public keys named <kid>.pem; or from JWT_SECRET, the secret the
+// back-end signs HS512 tokens with.
+func NewKeySetFromEnv() (*KeySet, error) {
+	set := &KeySet{keys: make(map[string]verifyKey)}
+
+	switch {
+	case os.Getenv("JWKS_URL") != "":
+		set.jwksURL = os.Getenv("JWKS_URL")
+		set.client = &http.Client{Timeout: 5 * time.Second}
+		// The back-end may still be starting; keys are fetched on demand.
+		if err := set.refresh(); err != nil {
+			log.Println("JWKS not available yet:", err)
+		}
+	case os.Getenv("JWT_PUBLIC_KEYS_DIR") != "":
+		if err := set.loadDir(os.Getenv("JWT_PUBLIC_KEYS_DIR")); err != nil {
+			return nil, err
+		}
+	case os.Getenv("JWT_SECRET") != "":
+		set.keys["hs512"] = verifyKey{alg: jwt.SigningMethodHS512.Alg(), key: []byte(os.Getenv("JWT_SECRET"))}
+	default:
+		return nil, errors.New("set JWKS_URL, JWT_PUBLIC_KEYS_DIR or JWT_SECRET to verify tokens")
+	}
+	return set, nil
+}
+
+// Keyfunc finds the key a token was signed with, checking that the token
+// uses that key's algorithm.
+func (s *KeySet) Keyfunc(token *jwt.Token) (any, error) {
+	kid, _ := token.Header["kid"].(string)
+
+	key, ok, stale := s.lookup(kid)
+	if (!ok || stale) && s.jwksURL != "" {
+		if err := s.refresh(); err != nil && !ok {
+			return nil, err
+		}
+		key, ok, _ = s.lookup(kid)
+	}
+	if !ok {
+		return nil, fmt.Errorf("unknown signing key %q", kid)
+	}
+	if token.Method.Alg() != key.alg {
+		return nil, fmt.Errorf("unexpected signing method %s", token.Method.Alg())
+	}
+	return key.key, nil
+}
+
+func (s *KeySet) lookup(kid string) (verifyKey, bool, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	key, ok := s.keys[kid]
+	return key, ok, s.jwksURL != "" && time.Since(s.fetchedAt) > jwksMaxAge
+}
+
+// refresh replaces the keys with those currently published at the JWKS URL.
+// The fetch happens without the lock, so tokens signed with keys already
+// known keep verifying while it is in flight.
+func (s *KeySet) refresh() error {
+	s.mu.Lock()
+	if time.Since(s.triedAt) < jwksMinInterval {
+		s.mu.Unlock()
+		return nil
+	}
+	s.triedAt = time.Now()
+	s.mu.Unlock()
+
+	keys, err := s.fetch()
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	s.keys = keys
+	s.fetchedAt = time.Now()
+	s.mu.Unlock()
+	return nil
+}
+
+// fetch downloads and parses the keys published at the JWKS URL.
+func (s *KeySet) fetch() (map[string]verifyKey, error) {
+	resp, err := s.client.Get(s.jwksURL)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("JWKS request failed: %s", resp.Status)
+	}
+
+	var set struct {
+		Keys []struct {
+			Kty string `json:"kty"`
+			Kid string `json:"kid"`
+			Alg string `json:"alg"`
+			N   string `json:"n"`
+			E   string `json:"e"`
+			Crv string `json:"crv"`
+			X   string `json:"x"`
+		} `json:"keys"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&set); err != nil {
+		return nil, err
+	}
+
+	keys := make(map[string]verifyKey, len(set.Keys))
+	for _, jwk := range set.Keys {
+		switch {
+		case jwk.Kty == "RSA":
+			n, errN := base64.RawURLEncoding.DecodeString(jwk.N)
+			e, errE := base64.RawURLEncoding.DecodeString(jwk.E)
+			if errN != nil || errE != nil {
+				return nil, fmt.Errorf("invalid RSA key %q", jwk.Kid)
+			}
+			keys[jwk.Kid] = verifyKey{
+				alg: jwt.SigningMethodRS256.Alg(),
+				key: &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())},
+			}
+		case jwk.Kty == "OKP" && jwk.Crv == "Ed25519":
+			x, err := base64.RawURLEncoding.DecodeString(jwk.X)
+			if err != nil || len(x) != ed25519.PublicKeySize {
+				return nil, fmt.Errorf("invalid Ed25519 key %q", jwk.Kid)
+			}
+			keys[jwk.Kid] = verifyKey{alg: jwt.SigningMethodEdDSA.Alg(), key: ed25519.PublicKey(x)}
+		}
+	}
+	return keys, nil
+}
+
+func (s *KeySet) loadDir(dir string) error {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.pem"))
+	if err != nil {
+		return err
+	}
+
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		block, _ := pem.Decode(data)
+		if block == nil || block.Type != "PUBLIC KEY" {
+			return fmt.Errorf("%s: not a PEM public key", path)
+		}
+		public, err := x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+
+		kid := strings.TrimSuffix(filepath.Base(path), ".pem")
+		switch public := public.(type) {
+		case *rsa.PublicKey:
+			s.keys[kid] = verifyKey{alg: jwt.SigningMethodRS256.Alg(), key: public}
+		case ed25519.PublicKey:
+			s.keys[kid] = verifyKey{alg: jwt.SigningMethodEdDSA.Alg(), key: public}
+		default:
+			return fmt.Errorf("%s: unsupported key type %T", path, public)
+		}
+	}
+
+	if len(s.keys) == 0 {
+		return fmt.Errorf("no public keys in %s", dir)
+	}
+	return nil
+}
@@ -0,0 +1,83 @@
+package websocket_utils
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func signedToken(t *testing.T, kid string, key ed25519.PrivateKey) *jwt.Token {
+	t.Helper()
+
+	token := jwt.NewWithClaims(jwt.SigningMethodEdDSA, jwt.MapClaims{"sub": "user"})
+	token.Header["kid"] = kid
+	signed, err := token.SignedString(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	parsed, _, err := jwt.NewParser().ParseUnverified(signed, jwt.MapClaims{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return parsed
+}
+
+// A token naming an unknown key makes the set refetch the JWKS; tokens with
+// known keys must not wait for that fetch.
+func TestKeyfuncDoesNotWaitForRefresh(t *testing.T) {
+	known, knownPrivate, _ := ed25519.GenerateKey(rand.Reader)
+	rotated, rotatedPrivate, _ := ed25519.GenerateKey(rand.Reader)
+
+	release := make(chan struct{})
+	fetching := make(chan struct{}, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fetching <- struct{}{}
+		<-release
+		json.NewEncoder(w).Encode(map[string]any{"keys": []map[string]string{
+			{"kty": "OKP", "crv": "Ed25519", "kid": "known", "x": base64.RawURLEncoding.EncodeToString(known)},
+			{"kty": "OKP", "crv": "Ed25519", "kid": "rotated", "x": base64.RawURLEncoding.EncodeToString(rotated)},
+		}})
+	}))
+	defer server.Close()
+	defer close(release)
+
+	set := &KeySet{
+		keys:      map[string]verifyKey{"known": {alg: jwt.SigningMethodEdDSA.Alg(), key: known}},
+		jwksURL:   server.URL,
+		client:    server.Client(),
+		fetchedAt: time.Now(),
+	}
+
+	refreshed := make(chan error, 1)
+	go func() {
+		_, err := set.Keyfunc(signedToken(t, "rotated", rotatedPrivate))
+		refreshed <- err
+	}()
+	<-fetching
+
+	verified := make(chan error, 1)
+	go func() {
+		_, err := set.Keyfunc(signedToken(t, "known", knownPrivate))
+		verified <- err
+	}()
+	select {
+	case err := <-verified:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("a known key waited for the JWKS fetch")
+	}
+
+	release <- struct{}{}
+	if err := <-refreshed; err != nil {
+		t.Fatalf("rotated key: %v", err)
+	}
+}
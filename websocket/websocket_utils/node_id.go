@@ -0,0 +1,27 @@
+package websocket_utils
+
+import "os"
+
+// NodeID identifies this websocket replica. It defaults to the hostname,
+// which is the pod name under Kubernetes.
+func NodeID() string {
+	if id := os.Getenv("WS_NODE_ID"); id != "" {
+		return id
+	}
+	if host, err := os.Hostname(); err == nil && host != "" {
+		return host
+	}
+	return "websocket"
+}
+
+// RelayCheckpoint names the saved position of the message change relay. It
+// must survive restarts, so unlike NodeID it does not default to the
+// hostname: replicas share the default and resume from the latest position
+// any of them saved. Clients catch up on anything else through replay when
+// they reconnect.
+func RelayCheckpoint() string {
+	if name := os.Getenv("WS_RELAY_CHECKPOINT"); name != "" {
+		return name
+	}
+	return "messages"
+}